@@ -0,0 +1,118 @@
+package blockstore
+
+import (
+	"errors"
+
+	blocks "gx/ipfs/QmYsEQydGrsxNZfAiskvQ76N2xE9hDQtSAkRSynwMiUK3c/go-block-format"
+	cid "gx/ipfs/QmeSrf6pzut73u6zLQkRFQ3ygt3k6XFT2kjdYP8Tnkwwyg/go-cid"
+)
+
+// ErrWriteBarrierActive is returned by StartWriteBarrier when another
+// barrier has not been stopped yet.
+var ErrWriteBarrierActive = errors.New("blockstore: a write barrier is already active")
+
+// ErrNoWriteBarrier is returned when the GCLocker of a GCBlockstore can not
+// record writes.
+var ErrNoWriteBarrier = errors.New("blockstore: locker does not support write barriers")
+
+// WriteBarrier records the blocks written through a GCBlockstore while a
+// garbage collector marks without holding the GCLock.
+type WriteBarrier interface {
+	// Written returns the cids of the blocks written since the previous
+	// call, or since the barrier was started.
+	Written() []*cid.Cid
+
+	// Stop stops recording writes.
+	Stop()
+}
+
+// ConcurrentGCLocker is a GCLocker that can start write barriers.
+type ConcurrentGCLocker interface {
+	GCLocker
+
+	// StartWriteBarrier starts recording every block written through a
+	// GCBlockstore using this locker. Only one barrier can be active at a
+	// time.
+	StartWriteBarrier() (WriteBarrier, error)
+}
+
+// ConcurrentGCBlockstore is a GCBlockstore that can run garbage collections
+// concurrently with writes.
+type ConcurrentGCBlockstore interface {
+	GCBlockstore
+
+	StartWriteBarrier() (WriteBarrier, error)
+}
+
+// writeRecorder is implemented by GCLockers with write barriers, and
+// notified by the gcBlockstore of every block about to be written.
+type writeRecorder interface {
+	recordWrite(*cid.Cid)
+}
+
+func (bs gcBlockstore) Put(b blocks.Block) error {
+	if r, ok := bs.GCLocker.(writeRecorder); ok {
+		r.recordWrite(b.Cid())
+	}
+	return bs.Blockstore.Put(b)
+}
+
+func (bs gcBlockstore) PutMany(blks []blocks.Block) error {
+	if r, ok := bs.GCLocker.(writeRecorder); ok {
+		for _, b := range blks {
+			r.recordWrite(b.Cid())
+		}
+	}
+	return bs.Blockstore.PutMany(blks)
+}
+
+func (bs gcBlockstore) StartWriteBarrier() (WriteBarrier, error) {
+	l, ok := bs.GCLocker.(ConcurrentGCLocker)
+	if !ok {
+		return nil, ErrNoWriteBarrier
+	}
+	return l.StartWriteBarrier()
+}
+
+func (bs *gclocker) StartWriteBarrier() (WriteBarrier, error) {
+	bs.barrierLk.Lock()
+	defer bs.barrierLk.Unlock()
+
+	if bs.barrier != nil {
+		return nil, ErrWriteBarrierActive
+	}
+	bs.barrier = &writeBarrier{l: bs}
+	return bs.barrier, nil
+}
+
+func (bs *gclocker) recordWrite(c *cid.Cid) {
+	bs.barrierLk.Lock()
+	defer bs.barrierLk.Unlock()
+
+	if bs.barrier != nil {
+		bs.barrier.written = append(bs.barrier.written, c)
+	}
+}
+
+type writeBarrier struct {
+	l       *gclocker
+	written []*cid.Cid
+}
+
+func (wb *writeBarrier) Written() []*cid.Cid {
+	wb.l.barrierLk.Lock()
+	defer wb.l.barrierLk.Unlock()
+
+	out := wb.written
+	wb.written = nil
+	return out
+}
+
+func (wb *writeBarrier) Stop() {
+	wb.l.barrierLk.Lock()
+	defer wb.l.barrierLk.Unlock()
+
+	if wb.l.barrier == wb {
+		wb.l.barrier = nil
+	}
+}
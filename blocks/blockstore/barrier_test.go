@@ -0,0 +1,75 @@
+package blockstore
+
+import (
+	"testing"
+
+	blocks "gx/ipfs/QmYsEQydGrsxNZfAiskvQ76N2xE9hDQtSAkRSynwMiUK3c/go-block-format"
+	ds "gx/ipfs/QmdHG8MAuARdGHxx4rPQASLcvhz24fzjSQq7AJRAQEorq5/go-datastore"
+	ds_sync "gx/ipfs/QmdHG8MAuARdGHxx4rPQASLcvhz24fzjSQq7AJRAQEorq5/go-datastore/sync"
+)
+
+func TestWriteBarrier(t *testing.T) {
+	bs := NewGCBlockstore(NewBlockstore(ds_sync.MutexWrap(ds.NewMapDatastore())), NewGCLocker())
+	cbs, ok := bs.(ConcurrentGCBlockstore)
+	if !ok {
+		t.Fatal("expected default gc blockstore to support write barriers")
+	}
+
+	before := blocks.NewBlock([]byte("before"))
+	if err := bs.Put(before); err != nil {
+		t.Fatal(err)
+	}
+
+	wb, err := cbs.StartWriteBarrier()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := cbs.StartWriteBarrier(); err != ErrWriteBarrierActive {
+		t.Fatalf("expected ErrWriteBarrierActive, got %v", err)
+	}
+
+	b1 := blocks.NewBlock([]byte("one"))
+	b2 := blocks.NewBlock([]byte("two"))
+	if err := bs.Put(b1); err != nil {
+		t.Fatal(err)
+	}
+	if err := bs.PutMany([]blocks.Block{b2}); err != nil {
+		t.Fatal(err)
+	}
+
+	written := wb.Written()
+	if len(written) != 2 || !written[0].Equals(b1.Cid()) || !written[1].Equals(b2.Cid()) {
+		t.Fatalf("unexpected written blocks: %v", written)
+	}
+
+	if w := wb.Written(); len(w) != 0 {
+		t.Fatalf("expected Written to drain the barrier, got %v", w)
+	}
+
+	wb.Stop()
+	if err := bs.Put(blocks.NewBlock([]byte("after"))); err != nil {
+		t.Fatal(err)
+	}
+	if w := wb.Written(); len(w) != 0 {
+		t.Fatalf("expected nothing recorded after Stop, got %v", w)
+	}
+
+	wb, err = cbs.StartWriteBarrier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wb.Stop()
+}
+
+type plainLocker struct {
+	GCLocker
+}
+
+func TestWriteBarrierUnsupported(t *testing.T) {
+	bs := NewGCBlockstore(NewBlockstore(ds_sync.MutexWrap(ds.NewMapDatastore())), plainLocker{NewGCLocker()})
+	cbs := bs.(ConcurrentGCBlockstore)
+	if _, err := cbs.StartWriteBarrier(); err != ErrNoWriteBarrier {
+		t.Fatalf("expected ErrNoWriteBarrier, got %v", err)
+	}
+}
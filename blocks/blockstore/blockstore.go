@@ -242,6 +242,9 @@ func NewGCLocker() GCLocker {
 type gclocker struct {
 	lk    sync.RWMutex
 	gcreq int32
+
+	barrierLk sync.Mutex
+	barrier   *writeBarrier
 }
 
 // Unlocker represents an object which can Unlock
@@ -0,0 +1,240 @@
+// Package car implements a simple content-addressed archive format for
+// moving DAGs in and out of a node. An archive is a varint length prefixed
+// cbor header listing the root CIDs, followed by a sequence of sections,
+// each a varint length prefix followed by a CID and the raw block data.
+package car
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	dag "github.com/ipfs/go-ipfs/merkledag"
+
+	node "gx/ipfs/QmNwUEK7QbwSqyKBu3mMtToo8SUc6wQJ7gdZq4gGGJqfnf/go-ipld-format"
+	blocks "gx/ipfs/QmYsEQydGrsxNZfAiskvQ76N2xE9hDQtSAkRSynwMiUK3c/go-block-format"
+	cid "gx/ipfs/QmeSrf6pzut73u6zLQkRFQ3ygt3k6XFT2kjdYP8Tnkwwyg/go-cid"
+	cbor "gx/ipfs/QmeZv9VXw2SfVbX55LV6kGTWASKBc9ZxAVqGBeJcDGdoXy/go-ipld-cbor"
+)
+
+// Version is the archive format version written by WriteCar.
+const Version = 1
+
+// MaxSectionSize bounds the size of a single header or block section read
+// from an archive.
+const MaxSectionSize = 4 << 20
+
+var ErrNoRoots = errors.New("car: archive has no roots")
+var ErrSectionTooLarge = errors.New("car: section exceeds maximum size")
+
+var errInvalidCid = errors.New("car: invalid cid in section")
+
+func init() {
+	cbor.RegisterCborType(CarHeader{})
+}
+
+// CarHeader is the first section of every archive.
+type CarHeader struct {
+	Roots   []*cid.Cid `refmt:"roots"`
+	Version uint64     `refmt:"version"`
+}
+
+// WriteCar writes the DAGs below the given roots to w. Every block is
+// written exactly once, in depth-first pre-order.
+func WriteCar(ctx context.Context, ds dag.DAGService, roots []*cid.Cid, w io.Writer) error {
+	if len(roots) == 0 {
+		return ErrNoRoots
+	}
+
+	hb, err := cbor.DumpObject(&CarHeader{Roots: roots, Version: Version})
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	if err := writeSection(bw, hb); err != nil {
+		return err
+	}
+
+	seen := cid.NewSet()
+	for _, r := range roots {
+		if err := writeDag(ctx, ds, r, seen, bw); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+func writeDag(ctx context.Context, ds dag.DAGService, c *cid.Cid, seen *cid.Set, w io.Writer) error {
+	if !seen.Visit(c) {
+		return nil
+	}
+
+	nd, err := ds.Get(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	if err := writeSection(w, nd.Cid().Bytes(), nd.RawData()); err != nil {
+		return err
+	}
+
+	for _, l := range nd.Links() {
+		if err := writeDag(ctx, ds, l.Cid, seen, w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeSection(w io.Writer, parts ...[]byte) error {
+	var sum int
+	for _, p := range parts {
+		sum += len(p)
+	}
+
+	buf := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(buf, uint64(sum))
+	if _, err := w.Write(buf[:n]); err != nil {
+		return err
+	}
+
+	for _, p := range parts {
+		if _, err := w.Write(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadCar reads an archive from r and adds all of its blocks to ds. Every
+// block is checked against its CID before it is added.
+func LoadCar(ds dag.DAGService, r io.Reader) (*CarHeader, error) {
+	br := bufio.NewReader(r)
+
+	hb, err := readSection(br)
+	if err != nil {
+		return nil, err
+	}
+	if hb == nil {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	var h CarHeader
+	if err := cbor.DecodeInto(hb, &h); err != nil {
+		return nil, fmt.Errorf("car: invalid header: %s", err)
+	}
+	if h.Version != Version {
+		return nil, fmt.Errorf("car: unsupported version %d", h.Version)
+	}
+	if len(h.Roots) == 0 {
+		return nil, ErrNoRoots
+	}
+
+	batch := ds.Batch()
+	for {
+		data, err := readSection(br)
+		if err != nil {
+			return nil, err
+		}
+		if data == nil {
+			break
+		}
+
+		nd, err := decodeSection(data)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := batch.Add(nd); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := batch.Commit(); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+// readSection returns the next section of the archive, or nil at a clean
+// end of input.
+func readSection(br *bufio.Reader) ([]byte, error) {
+	l, err := binary.ReadUvarint(br)
+	switch err {
+	case nil:
+	case io.EOF:
+		return nil, nil
+	default:
+		return nil, err
+	}
+
+	if l > MaxSectionSize {
+		return nil, ErrSectionTooLarge
+	}
+
+	buf := make([]byte, l)
+	if _, err := io.ReadFull(br, buf); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return buf, nil
+}
+
+func decodeSection(data []byte) (node.Node, error) {
+	n, err := cidLen(data)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := cid.Cast(data[:n])
+	if err != nil {
+		return nil, err
+	}
+
+	raw := data[n:]
+	sum, err := c.Prefix().Sum(raw)
+	if err != nil {
+		return nil, err
+	}
+	if !sum.Equals(c) {
+		return nil, fmt.Errorf("car: block data does not match cid %s", c)
+	}
+
+	b, err := blocks.NewBlockWithCid(raw, c)
+	if err != nil {
+		return nil, err
+	}
+	return node.Decode(b)
+}
+
+// cidLen returns the length of the binary CID at the start of data.
+func cidLen(data []byte) (int, error) {
+	// CIDv0 is a bare sha2-256 multihash
+	if len(data) >= 34 && data[0] == 0x12 && data[1] == 0x20 {
+		return 34, nil
+	}
+
+	var off int
+	// version, codec, multihash code, multihash length
+	for i := 0; i < 4; i++ {
+		v, n := binary.Uvarint(data[off:])
+		if n <= 0 {
+			return 0, errInvalidCid
+		}
+		off += n
+		if i == 3 {
+			if v > uint64(len(data)-off) {
+				return 0, errInvalidCid
+			}
+			off += int(v)
+		}
+	}
+	return off, nil
+}
@@ -0,0 +1,95 @@
+package car
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	dag "github.com/ipfs/go-ipfs/merkledag"
+	dstest "github.com/ipfs/go-ipfs/merkledag/test"
+
+	node "gx/ipfs/QmNwUEK7QbwSqyKBu3mMtToo8SUc6wQJ7gdZq4gGGJqfnf/go-ipld-format"
+	cid "gx/ipfs/QmeSrf6pzut73u6zLQkRFQ3ygt3k6XFT2kjdYP8Tnkwwyg/go-cid"
+)
+
+func mkTestDag(t *testing.T, ds dag.DAGService) []*cid.Cid {
+	a := dag.NodeWithData([]byte("a"))
+	b := dag.NewRawNode([]byte("b"))
+	root := dag.NodeWithData([]byte("root"))
+	if err := root.AddNodeLink("a", a); err != nil {
+		t.Fatal(err)
+	}
+	if err := root.AddNodeLink("b", b); err != nil {
+		t.Fatal(err)
+	}
+	// shared child, must only be written once
+	other := dag.NodeWithData([]byte("other"))
+	if err := other.AddNodeLink("a", a); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, nd := range []node.Node{a, b, root, other} {
+		if _, err := ds.Add(nd); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return []*cid.Cid{root.Cid(), other.Cid()}
+}
+
+func TestRoundtrip(t *testing.T) {
+	ctx := context.Background()
+	src := dstest.Mock()
+	roots := mkTestDag(t, src)
+
+	buf := new(bytes.Buffer)
+	if err := WriteCar(ctx, src, roots, buf); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := dstest.Mock()
+	h, err := LoadCar(dst, bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(h.Roots) != len(roots) {
+		t.Fatalf("expected %d roots, got %d", len(roots), len(h.Roots))
+	}
+	for i, r := range roots {
+		if !h.Roots[i].Equals(r) {
+			t.Fatalf("root %d mismatch: %s != %s", i, h.Roots[i], r)
+		}
+		if err := dag.FetchGraph(ctx, r, dst); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoadCorrupt(t *testing.T) {
+	ctx := context.Background()
+	src := dstest.Mock()
+	roots := mkTestDag(t, src)
+
+	buf := new(bytes.Buffer)
+	if err := WriteCar(ctx, src, roots, buf); err != nil {
+		t.Fatal(err)
+	}
+
+	data := buf.Bytes()
+	data[len(data)-1] ^= 0xff
+
+	if _, err := LoadCar(dstest.Mock(), bytes.NewReader(data)); err == nil {
+		t.Fatal("expected corrupt archive to fail")
+	}
+
+	if _, err := LoadCar(dstest.Mock(), bytes.NewReader(data[:len(data)-3])); err == nil {
+		t.Fatal("expected truncated archive to fail")
+	}
+}
+
+func TestWriteNoRoots(t *testing.T) {
+	err := WriteCar(context.Background(), dstest.Mock(), nil, new(bytes.Buffer))
+	if err != ErrNoRoots {
+		t.Fatalf("expected ErrNoRoots, got %v", err)
+	}
+}
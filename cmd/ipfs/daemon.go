@@ -382,6 +382,9 @@ func daemonFunc(req cmds.Request, re cmds.ResponseEmitter) {
 		return
 	}
 
+	// remove pins added with a ttl once they expire
+	expiryErrc := runPinExpiry(req, node)
+
 	// construct http gateway - if it is set in the config
 	var gwErrc <-chan error
 	if len(cfg.Addresses.Gateway) > 0 {
@@ -399,7 +402,7 @@ func daemonFunc(req cmds.Request, re cmds.ResponseEmitter) {
 	fmt.Printf("Daemon is ready\n")
 	// collect long-running errors and block for shutdown
 	// TODO(cryptix): our fuse currently doesnt follow this pattern for graceful shutdown
-	for err := range merge(apiErrc, gwErrc, gcErrc) {
+	for err := range merge(apiErrc, gwErrc, gcErrc, expiryErrc) {
 		if err != nil {
 			log.Error(err)
 			re.SetError(err, cmdkit.ErrNormal)
@@ -623,6 +626,15 @@ func maybeRunGC(req cmds.Request, node *core.IpfsNode) (error, <-chan error) {
 	return nil, errc
 }
 
+func runPinExpiry(req cmds.Request, node *core.IpfsNode) <-chan error {
+	errc := make(chan error)
+	go func() {
+		errc <- corerepo.PeriodicUnpinExpired(req.Context(), node)
+		close(errc)
+	}()
+	return errc
+}
+
 // merge does fan-in of multiple read-only error channels
 // taken from http://blog.golang.org/pipelines
 func merge(cs ...<-chan error) <-chan error {
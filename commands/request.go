@@ -12,6 +12,8 @@ import (
 	"time"
 
 	"github.com/ipfs/go-ipfs/core"
+	"github.com/ipfs/go-ipfs/core/coreapi"
+	coreiface "github.com/ipfs/go-ipfs/core/coreapi/interface"
 	"github.com/ipfs/go-ipfs/repo/config"
 	u "gx/ipfs/QmPsAfmDBnZN3kZGSuNwvCNDZiHneERSKmRcFyG3UkvcT3/go-ipfs-util"
 
@@ -57,6 +59,16 @@ func (c *Context) GetNode() (*core.IpfsNode, error) {
 	return c.node, err
 }
 
+// GetApi returns CoreAPI instance backed by ipfs node.
+// It may construct the node with the provided function
+func (c *Context) GetApi() (coreiface.CoreAPI, error) {
+	n, err := c.GetNode()
+	if err != nil {
+		return nil, err
+	}
+	return coreapi.NewCoreAPI(n), nil
+}
+
 // NodeWithoutConstructing returns the underlying node variable
 // so that clients may close it.
 func (c *Context) NodeWithoutConstructing() *core.IpfsNode {
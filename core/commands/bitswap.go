@@ -2,8 +2,10 @@ package commands
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
+	"time"
 
 	oldcmds "github.com/ipfs/go-ipfs/commands"
 	e "github.com/ipfs/go-ipfs/core/commands/e"
@@ -24,7 +26,8 @@ var BitswapCmd = &cmds.Command{
 	},
 
 	Subcommands: map[string]*cmds.Command{
-		"stat": bitswapStatCmd,
+		"stat":     bitswapStatCmd,
+		"sessions": bitswapSessionsCmd,
 	},
 	OldSubcommands: map[string]*oldcmds.Command{
 		"wantlist":  showWantlistCmd,
@@ -181,6 +184,9 @@ var bitswapStatCmd = &cmds.Command{
 			fmt.Fprintf(w, "\tdata sent: %d\n", out.DataSent)
 			fmt.Fprintf(w, "\tdup blocks received: %d\n", out.DupBlksReceived)
 			fmt.Fprintf(w, "\tdup data received: %s\n", humanize.Bytes(out.DupDataReceived))
+			fmt.Fprintf(w, "\trate limited: %d\n", out.RateExceeded)
+			fmt.Fprintf(w, "\tpeer rate limited: %d\n", out.PeerRateExceeded)
+			fmt.Fprintf(w, "\tpeer outstanding limited: %d\n", out.OutstandingExceeded)
 			fmt.Fprintf(w, "\twantlist [%d keys]\n", len(out.Wantlist))
 			for _, k := range out.Wantlist {
 				fmt.Fprintf(w, "\t\t%s\n", k.String())
@@ -195,17 +201,85 @@ var bitswapStatCmd = &cmds.Command{
 	},
 }
 
+var bitswapSessionsCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Show the live bitswap sessions.",
+		ShortDescription: `
+Bitswap fetches the blocks of a single operation, like fetching a file, in a
+session. This command prints, for every live session, the peers it fetches
+blocks from, the blocks it wants, and how many blocks it received.
+`,
+	},
+	Type: bitswap.SessionStat{},
+	Run: func(req cmds.Request, res cmds.ResponseEmitter) {
+		nd, err := req.InvocContext().GetNode()
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		if !nd.OnlineMode() {
+			res.SetError(errNotOnline, cmdkit.ErrClient)
+			return
+		}
+
+		bs, ok := nd.Exchange.(*bitswap.Bitswap)
+		if !ok {
+			res.SetError(e.TypeErr(bs, nd.Exchange), cmdkit.ErrNormal)
+			return
+		}
+
+		for _, st := range bs.SessionStats() {
+			if err := res.Emit(st); err != nil {
+				log.Error(err)
+				return
+			}
+		}
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeEncoder(func(req cmds.Request, w io.Writer, v interface{}) error {
+			out, ok := v.(*bitswap.SessionStat)
+			if !ok {
+				return e.TypeErr(out, v)
+			}
+
+			fmt.Fprintf(w, "session %d (%s)\n", out.ID, out.Tag)
+			fmt.Fprintf(w, "\tblocks received: %d\n", out.BlocksReceived)
+			fmt.Fprintf(w, "\tdup blocks received: %d\n", out.DupBlksReceived)
+			fmt.Fprintf(w, "\taverage latency: %s\n", out.AverageLatency)
+			fmt.Fprintf(w, "\tsplit factor: %d\n", out.Split)
+			fmt.Fprintf(w, "\twanted [%d keys]\n", len(out.Wanted))
+			for _, k := range out.Wanted {
+				fmt.Fprintf(w, "\t\t%s\n", k.String())
+			}
+			fmt.Fprintf(w, "\tactive peers [%d]\n", len(out.ActivePeers))
+			for _, p := range out.ActivePeers {
+				fmt.Fprintf(w, "\t\t%s\n", p)
+			}
+
+			return nil
+		}),
+	},
+}
+
 var ledgerCmd = &oldcmds.Command{
 	Helptext: cmdkit.HelpText{
 		Tagline: "Show the current ledger for a peer.",
 		ShortDescription: `
 The Bitswap decision engine tracks the number of bytes exchanged between IPFS
 nodes, and stores this information as a collection of ledgers. This command
-prints the ledger associated with a given peer.
+prints the ledger associated with a given peer, including how often the peer
+was held back by the Bitswap send quotas.
+
+Ledgers are kept in the repo across restarts. With --all, the ledgers of all
+past and present partners are printed, most recent exchange first.
 `,
 	},
 	Arguments: []cmdkit.Argument{
-		cmdkit.StringArg("peer", true, false, "The PeerID (B58) of the ledger to inspect."),
+		cmdkit.StringArg("peer", false, false, "The PeerID (B58) of the ledger to inspect."),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.BoolOption("all", "a", "Show the ledgers of all past and present partners."),
 	},
 	Type: decision.Receipt{},
 	Run: func(req oldcmds.Request, res oldcmds.Response) {
@@ -226,6 +300,28 @@ prints the ledger associated with a given peer.
 			return
 		}
 
+		all, _, err := req.Option("all").Bool()
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		if all {
+			ledgers := bs.Ledgers()
+			out := make(chan interface{}, len(ledgers))
+			for _, l := range ledgers {
+				out <- l
+			}
+			close(out)
+			res.SetOutput((<-chan interface{})(out))
+			return
+		}
+
+		if len(req.Arguments()) == 0 {
+			res.SetError(errors.New("a peer ID is required unless --all is given"), cmdkit.ErrClient)
+			return
+		}
+
 		partner, err := peer.IDB58Decode(req.Arguments()[0])
 		if err != nil {
 			res.SetError(err, cmdkit.ErrClient)
@@ -250,20 +346,47 @@ prints the ledger associated with a given peer.
 				"Debt ratio:\t%f\n"+
 				"Exchanges:\t%d\n"+
 				"Bytes sent:\t%d\n"+
-				"Bytes received:\t%d\n\n",
+				"Bytes received:\t%d\n"+
+				"Last exchange:\t%s\n"+
+				"Rate limited:\t%d\n"+
+				"Outstanding limited:\t%d\n\n",
 				out.Peer, out.Value, out.Exchanged,
-				out.Sent, out.Recv)
+				out.Sent, out.Recv, lastExchange(out.LastExchange),
+				out.PeerRateExceeded, out.OutstandingExceeded)
 			return buf, nil
 		},
 	},
 }
 
+func lastExchange(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return t.Format(time.RFC3339)
+}
+
+// ReprovideOutput is the progress of a reprovide run.
+type ReprovideOutput struct {
+	Provided uint64
+	Failed   uint64
+	Duration time.Duration
+	Done     bool
+}
+
 var reprovideCmd = &oldcmds.Command{
 	Helptext: cmdkit.HelpText{
 		Tagline: "Trigger reprovider.",
 		ShortDescription: `
 Trigger reprovider to announce our data to network.
 `,
+		LongDescription: `
+Trigger reprovider to announce our data to network, and wait for it to be
+done. With --progress, the number of keys announced so far is shown while
+the reprovider runs, followed by a summary of the run.
+`,
+	},
+	Options: []cmdkit.Option{
+		cmdkit.BoolOption("progress", "Show progress"),
 	},
 	Run: func(req oldcmds.Request, res oldcmds.Response) {
 		nd, err := req.InvocContext().GetNode()
@@ -277,12 +400,84 @@ Trigger reprovider to announce our data to network.
 			return
 		}
 
-		err = nd.Reprovider.Trigger(req.Context())
-		if err != nil {
-			res.SetError(err, cmdkit.ErrNormal)
+		showProgress, _, _ := req.Option("progress").Bool()
+		if !showProgress {
+			err = nd.Reprovider.Trigger(req.Context())
+			if err != nil {
+				res.SetError(err, cmdkit.ErrNormal)
+				return
+			}
+
+			res.SetOutput(nil)
 			return
 		}
 
-		res.SetOutput(nil)
+		out := make(chan interface{})
+		res.SetOutput((<-chan interface{})(out))
+
+		ch := make(chan error, 1)
+		go func() {
+			ch <- nd.Reprovider.Trigger(req.Context())
+		}()
+
+		ticker := time.NewTicker(500 * time.Millisecond)
+		defer ticker.Stop()
+		defer close(out)
+		for {
+			select {
+			case err := <-ch:
+				if err != nil {
+					res.SetError(err, cmdkit.ErrNormal)
+					return
+				}
+
+				run := nd.Reprovider.Stat().LastRun
+				out <- &ReprovideOutput{
+					Provided: run.Provided,
+					Failed:   run.Failed,
+					Duration: run.Duration(),
+					Done:     true,
+				}
+				return
+			case <-ticker.C:
+				st := nd.Reprovider.Stat()
+				if !st.Running {
+					continue
+				}
+				out <- &ReprovideOutput{
+					Provided: st.Current.Provided,
+					Failed:   st.Current.Failed,
+					Duration: st.Current.Duration(),
+				}
+			case <-req.Context().Done():
+				res.SetError(req.Context().Err(), cmdkit.ErrNormal)
+				return
+			}
+		}
+	},
+	Marshalers: oldcmds.MarshalerMap{
+		oldcmds.Text: func(res oldcmds.Response) (io.Reader, error) {
+			v, err := unwrapOutput(res.Output())
+			if err != nil {
+				return nil, err
+			}
+
+			out, ok := v.(*ReprovideOutput)
+			if !ok {
+				return nil, e.TypeErr(out, v)
+			}
+
+			if !out.Done {
+				fmt.Fprintf(res.Stderr(), "Provided %d keys (%d failed) in %s\r",
+					out.Provided, out.Failed, out.Duration.Round(time.Second))
+				return new(bytes.Buffer), nil
+			}
+
+			buf := new(bytes.Buffer)
+			fmt.Fprintf(buf, "Provided %d keys (%d failed) in %s\n",
+				out.Provided, out.Failed, out.Duration.Round(time.Millisecond))
+			return buf, nil
+		},
 	},
+	Type: ReprovideOutput{},
 }
@@ -1,19 +1,18 @@
 package commands
 
 import (
-	"bytes"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"os"
 
 	util "github.com/ipfs/go-ipfs/blocks/blockstore/util"
 	e "github.com/ipfs/go-ipfs/core/commands/e"
+	coreapi "github.com/ipfs/go-ipfs/core/coreapi"
+	coreiface "github.com/ipfs/go-ipfs/core/coreapi/interface"
 	"gx/ipfs/QmP9vZfc5WSjfGTXmwX2EcicMFzmZ6fXn7HTdKYat6ccmH/go-ipfs-cmds"
 	"gx/ipfs/QmQp2a2Hhb7F6eK2A5hN8f9aJy4mtkEikL9Zj4cgB7d1dD/go-ipfs-cmdkit"
 
 	mh "gx/ipfs/QmYeKnKpubCMRiq3PGZcTREErthbb5Q9cXsCoSkD9bjEBd/go-multihash"
-	blocks "gx/ipfs/QmYsEQydGrsxNZfAiskvQ76N2xE9hDQtSAkRSynwMiUK3c/go-block-format"
 	cid "gx/ipfs/QmeSrf6pzut73u6zLQkRFQ3ygt3k6XFT2kjdYP8Tnkwwyg/go-cid"
 )
 
@@ -61,15 +60,27 @@ on raw IPFS blocks. It outputs the following to stdout:
 		cmdkit.StringArg("key", true, false, "The base58 multihash of an existing block to stat.").EnableStdin(),
 	},
 	Run: func(req cmds.Request, res cmds.ResponseEmitter) {
-		b, err := getBlockForKey(req, req.Arguments()[0])
+		api, err := req.InvocContext().GetApi()
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		p, err := blockPathForKey(req.Arguments()[0])
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		b, err := api.Block().Stat(req.Context(), p)
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
 			return
 		}
 
 		err = cmds.EmitOnce(res, &BlockStat{
-			Key:  b.Cid().String(),
-			Size: len(b.RawData()),
+			Key:  b.Path().Cid().String(),
+			Size: b.Size(),
 		})
 		if err != nil {
 			log.Error(err)
@@ -101,13 +112,25 @@ It outputs to stdout, and <key> is a base58 encoded multihash.
 		cmdkit.StringArg("key", true, false, "The base58 multihash of an existing block to get.").EnableStdin(),
 	},
 	Run: func(req cmds.Request, res cmds.ResponseEmitter) {
-		b, err := getBlockForKey(req, req.Arguments()[0])
+		api, err := req.InvocContext().GetApi()
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		p, err := blockPathForKey(req.Arguments()[0])
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		r, err := api.Block().Get(req.Context(), p)
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
 			return
 		}
 
-		err = res.Emit(bytes.NewReader(b.RawData()))
+		err = res.Emit(r)
 		if err != nil {
 			log.Error(err)
 		}
@@ -130,9 +153,10 @@ It reads from stdin, and <key> is a base58 encoded multihash.
 		cmdkit.StringOption("format", "f", "cid format for blocks to be created with.").WithDefault("v0"),
 		cmdkit.StringOption("mhtype", "multihash hash function").WithDefault("sha2-256"),
 		cmdkit.IntOption("mhlen", "multihash hash length").WithDefault(-1),
+		cmdkit.BoolOption("pin", "Pin the added block recursively.").WithDefault(false),
 	},
 	Run: func(req cmds.Request, res cmds.ResponseEmitter) {
-		n, err := req.InvocContext().GetNode()
+		api, err := req.InvocContext().GetApi()
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
 			return
@@ -144,31 +168,7 @@ It reads from stdin, and <key> is a base58 encoded multihash.
 			return
 		}
 
-		data, err := ioutil.ReadAll(file)
-		if err != nil {
-			res.SetError(err, cmdkit.ErrNormal)
-			return
-		}
-
-		err = file.Close()
-		if err != nil {
-			res.SetError(err, cmdkit.ErrNormal)
-			return
-		}
-
-		var pref cid.Prefix
-		pref.Version = 1
-
 		format, _, _ := req.Option("format").String()
-		formatval, ok := cid.Codecs[format]
-		if !ok {
-			res.SetError(fmt.Errorf("unrecognized format: %s", format), cmdkit.ErrNormal)
-			return
-		}
-		if format == "v0" {
-			pref.Version = 0
-		}
-		pref.Codec = formatval
 
 		mhtype, _, _ := req.Option("mhtype").String()
 		mhtval, ok := mh.Names[mhtype]
@@ -177,36 +177,37 @@ It reads from stdin, and <key> is a base58 encoded multihash.
 			res.SetError(err, cmdkit.ErrNormal)
 			return
 		}
-		pref.MhType = mhtval
 
 		mhlen, _, err := req.Option("mhlen").Int()
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
 			return
 		}
-		pref.MhLength = mhlen
 
-		bcid, err := pref.Sum(data)
+		dopin, _, err := req.Option("pin").Bool()
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
 			return
 		}
 
-		b, err := blocks.NewBlockWithCid(data, bcid)
+		p, err := api.Block().Put(req.Context(), file,
+			api.Block().WithFormat(format),
+			api.Block().WithHash(mhtval, mhlen),
+			api.Block().WithPin(dopin))
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
 			return
 		}
 
-		k, err := n.Blocks.AddBlock(b)
+		err = file.Close()
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
 			return
 		}
 
 		err = cmds.EmitOnce(res, &BlockStat{
-			Key:  k.String(),
-			Size: len(data),
+			Key:  p.Path().Cid().String(),
+			Size: p.Size(),
 		})
 		if err != nil {
 			log.Error(err)
@@ -225,27 +226,17 @@ It reads from stdin, and <key> is a base58 encoded multihash.
 	Type: BlockStat{},
 }
 
-func getBlockForKey(req cmds.Request, skey string) (blocks.Block, error) {
+func blockPathForKey(skey string) (coreiface.Path, error) {
 	if len(skey) == 0 {
 		return nil, fmt.Errorf("zero length cid invalid")
 	}
 
-	n, err := req.InvocContext().GetNode()
-	if err != nil {
-		return nil, err
-	}
-
 	c, err := cid.Decode(skey)
 	if err != nil {
 		return nil, err
 	}
 
-	b, err := n.Blocks.GetBlock(req.Context(), c)
-	if err != nil {
-		return nil, err
-	}
-
-	return b, nil
+	return coreapi.ParseCid(c), nil
 }
 
 var blockRmCmd = &cmds.Command{
@@ -264,7 +255,7 @@ It takes a list of base58 encoded multihashs to remove.
 		cmdkit.BoolOption("quiet", "q", "Write minimal output."),
 	},
 	Run: func(req cmds.Request, res cmds.ResponseEmitter) {
-		n, err := req.InvocContext().GetNode()
+		api, err := req.InvocContext().GetApi()
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
 			return
@@ -272,7 +263,7 @@ It takes a list of base58 encoded multihashs to remove.
 		hashes := req.Arguments()
 		force, _, _ := req.Option("force").Bool()
 		quiet, _, _ := req.Option("quiet").Bool()
-		cids := make([]*cid.Cid, 0, len(hashes))
+		paths := make([]coreiface.Path, 0, len(hashes))
 		for _, hash := range hashes {
 			c, err := cid.Decode(hash)
 			if err != nil {
@@ -281,19 +272,24 @@ It takes a list of base58 encoded multihashs to remove.
 				return
 			}
 
-			cids = append(cids, c)
+			paths = append(paths, coreapi.ParseCid(c))
 		}
-		ch, err := util.RmBlocks(n.Blockstore, n.Pinning, cids, util.RmBlocksOpts{
-			Quiet: quiet,
-			Force: force,
-		})
 
-		if err != nil {
-			res.SetError(err, cmdkit.ErrNormal)
-			return
-		}
+		ch := make(chan interface{}, len(paths))
+		go func() {
+			defer close(ch)
+
+			for _, p := range paths {
+				err := api.Block().Rm(req.Context(), p, api.Block().WithForce(force))
+				if err != nil {
+					ch <- &util.RemovedBlock{Hash: p.Cid().String(), Error: err.Error()}
+				} else if !quiet {
+					ch <- &util.RemovedBlock{Hash: p.Cid().String()}
+				}
+			}
+		}()
 
-		err = res.Emit(ch)
+		err = res.Emit((<-chan interface{})(ch))
 		if err != nil {
 			log.Error(err)
 		}
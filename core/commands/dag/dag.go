@@ -7,7 +7,9 @@ import (
 	"math"
 	"strings"
 
+	car "github.com/ipfs/go-ipfs/car"
 	cmds "github.com/ipfs/go-ipfs/commands"
+	core "github.com/ipfs/go-ipfs/core"
 	e "github.com/ipfs/go-ipfs/core/commands/e"
 	coredag "github.com/ipfs/go-ipfs/core/coredag"
 	path "github.com/ipfs/go-ipfs/path"
@@ -33,6 +35,8 @@ to deprecate and replace the existing 'ipfs object' command moving forward.
 		"put":     DagPutCmd,
 		"get":     DagGetCmd,
 		"resolve": DagResolveCmd,
+		"export":  DagExportCmd,
+		"import":  DagImportCmd,
 	},
 }
 
@@ -134,6 +138,17 @@ into an object of the specified format.
 				return err
 			}
 
+			if n.ProvideQueue != nil {
+				var keys []*cid.Cid
+				cids.ForEach(func(c *cid.Cid) error {
+					keys = append(keys, c)
+					return nil
+				})
+				if err := n.ProvideQueue.Enqueue(keys...); err != nil {
+					return err
+				}
+			}
+
 			if dopin {
 				defer n.Blockstore.PinLock().Unlock()
 
@@ -278,6 +293,152 @@ var DagResolveCmd = &cmds.Command{
 	Type: ResolveOutput{},
 }
 
+// DagExportCmd streams the DAG below a path out as a CAR archive
+var DagExportCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Streams the selected DAG as a .car stream on stdout.",
+		ShortDescription: `
+'ipfs dag export' fetches a dag rooted at the given path and writes it out
+as a content addressed archive (.car) on stdout. Every block is written
+exactly once. The archive can be loaded into another node with
+'ipfs dag import'.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("root", true, false, "Path of the root of the DAG to export.").EnableStdin(),
+	},
+	Run: func(req cmds.Request, res cmds.Response) {
+		n, err := req.InvocContext().GetNode()
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		p, err := path.ParsePath(req.Arguments()[0])
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		c, err := core.ResolveToCid(req.Context(), n.Namesys, n.Resolver, p)
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		pr, pw := io.Pipe()
+		go func() {
+			err := car.WriteCar(req.Context(), n.DAG, []*cid.Cid{c}, pw)
+			pw.CloseWithError(err)
+		}()
+
+		res.SetOutput(pr)
+	},
+}
+
+// DagImportCmd loads the blocks of CAR archives into the node
+var DagImportCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Import the contents of .car files.",
+		ShortDescription: `
+'ipfs dag import' reads content addressed archives (.car) created by
+'ipfs dag export' and adds every block they contain to the local repo,
+checking each block against its cid. By default, the roots listed in each
+archive are pinned recursively once the import completes.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.FileArg("path", true, true, "The path of a .car file.").EnableStdin(),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.BoolOption("pin-roots", "Pin the roots of the imported archives.").WithDefault(true),
+	},
+	Run: func(req cmds.Request, res cmds.Response) {
+		n, err := req.InvocContext().GetNode()
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		doPin, _, err := req.Option("pin-roots").Bool()
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		outChan := make(chan interface{}, 8)
+		res.SetOutput((<-chan interface{})(outChan))
+
+		importAll := func(f files.File) error {
+			if doPin {
+				// hold the pin lock for the whole import so gc can not
+				// remove blocks before the roots are pinned
+				defer n.Blockstore.PinLock().Unlock()
+			}
+
+			var roots []*cid.Cid
+			for {
+				file, err := f.NextFile()
+				if err == io.EOF {
+					break
+				} else if err != nil {
+					return err
+				}
+
+				h, err := car.LoadCar(n.DAG, file)
+				file.Close()
+				if err != nil {
+					return err
+				}
+				roots = append(roots, h.Roots...)
+			}
+
+			for _, c := range roots {
+				if doPin {
+					nd, err := n.DAG.Get(req.Context(), c)
+					if err != nil {
+						return fmt.Errorf("pinning root %s: %s", c, err)
+					}
+
+					if err := n.Pinning.Pin(req.Context(), nd, true); err != nil {
+						return fmt.Errorf("pinning root %s: %s", c, err)
+					}
+				}
+				outChan <- &OutputObject{Cid: c}
+			}
+
+			if doPin {
+				return n.Pinning.Flush()
+			}
+			return nil
+		}
+
+		go func() {
+			defer close(outChan)
+			if err := importAll(req.Files()); err != nil {
+				res.SetError(err, cmdkit.ErrNormal)
+				return
+			}
+		}()
+	},
+	Type: OutputObject{},
+	Marshalers: cmds.MarshalerMap{
+		cmds.Text: func(res cmds.Response) (io.Reader, error) {
+			v, err := unwrapOutput(res.Output())
+			if err != nil {
+				return nil, err
+			}
+
+			oobj, ok := v.(*OutputObject)
+			if !ok {
+				return nil, e.TypeErr(oobj, v)
+			}
+
+			return strings.NewReader(fmt.Sprintf("root %s\n", oobj.Cid)), nil
+		},
+	},
+}
+
 // copy+pasted from ../commands.go
 func unwrapOutput(i interface{}) (interface{}, error) {
 	var (
@@ -2,7 +2,6 @@ package commands
 
 import (
 	"bytes"
-	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -10,10 +9,10 @@ import (
 
 	cmds "github.com/ipfs/go-ipfs/commands"
 	e "github.com/ipfs/go-ipfs/core/commands/e"
-	dag "github.com/ipfs/go-ipfs/merkledag"
+	coreapi "github.com/ipfs/go-ipfs/core/coreapi"
+	coreiface "github.com/ipfs/go-ipfs/core/coreapi/interface"
 	path "github.com/ipfs/go-ipfs/path"
 
-	routing "gx/ipfs/QmPCGUjMRuBcPybZFpjhzpifwPP9wPRoiy5geTQKU4vqWA/go-libp2p-routing"
 	notif "gx/ipfs/QmPCGUjMRuBcPybZFpjhzpifwPP9wPRoiy5geTQKU4vqWA/go-libp2p-routing/notifications"
 	"gx/ipfs/QmQp2a2Hhb7F6eK2A5hN8f9aJy4mtkEikL9Zj4cgB7d1dD/go-ipfs-cmdkit"
 	b58 "gx/ipfs/QmT8rehPR3F6bmwL6zjUN8XpiDBFFpMP2myPdC6ApsWfJf/go-base58"
@@ -147,18 +146,12 @@ var findProvidersDhtCmd = &cmds.Command{
 		cmdkit.IntOption("num-providers", "n", "The number of providers to find.").WithDefault(20),
 	},
 	Run: func(req cmds.Request, res cmds.Response) {
-		n, err := req.InvocContext().GetNode()
+		api, err := req.InvocContext().GetApi()
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
 			return
 		}
 
-		dht, ok := n.Routing.(*ipdht.IpfsDHT)
-		if !ok {
-			res.SetError(ErrNotDHT, cmdkit.ErrNormal)
-			return
-		}
-
 		numProviders, _, err := res.Request().Option("num-providers").Int()
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
@@ -169,19 +162,24 @@ var findProvidersDhtCmd = &cmds.Command{
 			return
 		}
 
-		outChan := make(chan interface{})
-		res.SetOutput((<-chan interface{})(outChan))
+		c, err := cid.Decode(req.Arguments()[0])
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
 
 		events := make(chan *notif.QueryEvent)
 		ctx := notif.RegisterForQueryEvents(req.Context(), events)
 
-		c, err := cid.Decode(req.Arguments()[0])
+		pchan, err := api.Dht().FindProviders(ctx, coreapi.ParseCid(c), api.Dht().WithNumProviders(numProviders))
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
 			return
 		}
 
-		pchan := dht.FindProvidersAsync(ctx, c, numProviders)
+		outChan := make(chan interface{})
+		res.SetOutput((<-chan interface{})(outChan))
+
 		go func() {
 			defer close(outChan)
 			for e := range events {
@@ -260,25 +258,15 @@ var provideRefDhtCmd = &cmds.Command{
 		cmdkit.BoolOption("recursive", "r", "Recursively provide entire graph."),
 	},
 	Run: func(req cmds.Request, res cmds.Response) {
-		n, err := req.InvocContext().GetNode()
+		api, err := req.InvocContext().GetApi()
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
 			return
 		}
 
-		if n.Routing == nil {
-			res.SetError(errNotOnline, cmdkit.ErrNormal)
-			return
-		}
-
-		if len(n.PeerHost.Network().Conns()) == 0 {
-			res.SetError(errors.New("cannot provide, no connected peers"), cmdkit.ErrNormal)
-			return
-		}
-
 		rec, _, _ := req.Option("recursive").Bool()
 
-		var cids []*cid.Cid
+		var paths []coreiface.Path
 		for _, arg := range req.Arguments() {
 			c, err := cid.Decode(arg)
 			if err != nil {
@@ -286,18 +274,7 @@ var provideRefDhtCmd = &cmds.Command{
 				return
 			}
 
-			has, err := n.Blockstore.Has(c)
-			if err != nil {
-				res.SetError(err, cmdkit.ErrNormal)
-				return
-			}
-
-			if !has {
-				res.SetError(fmt.Errorf("block %s not found locally, cannot provide", c), cmdkit.ErrNormal)
-				return
-			}
-
-			cids = append(cids, c)
+			paths = append(paths, coreapi.ParseCid(c))
 		}
 
 		outChan := make(chan interface{})
@@ -319,17 +296,15 @@ var provideRefDhtCmd = &cmds.Command{
 
 		go func() {
 			defer close(events)
-			var err error
-			if rec {
-				err = provideKeysRec(ctx, n.Routing, n.DAG, cids)
-			} else {
-				err = provideKeys(ctx, n.Routing, cids)
-			}
-			if err != nil {
-				notif.PublishQueryEvent(ctx, &notif.QueryEvent{
-					Type:  notif.QueryError,
-					Extra: err.Error(),
-				})
+			for _, p := range paths {
+				err := api.Dht().Provide(ctx, p, api.Dht().WithRecursive(rec))
+				if err != nil {
+					notif.PublishQueryEvent(ctx, &notif.QueryEvent{
+						Type:  notif.QueryError,
+						Extra: err.Error(),
+					})
+					return
+				}
 			}
 		}()
 	},
@@ -363,42 +338,6 @@ var provideRefDhtCmd = &cmds.Command{
 	Type: notif.QueryEvent{},
 }
 
-func provideKeys(ctx context.Context, r routing.IpfsRouting, cids []*cid.Cid) error {
-	for _, c := range cids {
-		err := r.Provide(ctx, c, true)
-		if err != nil {
-			return err
-		}
-	}
-	return nil
-}
-
-func provideKeysRec(ctx context.Context, r routing.IpfsRouting, dserv dag.DAGService, cids []*cid.Cid) error {
-	provided := cid.NewSet()
-	for _, c := range cids {
-		kset := cid.NewSet()
-
-		err := dag.EnumerateChildrenAsync(ctx, dag.GetLinksDirect(dserv), c, kset.Visit)
-		if err != nil {
-			return err
-		}
-
-		for _, k := range kset.Keys() {
-			if provided.Has(k) {
-				continue
-			}
-
-			err = r.Provide(ctx, k, true)
-			if err != nil {
-				return err
-			}
-			provided.Add(k)
-		}
-	}
-
-	return nil
-}
-
 var findPeerDhtCmd = &cmds.Command{
 	Helptext: cmdkit.HelpText{
 		Tagline:          "Query the DHT for all of the multiaddresses associated with a Peer ID.",
@@ -412,18 +351,12 @@ var findPeerDhtCmd = &cmds.Command{
 		cmdkit.BoolOption("verbose", "v", "Print extra information."),
 	},
 	Run: func(req cmds.Request, res cmds.Response) {
-		n, err := req.InvocContext().GetNode()
+		api, err := req.InvocContext().GetApi()
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
 			return
 		}
 
-		dht, ok := n.Routing.(*ipdht.IpfsDHT)
-		if !ok {
-			res.SetError(ErrNotDHT, cmdkit.ErrNormal)
-			return
-		}
-
 		pid, err := peer.IDB58Decode(req.Arguments()[0])
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
@@ -449,7 +382,7 @@ var findPeerDhtCmd = &cmds.Command{
 
 		go func() {
 			defer close(events)
-			pi, err := dht.FindPeer(ctx, pid)
+			pi, err := api.Dht().FindPeer(ctx, pid)
 			if err != nil {
 				notif.PublishQueryEvent(ctx, &notif.QueryEvent{
 					Type:  notif.QueryError,
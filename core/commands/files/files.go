@@ -8,7 +8,9 @@ import (
 	"io"
 	"os"
 	gopath "path"
+	"strconv"
 	"strings"
+	"time"
 
 	cmds "github.com/ipfs/go-ipfs/commands"
 	core "github.com/ipfs/go-ipfs/core"
@@ -47,18 +49,25 @@ operations.
 	},
 	Options: []cmdkit.Option{
 		cmdkit.BoolOption("f", "flush", "Flush target and ancestors after write.").WithDefault(true),
+		cmdkit.IntOption("tx", "Operate on the working copy of the given transaction. See 'ipfs files tx'."),
 	},
 	Subcommands: map[string]*cmds.Command{
-		"read":  FilesReadCmd,
-		"write": FilesWriteCmd,
-		"mv":    FilesMvCmd,
-		"cp":    FilesCpCmd,
-		"ls":    FilesLsCmd,
-		"mkdir": FilesMkdirCmd,
-		"stat":  FilesStatCmd,
-		"rm":    FilesRmCmd,
-		"flush": FilesFlushCmd,
-		"chcid": FilesChcidCmd,
+		"read":     FilesReadCmd,
+		"write":    FilesWriteCmd,
+		"mv":       FilesMvCmd,
+		"cp":       FilesCpCmd,
+		"ls":       FilesLsCmd,
+		"mkdir":    FilesMkdirCmd,
+		"stat":     FilesStatCmd,
+		"rm":       FilesRmCmd,
+		"flush":    FilesFlushCmd,
+		"chcid":    FilesChcidCmd,
+		"chmod":    FilesChmodCmd,
+		"touch":    FilesTouchCmd,
+		"snapshot": FilesSnapshotCmd,
+		"tx":       FilesTxCmd,
+		"sync":     FilesSyncCmd,
+		"watch":    FilesWatchCmd,
 	},
 }
 
@@ -77,7 +86,7 @@ var FilesStatCmd = &cmds.Command{
 	},
 	Options: []cmdkit.Option{
 		cmdkit.StringOption("format", "Print statistics in given format. Allowed tokens: "+
-			"<hash> <size> <cumulsize> <type> <childs>. Conflicts with other format options.").WithDefault(
+			"<hash> <size> <cumulsize> <type> <childs> <mode> <mtime>. Conflicts with other format options.").WithDefault(
 			`<hash>
 Size: <size>
 CumulativeSize: <cumulsize>
@@ -105,7 +114,13 @@ Type: <type>`),
 			return
 		}
 
-		fsn, err := mfs.Lookup(node.FilesRoot, path)
+		root, err := filesRoot(req, node)
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		fsn, err := mfs.Lookup(root, path)
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
 			return
@@ -138,6 +153,8 @@ Type: <type>`),
 			s = strings.Replace(s, "<cumulsize>", fmt.Sprintf("%d", out.CumulativeSize), -1)
 			s = strings.Replace(s, "<childs>", fmt.Sprintf("%d", out.Blocks), -1)
 			s = strings.Replace(s, "<type>", out.Type, -1)
+			s = strings.Replace(s, "<mode>", formatMode(out.Mode), -1)
+			s = strings.Replace(s, "<mtime>", formatMtime(out.Mtime), -1)
 
 			fmt.Fprintln(buf, s)
 			return buf, nil
@@ -146,6 +163,24 @@ Type: <type>`),
 	Type: Object{},
 }
 
+// formatMode formats the permission bits of a node in octal, or "-" if the
+// node doesn't store them.
+func formatMode(mode *os.FileMode) string {
+	if mode == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%04o", uint32(*mode))
+}
+
+// formatMtime formats the modification time of a node, or "-" if the node
+// doesn't store one.
+func formatMtime(mtime *time.Time) string {
+	if mtime == nil {
+		return "-"
+	}
+	return mtime.Format(time.RFC3339)
+}
+
 func moreThanOne(a, b, c bool) bool {
 	return a && b || b && c || a && c
 }
@@ -199,13 +234,18 @@ func statNode(ds dag.DAGService, fsn mfs.FSNode) (*Object, error) {
 			return nil, fmt.Errorf("unrecognized node type: %s", fsn.Type())
 		}
 
-		return &Object{
+		o := &Object{
 			Hash:           c.String(),
 			Blocks:         len(nd.Links()),
 			Size:           d.GetFilesize(),
 			CumulativeSize: cumulsize,
 			Type:           ndtype,
-		}, nil
+			Mode:           ft.Mode(d),
+		}
+		if mtime := ft.ModTime(d); !mtime.IsZero() {
+			o.Mtime = &mtime
+		}
+		return o, nil
 	case *dag.RawNode:
 		return &Object{
 			Hash:           c.String(),
@@ -253,20 +293,26 @@ var FilesCpCmd = &cmds.Command{
 			dst += gopath.Base(src)
 		}
 
-		nd, err := getNodeFromPath(req.Context(), node, src)
+		root, err := filesRoot(req, node)
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		nd, err := getNodeFromPath(req.Context(), node, root, src)
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
 			return
 		}
 
-		err = mfs.PutNode(node.FilesRoot, dst, nd)
+		err = mfs.PutNode(root, dst, nd)
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
 			return
 		}
 
 		if flush {
-			err := mfs.FlushPath(node.FilesRoot, dst)
+			err := mfs.FlushPath(root, dst)
 			if err != nil {
 				res.SetError(err, cmdkit.ErrNormal)
 				return
@@ -277,7 +323,7 @@ var FilesCpCmd = &cmds.Command{
 	},
 }
 
-func getNodeFromPath(ctx context.Context, node *core.IpfsNode, p string) (node.Node, error) {
+func getNodeFromPath(ctx context.Context, node *core.IpfsNode, root *mfs.Root, p string) (node.Node, error) {
 	switch {
 	case strings.HasPrefix(p, "/ipfs/"):
 		np, err := path.ParsePath(p)
@@ -292,7 +338,7 @@ func getNodeFromPath(ctx context.Context, node *core.IpfsNode, p string) (node.N
 
 		return core.Resolve(ctx, node.Namesys, resolver, np)
 	default:
-		fsn, err := mfs.Lookup(node.FilesRoot, p)
+		fsn, err := mfs.Lookup(root, p)
 		if err != nil {
 			return nil, err
 		}
@@ -307,6 +353,8 @@ type Object struct {
 	CumulativeSize uint64
 	Blocks         int
 	Type           string
+	Mode           *os.FileMode `json:",omitempty"`
+	Mtime          *time.Time   `json:",omitempty"`
 }
 
 type FilesLsOutput struct {
@@ -361,7 +409,13 @@ Examples:
 			return
 		}
 
-		fsn, err := mfs.Lookup(nd.FilesRoot, path)
+		root, err := filesRoot(req, nd)
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		fsn, err := mfs.Lookup(root, path)
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
 			return
@@ -465,7 +519,13 @@ Examples:
 			return
 		}
 
-		fsn, err := mfs.Lookup(n.FilesRoot, path)
+		root, err := filesRoot(req, n)
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		fsn, err := mfs.Lookup(root, path)
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
 			return
@@ -578,7 +638,13 @@ Example:
 			return
 		}
 
-		err = mfs.Mv(n.FilesRoot, src, dst)
+		root, err := filesRoot(req, n)
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		err = mfs.Mv(root, src, dst)
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
 			return
@@ -669,7 +735,13 @@ stat' on the file or any of its ancestors.
 			return
 		}
 
-		fi, err := getFileHandle(nd.FilesRoot, path, create, prefix)
+		root, err := filesRoot(req, nd)
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		fi, err := getFileHandle(root, path, create, prefix)
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
 			return
@@ -783,7 +855,11 @@ Examples:
 			res.SetError(err, cmdkit.ErrNormal)
 			return
 		}
-		root := n.FilesRoot
+		root, err := filesRoot(req, n)
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
 
 		err = mfs.Mkdir(root, dirtomake, mfs.MkdirOpts{
 			Mkparents: dashp,
@@ -822,7 +898,13 @@ are run with the '--flush=false'.
 			path = req.Arguments()[0]
 		}
 
-		err = mfs.FlushPath(nd.FilesRoot, path)
+		root, err := filesRoot(req, nd)
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		err = mfs.FlushPath(root, path)
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
 			return
@@ -866,7 +948,114 @@ Change the cid version or hash function of the root node of a given path.
 			return
 		}
 
-		err = updatePath(nd.FilesRoot, path, prefix, flush)
+		root, err := filesRoot(req, nd)
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		err = updatePath(root, path, prefix, flush)
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		res.SetOutput(nil)
+	},
+}
+
+var FilesChmodCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Change the permission bits of a file or directory.",
+		ShortDescription: `
+Sets the permission bits of the file or directory at <path>, given in octal.
+They are restored by 'ipfs get'.
+
+  $ ipfs files chmod 0755 /scripts/build.sh
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("mode", true, false, "Permission bits, in octal."),
+		cmdkit.StringArg("path", true, false, "Path to change."),
+	},
+	Run: func(req cmds.Request, res cmds.Response) {
+		nd, err := req.InvocContext().GetNode()
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		mode, err := strconv.ParseUint(req.Arguments()[0], 8, 32)
+		if err != nil || os.FileMode(mode)&^os.ModePerm != 0 {
+			res.SetError(fmt.Errorf("invalid mode: %s", req.Arguments()[0]), cmdkit.ErrClient)
+			return
+		}
+
+		path, err := checkPath(req.Arguments()[1])
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		root, err := filesRoot(req, nd)
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		err = mfs.Chmod(root, path, os.FileMode(mode))
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		res.SetOutput(nil)
+	},
+}
+
+var FilesTouchCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Change the modification time of a file or directory.",
+		ShortDescription: `
+Sets the modification time of the file or directory at <path> to the current
+time, or to the time given with '--mtime' in seconds since the Unix epoch.
+It is restored by 'ipfs get'. Writing to a file that has a modification time
+updates it.
+
+  $ ipfs files touch --mtime=1500000000 /docs/README
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("path", true, false, "Path to change."),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.IntOption("mtime", "Modification time, in seconds since the Unix epoch. Default: now."),
+	},
+	Run: func(req cmds.Request, res cmds.Response) {
+		nd, err := req.InvocContext().GetNode()
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		path, err := checkPath(req.Arguments()[0])
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		mtime := time.Now()
+		if secs, found, _ := req.Option("mtime").Int(); found {
+			mtime = time.Unix(int64(secs), 0)
+		}
+
+		root, err := filesRoot(req, nd)
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		err = mfs.Touch(root, path, mtime)
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
 			return
@@ -947,7 +1136,13 @@ Remove files or directories.
 		}
 
 		dir, name := gopath.Split(path)
-		parent, err := mfs.Lookup(nd.FilesRoot, dir)
+		root, err := filesRoot(req, nd)
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		parent, err := mfs.Lookup(root, dir)
 		if err != nil {
 			res.SetError(fmt.Errorf("parent lookup: %s", err), cmdkit.ErrNormal)
 			return
@@ -0,0 +1,440 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	cmds "github.com/ipfs/go-ipfs/commands"
+	core "github.com/ipfs/go-ipfs/core"
+	e "github.com/ipfs/go-ipfs/core/commands/e"
+	dagutils "github.com/ipfs/go-ipfs/merkledag/utils"
+	mfs "github.com/ipfs/go-ipfs/mfs"
+	pin "github.com/ipfs/go-ipfs/pin"
+	cmdkit "gx/ipfs/QmQp2a2Hhb7F6eK2A5hN8f9aJy4mtkEikL9Zj4cgB7d1dD/go-ipfs-cmdkit"
+
+	node "gx/ipfs/QmNwUEK7QbwSqyKBu3mMtToo8SUc6wQJ7gdZq4gGGJqfnf/go-ipld-format"
+	cid "gx/ipfs/QmeSrf6pzut73u6zLQkRFQ3ygt3k6XFT2kjdYP8Tnkwwyg/go-cid"
+)
+
+var FilesSnapshotCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Record and restore states of the files root.",
+		ShortDescription: `
+A snapshot records what '/' looked like at a given time. Snapshots are kept in
+the repo, and can be listed, compared, and restored from later.
+
+    $ ipfs files snapshot create --name=before-cleanup
+    1
+    $ ipfs files rm -r /old
+    $ ipfs files snapshot diff 1
+    - QmPXFqyxdm6Kw4WZhR7PGcJ4vM7ncS9Fuq7ZmPxmE3dXPY "old"
+    $ ipfs files snapshot restore 1 /old
+
+Unless they are pinned with '--pin', the content of snapshots is removed by
+'ipfs repo gc' once it is no longer referenced from '/'.
+`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"create":  filesSnapshotCreateCmd,
+		"ls":      filesSnapshotLsCmd,
+		"restore": filesSnapshotRestoreCmd,
+		"diff":    filesSnapshotDiffCmd,
+		"rm":      filesSnapshotRmCmd,
+	},
+}
+
+type SnapshotList struct {
+	Snapshots []*mfs.Snapshot
+}
+
+type SnapshotDiff struct {
+	Changes []*dagutils.Change
+}
+
+func snapshotStore(n *core.IpfsNode) *mfs.SnapshotStore {
+	return mfs.NewSnapshotStore(n.Repo.Datastore())
+}
+
+func parseSnapshotID(arg string) (uint64, error) {
+	id, err := strconv.ParseUint(arg, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid snapshot ID %q", arg)
+	}
+	return id, nil
+}
+
+// getSnapshotNode returns the node at p in the snapshot with the given ID.
+func getSnapshotNode(req cmds.Request, n *core.IpfsNode, arg, p string) (node.Node, error) {
+	id, err := parseSnapshotID(arg)
+	if err != nil {
+		return nil, err
+	}
+
+	snap, err := snapshotStore(n).Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	p = strings.TrimRight(p, "/")
+	return getNodeFromPath(req.Context(), n, n.FilesRoot, "/ipfs/"+snap.Cid.String()+p)
+}
+
+var filesSnapshotCreateCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Record the current state of the files root.",
+		ShortDescription: `
+Flushes the files root and records it as a new snapshot. Prints the ID of the
+snapshot.
+`,
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("name", "n", "Name of the snapshot."),
+		cmdkit.BoolOption("pin", "Pin the snapshot, so that its content is kept."),
+	},
+	Run: func(req cmds.Request, res cmds.Response) {
+		n, err := req.InvocContext().GetNode()
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		name, _, _ := req.Option("name").String()
+		dopin, _, _ := req.Option("pin").Bool()
+
+		nd, err := n.FilesRoot.GetValue().GetNode()
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+		if err := n.FilesRoot.Flush(); err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		pinned := false
+		if dopin {
+			defer n.Blockstore.PinLock().Unlock()
+
+			pinned, err = pinSnapshot(req, n, nd)
+			if err != nil {
+				res.SetError(err, cmdkit.ErrNormal)
+				return
+			}
+		}
+
+		snap, err := snapshotStore(n).Create(nd.Cid(), name, pinned)
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		res.SetOutput(snap)
+	},
+	Marshalers: cmds.MarshalerMap{
+		cmds.Text: func(res cmds.Response) (io.Reader, error) {
+			v, err := unwrapOutput(res.Output())
+			if err != nil {
+				return nil, err
+			}
+
+			snap, ok := v.(*mfs.Snapshot)
+			if !ok {
+				return nil, e.TypeErr(snap, v)
+			}
+			return bytes.NewBufferString(fmt.Sprintf("%d\n", snap.ID)), nil
+		},
+	},
+	Type: mfs.Snapshot{},
+}
+
+var filesSnapshotLsCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "List snapshots of the files root.",
+		ShortDescription: `
+Lists the snapshots, oldest first, with the time they were taken, the hash of
+the files root, and their name.
+`,
+	},
+	Run: func(req cmds.Request, res cmds.Response) {
+		n, err := req.InvocContext().GetNode()
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		snaps, err := snapshotStore(n).List()
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		res.SetOutput(&SnapshotList{Snapshots: snaps})
+	},
+	Marshalers: cmds.MarshalerMap{
+		cmds.Text: func(res cmds.Response) (io.Reader, error) {
+			v, err := unwrapOutput(res.Output())
+			if err != nil {
+				return nil, err
+			}
+
+			list, ok := v.(*SnapshotList)
+			if !ok {
+				return nil, e.TypeErr(list, v)
+			}
+
+			buf := new(bytes.Buffer)
+			for _, snap := range list.Snapshots {
+				fmt.Fprintf(buf, "%d\t%s\t%s", snap.ID, snap.Created.Format(time.RFC3339), snap.Cid)
+				if snap.Name != "" {
+					fmt.Fprintf(buf, "\t%s", snap.Name)
+				}
+				if snap.Pinned {
+					fmt.Fprint(buf, "\t(pinned)")
+				}
+				fmt.Fprintln(buf)
+			}
+			return buf, nil
+		},
+	},
+	Type: SnapshotList{},
+}
+
+var filesSnapshotRestoreCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Restore the files root, or a part of it, from a snapshot.",
+		ShortDescription: `
+Replaces the given path, '/' by default, with what it was in the snapshot.
+Restoring '/' replaces everything in the files root.
+
+    $ ipfs files snapshot restore 1 /photos
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("id", true, false, "ID of the snapshot to restore from."),
+		cmdkit.StringArg("path", false, false, "Path to restore."),
+	},
+	Run: func(req cmds.Request, res cmds.Response) {
+		n, err := req.InvocContext().GetNode()
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		p := "/"
+		if len(req.Arguments()) > 1 {
+			p, err = checkPath(req.Arguments()[1])
+			if err != nil {
+				res.SetError(err, cmdkit.ErrNormal)
+				return
+			}
+		}
+
+		nd, err := getSnapshotNode(req, n, req.Arguments()[0], p)
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		if err := mfs.Restore(req.Context(), n.FilesRoot, p, nd); err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		res.SetOutput(nil)
+	},
+}
+
+var filesSnapshotDiffCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Show the differences between two snapshots.",
+		ShortDescription: `
+Shows what changed from the first snapshot to the second one, or to the
+current files root if only one snapshot is given.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("from", true, false, "ID of the first snapshot."),
+		cmdkit.StringArg("to", false, false, "ID of the second snapshot."),
+	},
+	Run: func(req cmds.Request, res cmds.Response) {
+		n, err := req.InvocContext().GetNode()
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		a, err := getSnapshotNode(req, n, req.Arguments()[0], "")
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		var b node.Node
+		if len(req.Arguments()) > 1 {
+			b, err = getSnapshotNode(req, n, req.Arguments()[1], "")
+		} else {
+			b, err = n.FilesRoot.GetValue().GetNode()
+		}
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		changes, err := dagutils.Diff(req.Context(), n.DAG, a, b)
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		res.SetOutput(&SnapshotDiff{Changes: changes})
+	},
+	Marshalers: cmds.MarshalerMap{
+		cmds.Text: func(res cmds.Response) (io.Reader, error) {
+			v, err := unwrapOutput(res.Output())
+			if err != nil {
+				return nil, err
+			}
+
+			diff, ok := v.(*SnapshotDiff)
+			if !ok {
+				return nil, e.TypeErr(diff, v)
+			}
+
+			buf := new(bytes.Buffer)
+			writeChanges(buf, diff.Changes)
+			return buf, nil
+		},
+	},
+	Type: SnapshotDiff{},
+}
+
+var filesSnapshotRmCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Remove a snapshot.",
+		ShortDescription: `
+Removes a snapshot, and unpins it if it was pinned with '--pin' and no other
+snapshot needs the pin. Roots that were already pinned when the snapshot was
+created, or that were pinned again with a name since, stay pinned.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("id", true, true, "ID of the snapshot to remove."),
+	},
+	Run: func(req cmds.Request, res cmds.Response) {
+		n, err := req.InvocContext().GetNode()
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		defer n.Blockstore.PinLock().Unlock()
+
+		store := snapshotStore(n)
+		for _, arg := range req.Arguments() {
+			id, err := parseSnapshotID(arg)
+			if err != nil {
+				res.SetError(err, cmdkit.ErrNormal)
+				return
+			}
+
+			snap, err := store.Remove(id)
+			if err != nil {
+				res.SetError(err, cmdkit.ErrNormal)
+				return
+			}
+
+			if !snap.Pinned || !ownsPin(n, snap.Cid) {
+				continue
+			}
+
+			// other snapshots of the same root still need the pin
+			shared, err := pinnedElsewhere(store, snap)
+			if err != nil {
+				res.SetError(err, cmdkit.ErrNormal)
+				return
+			}
+			if shared {
+				continue
+			}
+
+			err = n.Pinning.Unpin(req.Context(), snap.Cid, true)
+			if err != nil && err != pin.ErrNotPinned {
+				res.SetError(fmt.Errorf("unpinning snapshot %d: %s", id, err), cmdkit.ErrNormal)
+				return
+			}
+		}
+
+		if err := n.Pinning.Flush(); err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		res.SetOutput(nil)
+	},
+}
+
+// snapshotPinName is the name of the pins added for snapshots. Pins of the
+// same root made by other means are left alone when snapshots are removed.
+const snapshotPinName = "files-snapshot"
+
+// pinSnapshot pins nd, the root of a new snapshot, and returns true if the
+// snapshot owns the pin. A root pinned by other means stays pinned as it is,
+// and is not owned by the snapshot.
+func pinSnapshot(req cmds.Request, n *core.IpfsNode, nd node.Node) (bool, error) {
+	_, pinned, err := n.Pinning.IsPinnedWithType(nd.Cid(), pin.Recursive)
+	if err != nil {
+		return false, err
+	}
+	if pinned {
+		return ownsPin(n, nd.Cid()), nil
+	}
+
+	if err := n.Pinning.Pin(req.Context(), nd, true); err != nil {
+		return false, err
+	}
+	if err := n.Pinning.SetMetadata(nd.Cid(), &pin.Metadata{Name: snapshotPinName}); err != nil {
+		return false, err
+	}
+	return true, n.Pinning.Flush()
+}
+
+// ownsPin returns true if the pin of c was added for a snapshot.
+func ownsPin(n *core.IpfsNode, c *cid.Cid) bool {
+	meta, ok := n.Pinning.Metadata(c)
+	return ok && meta.Name == snapshotPinName
+}
+
+// pinnedElsewhere returns true if another pinned snapshot has the same root
+// as snap.
+func pinnedElsewhere(store *mfs.SnapshotStore, snap *mfs.Snapshot) (bool, error) {
+	snaps, err := store.List()
+	if err != nil {
+		return false, err
+	}
+
+	for _, other := range snaps {
+		if other.ID != snap.ID && other.Pinned && other.Cid.Equals(snap.Cid) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// writeChanges prints changes one per line, prefixed with '+' for additions,
+// '~' for modifications and '-' for removals.
+func writeChanges(w io.Writer, changes []*dagutils.Change) {
+	for _, change := range changes {
+		switch change.Type {
+		case dagutils.Add:
+			fmt.Fprintf(w, "+ %s %q\n", change.After, change.Path)
+		case dagutils.Mod:
+			fmt.Fprintf(w, "~ %s %s %q\n", change.Before, change.After, change.Path)
+		case dagutils.Remove:
+			fmt.Fprintf(w, "- %s %q\n", change.Before, change.Path)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	cmds "github.com/ipfs/go-ipfs/commands"
+	e "github.com/ipfs/go-ipfs/core/commands/e"
+	coreunix "github.com/ipfs/go-ipfs/core/coreunix"
+	dagutils "github.com/ipfs/go-ipfs/merkledag/utils"
+	mfs "github.com/ipfs/go-ipfs/mfs"
+	cmdkit "gx/ipfs/QmQp2a2Hhb7F6eK2A5hN8f9aJy4mtkEikL9Zj4cgB7d1dD/go-ipfs-cmdkit"
+)
+
+type FilesSyncOutput struct {
+	Changes []*dagutils.Change
+}
+
+var FilesSyncCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Mirror a local directory into mfs.",
+		ShortDescription: `
+Makes <path> mirror the local directory <local-dir>: files that are new or
+changed since the last sync are added, and files that no longer exist locally
+are removed. Prints the changes made.
+
+    $ ipfs files sync /srv/www /www
+    + QmPXFqyxdm6Kw4WZhR7PGcJ4vM7ncS9Fuq7ZmPxmE3dXPY "index.html"
+    - QmSNssW5y6HJT5RJQJp2eRbJ5LxVWjAQPbbvLnEbQy6pVX "old.html"
+    1 added, 0 modified, 1 removed
+
+Files are added with their mode and modification time. A file whose size and
+modification time match the ones stored in mfs is considered unchanged, unless
+'--checksum' is given, which compares the content of all files instead.
+
+<local-dir> is read by the daemon, if one is running, and must be an absolute
+path.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("local-dir", true, false, "Absolute path of the local directory to mirror."),
+		cmdkit.StringArg("path", true, false, "Path of the mfs directory to update."),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.BoolOption("checksum", "Compare the content of files, rather than their size and modification time."),
+		cmdkit.StringOption("chunker", "s", "Chunking algorithm, size-[bytes] or rabin-[min]-[avg]-[max]").WithDefault("size-262144"),
+		cmdkit.BoolOption("trickle", "t", "Use trickle-dag format for dag generation."),
+		cmdkit.BoolOption("raw-leaves", "Use raw blocks for leaf nodes. (experimental)"),
+		cidVersionOption,
+		hashOption,
+	},
+	Run: func(req cmds.Request, res cmds.Response) {
+		n, err := req.InvocContext().GetNode()
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		local := req.Arguments()[0]
+		if !filepath.IsAbs(local) {
+			res.SetError(fmt.Errorf("%s is not an absolute path", local), cmdkit.ErrClient)
+			return
+		}
+
+		path, err := checkPath(req.Arguments()[1])
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		prefix, err := getPrefix(req)
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		root, err := filesRoot(req, n)
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		checksum, _, _ := req.Option("checksum").Bool()
+		chunker, _, _ := req.Option("chunker").String()
+		trickle, _, _ := req.Option("trickle").Bool()
+		rawLeaves, rawLeavesSet, _ := req.Option("raw-leaves").Bool()
+		if !rawLeavesSet && prefix != nil && prefix.Version > 0 {
+			rawLeaves = true
+		}
+		flush, _, _ := req.Option("flush").Bool()
+
+		// keep the garbage collector from removing added blocks before
+		// they are linked
+		defer n.Blockstore.PinLock().Unlock()
+
+		syncer := coreunix.NewSyncer(req.Context(), n.DAG, root)
+		syncer.Checksum = checksum
+		syncer.Chunker = chunker
+		syncer.Trickle = trickle
+		syncer.RawLeaves = rawLeaves
+		syncer.Prefix = prefix
+
+		changes, err := syncer.Sync(local, path)
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		if flush {
+			if err := mfs.FlushPath(root, path); err != nil {
+				res.SetError(err, cmdkit.ErrNormal)
+				return
+			}
+		}
+
+		res.SetOutput(&FilesSyncOutput{Changes: changes})
+	},
+	Marshalers: cmds.MarshalerMap{
+		cmds.Text: func(res cmds.Response) (io.Reader, error) {
+			v, err := unwrapOutput(res.Output())
+			if err != nil {
+				return nil, err
+			}
+
+			out, ok := v.(*FilesSyncOutput)
+			if !ok {
+				return nil, e.TypeErr(out, v)
+			}
+
+			var count [3]int
+			for _, change := range out.Changes {
+				count[change.Type]++
+			}
+
+			buf := new(bytes.Buffer)
+			writeChanges(buf, out.Changes)
+			fmt.Fprintf(buf, "%d added, %d modified, %d removed\n", count[dagutils.Add], count[dagutils.Mod], count[dagutils.Remove])
+			return buf, nil
+		},
+	},
+	Type: FilesSyncOutput{},
+}
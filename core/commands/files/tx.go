@@ -0,0 +1,284 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	cmds "github.com/ipfs/go-ipfs/commands"
+	core "github.com/ipfs/go-ipfs/core"
+	e "github.com/ipfs/go-ipfs/core/commands/e"
+	mfs "github.com/ipfs/go-ipfs/mfs"
+	cmdkit "gx/ipfs/QmQp2a2Hhb7F6eK2A5hN8f9aJy4mtkEikL9Zj4cgB7d1dD/go-ipfs-cmdkit"
+)
+
+var FilesTxCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Apply several changes to the files root at once.",
+		ShortDescription: `
+A transaction stages changes in a private copy of the files root, and applies
+them all as a single update of '/' when it is committed. Other 'ipfs files'
+commands operate on the copy of a transaction when given '--tx'.
+
+    $ ipfs files tx begin
+    1
+    $ ipfs files mv --tx=1 /inbox/report /archive/report
+    $ ipfs files rm --tx=1 -r /inbox
+    $ ipfs files tx commit 1
+    QmPXFqyxdm6Kw4WZhR7PGcJ4vM7ncS9Fuq7ZmPxmE3dXPY
+
+Committing fails if '/' changed since the transaction began, in which case the
+transaction is left open so that it can be inspected or rolled back.
+Transactions are kept in the repo until they are committed or rolled back.
+`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"begin":    filesTxBeginCmd,
+		"commit":   filesTxCommitCmd,
+		"rollback": filesTxRollbackCmd,
+		"ls":       filesTxLsCmd,
+	},
+}
+
+// TxEntry describes a transaction. Root is the hash of its working copy,
+// or the new files root once committed.
+type TxEntry struct {
+	ID      uint64
+	Created time.Time
+	Base    string
+	Root    string
+}
+
+type TxList struct {
+	Transactions []TxEntry
+}
+
+// filesRoot returns the root the command operates on: the working copy of
+// the transaction given with '--tx', or the files root.
+func filesRoot(req cmds.Request, n *core.IpfsNode) (*mfs.Root, error) {
+	id, found, err := req.Option("tx").Int()
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return n.FilesRoot, nil
+	}
+
+	tx, err := n.FilesRoot.Tx(uint64(id))
+	if err != nil {
+		return nil, fmt.Errorf("transaction %d: %s", id, err)
+	}
+	return tx.Root(), nil
+}
+
+func getTx(n *core.IpfsNode, arg string) (*mfs.Tx, error) {
+	id, err := strconv.ParseUint(arg, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid transaction ID %q", arg)
+	}
+
+	tx, err := n.FilesRoot.Tx(id)
+	if err != nil {
+		return nil, fmt.Errorf("transaction %d: %s", id, err)
+	}
+	return tx, nil
+}
+
+func txEntry(tx *mfs.Tx) (TxEntry, error) {
+	nd, err := tx.Root().GetValue().GetNode()
+	if err != nil {
+		return TxEntry{}, err
+	}
+
+	return TxEntry{
+		ID:      tx.ID,
+		Created: tx.Created,
+		Base:    tx.Base.String(),
+		Root:    nd.Cid().String(),
+	}, nil
+}
+
+var filesTxBeginCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Begin a transaction.",
+		ShortDescription: `
+Begins a transaction from the current files root. Prints the ID of the
+transaction.
+`,
+	},
+	Run: func(req cmds.Request, res cmds.Response) {
+		n, err := req.InvocContext().GetNode()
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		tx, err := n.FilesRoot.Begin()
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		out, err := txEntry(tx)
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+		res.SetOutput(&out)
+	},
+	Marshalers: cmds.MarshalerMap{
+		cmds.Text: func(res cmds.Response) (io.Reader, error) {
+			v, err := unwrapOutput(res.Output())
+			if err != nil {
+				return nil, err
+			}
+
+			out, ok := v.(*TxEntry)
+			if !ok {
+				return nil, e.TypeErr(out, v)
+			}
+			return bytes.NewBufferString(fmt.Sprintf("%d\n", out.ID)), nil
+		},
+	},
+	Type: TxEntry{},
+}
+
+var filesTxCommitCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Commit a transaction.",
+		ShortDescription: `
+Applies the changes staged in a transaction to the files root, and prints the
+hash of the new files root.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("id", true, false, "ID of the transaction."),
+	},
+	Run: func(req cmds.Request, res cmds.Response) {
+		n, err := req.InvocContext().GetNode()
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		tx, err := getTx(n, req.Arguments()[0])
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		out, err := txEntry(tx)
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		c, err := tx.Commit()
+		if err != nil {
+			res.SetError(fmt.Errorf("transaction %d: %s", tx.ID, err), cmdkit.ErrNormal)
+			return
+		}
+
+		out.Root = c.String()
+		res.SetOutput(&out)
+	},
+	Marshalers: cmds.MarshalerMap{
+		cmds.Text: func(res cmds.Response) (io.Reader, error) {
+			v, err := unwrapOutput(res.Output())
+			if err != nil {
+				return nil, err
+			}
+
+			out, ok := v.(*TxEntry)
+			if !ok {
+				return nil, e.TypeErr(out, v)
+			}
+			return bytes.NewBufferString(out.Root + "\n"), nil
+		},
+	},
+	Type: TxEntry{},
+}
+
+var filesTxRollbackCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Discard a transaction.",
+		ShortDescription: `
+Discards a transaction and the changes staged in it. The files root is left
+as it is.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("id", true, false, "ID of the transaction."),
+	},
+	Run: func(req cmds.Request, res cmds.Response) {
+		n, err := req.InvocContext().GetNode()
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		tx, err := getTx(n, req.Arguments()[0])
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		if err := tx.Rollback(); err != nil {
+			res.SetError(fmt.Errorf("transaction %d: %s", tx.ID, err), cmdkit.ErrNormal)
+			return
+		}
+
+		res.SetOutput(nil)
+	},
+}
+
+var filesTxLsCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "List open transactions.",
+		ShortDescription: `
+Lists the open transactions, oldest first, with the time they began, the files
+root they began from, and the hash of their working copy.
+`,
+	},
+	Run: func(req cmds.Request, res cmds.Response) {
+		n, err := req.InvocContext().GetNode()
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		list := &TxList{Transactions: []TxEntry{}}
+		for _, tx := range n.FilesRoot.Transactions() {
+			ent, err := txEntry(tx)
+			if err != nil {
+				res.SetError(err, cmdkit.ErrNormal)
+				return
+			}
+			list.Transactions = append(list.Transactions, ent)
+		}
+
+		res.SetOutput(list)
+	},
+	Marshalers: cmds.MarshalerMap{
+		cmds.Text: func(res cmds.Response) (io.Reader, error) {
+			v, err := unwrapOutput(res.Output())
+			if err != nil {
+				return nil, err
+			}
+
+			list, ok := v.(*TxList)
+			if !ok {
+				return nil, e.TypeErr(list, v)
+			}
+
+			buf := new(bytes.Buffer)
+			for _, tx := range list.Transactions {
+				fmt.Fprintf(buf, "%d\t%s\t%s\t%s\n", tx.ID, tx.Created.Format(time.RFC3339), tx.Base, tx.Root)
+			}
+			return buf, nil
+		},
+	},
+	Type: TxList{},
+}
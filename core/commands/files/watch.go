@@ -0,0 +1,120 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	cmds "github.com/ipfs/go-ipfs/commands"
+	e "github.com/ipfs/go-ipfs/core/commands/e"
+	mfs "github.com/ipfs/go-ipfs/mfs"
+	cmdkit "gx/ipfs/QmQp2a2Hhb7F6eK2A5hN8f9aJy4mtkEikL9Zj4cgB7d1dD/go-ipfs-cmdkit"
+)
+
+// FilesEvent is a change reported by 'ipfs files watch'. From is only set
+// for moves, and Hash is empty for removals.
+type FilesEvent struct {
+	Type string
+	Path string
+	From string `json:",omitempty"`
+	Hash string `json:",omitempty"`
+}
+
+var FilesWatchCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Stream the changes made under a path.",
+		ShortDescription: `
+Prints the changes made at or below <path> as they happen, until the command
+is interrupted. Each change is one of:
+
+    create <path> <hash>
+    write <path> <hash>
+    move <from> <to> <hash>
+    remove <path>
+
+Only changes made by the daemon this command runs on are seen, so it is only
+useful with a running daemon. The path does not need to exist yet. A watcher
+that does not keep up with the changes is disconnected.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("path", false, false, "Path to watch. Default: '/'."),
+	},
+	Run: func(req cmds.Request, res cmds.Response) {
+		n, err := req.InvocContext().GetNode()
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		path := "/"
+		if len(req.Arguments()) > 0 {
+			path = req.Arguments()[0]
+		}
+		path, err = checkPath(path)
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		root, err := filesRoot(req, n)
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		ctx := req.Context()
+		events := root.Watch(ctx, path)
+
+		out := make(chan interface{})
+		res.SetOutput((<-chan interface{})(out))
+
+		go func() {
+			defer close(out)
+			for ev := range events {
+				select {
+				case out <- filesEvent(ev):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	},
+	Marshalers: cmds.MarshalerMap{
+		cmds.Text: func(res cmds.Response) (io.Reader, error) {
+			v, err := unwrapOutput(res.Output())
+			if err != nil {
+				return nil, err
+			}
+
+			ev, ok := v.(*FilesEvent)
+			if !ok {
+				return nil, e.TypeErr(ev, v)
+			}
+
+			buf := new(bytes.Buffer)
+			switch ev.Type {
+			case mfs.EventMove.String():
+				fmt.Fprintf(buf, "%s %s %s %s\n", ev.Type, ev.From, ev.Path, ev.Hash)
+			case mfs.EventRemove.String():
+				fmt.Fprintf(buf, "%s %s\n", ev.Type, ev.Path)
+			default:
+				fmt.Fprintf(buf, "%s %s %s\n", ev.Type, ev.Path, ev.Hash)
+			}
+			return buf, nil
+		},
+	},
+	Type: FilesEvent{},
+}
+
+func filesEvent(ev mfs.Event) *FilesEvent {
+	out := &FilesEvent{
+		Type: ev.Type.String(),
+		Path: ev.Path,
+		From: ev.From,
+	}
+	if ev.Cid != nil {
+		out.Hash = ev.Cid.String()
+	}
+	return out
+}
@@ -4,31 +4,22 @@ import (
 	"bytes"
 	"encoding/base64"
 	"encoding/json"
-	"encoding/xml"
-	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"strings"
 	"text/tabwriter"
 
 	cmds "github.com/ipfs/go-ipfs/commands"
-	core "github.com/ipfs/go-ipfs/core"
 	e "github.com/ipfs/go-ipfs/core/commands/e"
+	coreapi "github.com/ipfs/go-ipfs/core/coreapi"
 	dag "github.com/ipfs/go-ipfs/merkledag"
-	path "github.com/ipfs/go-ipfs/path"
-	pin "github.com/ipfs/go-ipfs/pin"
-	ft "github.com/ipfs/go-ipfs/unixfs"
 
 	node "gx/ipfs/QmNwUEK7QbwSqyKBu3mMtToo8SUc6wQJ7gdZq4gGGJqfnf/go-ipld-format"
 	cmdkit "gx/ipfs/QmQp2a2Hhb7F6eK2A5hN8f9aJy4mtkEikL9Zj4cgB7d1dD/go-ipfs-cmdkit"
-	cid "gx/ipfs/QmeSrf6pzut73u6zLQkRFQ3ygt3k6XFT2kjdYP8Tnkwwyg/go-cid"
 )
 
 // ErrObjectTooLarge is returned when too much data was read from stdin. current limit 2m
-var ErrObjectTooLarge = errors.New("input object was too large. limit is 2mbytes")
-
-const inputLimit = 2 << 20
+var ErrObjectTooLarge = coreapi.ErrObjectTooLarge
 
 type Node struct {
 	Links []Link
@@ -85,31 +76,25 @@ is the raw data of the object.
 		cmdkit.StringArg("key", true, false, "Key of the object to retrieve, in base58-encoded multihash format.").EnableStdin(),
 	},
 	Run: func(req cmds.Request, res cmds.Response) {
-		n, err := req.InvocContext().GetNode()
+		api, err := req.InvocContext().GetApi()
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
 			return
 		}
 
-		fpath, err := path.ParsePath(req.Arguments()[0])
+		path, err := coreapi.ParsePath(req.Arguments()[0])
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
 			return
 		}
 
-		node, err := core.Resolve(req.Context(), n.Namesys, n.Resolver, fpath)
+		data, err := api.Object().Data(req.Context(), path)
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
 			return
 		}
 
-		pbnode, ok := node.(*dag.ProtoNode)
-		if !ok {
-			res.SetError(dag.ErrNotProtobuf, cmdkit.ErrNormal)
-			return
-		}
-
-		res.SetOutput(bytes.NewReader(pbnode.Data()))
+		res.SetOutput(data)
 	},
 }
 
@@ -130,7 +115,7 @@ multihash.
 		cmdkit.BoolOption("headers", "v", "Print table headers (Hash, Size, Name)."),
 	},
 	Run: func(req cmds.Request, res cmds.Response) {
-		n, err := req.InvocContext().GetNode()
+		api, err := req.InvocContext().GetApi()
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
 			return
@@ -142,18 +127,38 @@ multihash.
 			return
 		}
 
-		fpath := path.Path(req.Arguments()[0])
-		node, err := core.Resolve(req.Context(), n.Namesys, n.Resolver, fpath)
+		path, err := coreapi.ParsePath(req.Arguments()[0])
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
 			return
 		}
 
-		output, err := getOutput(node)
+		rp, err := api.ResolvePath(req.Context(), path)
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
 			return
 		}
+
+		links, err := api.Object().Links(req.Context(), rp)
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		outLinks := make([]Link, len(links))
+		for i, link := range links {
+			outLinks[i] = Link{
+				Hash: link.Cid.String(),
+				Name: link.Name,
+				Size: link.Size,
+			}
+		}
+
+		output := &Object{
+			Hash:  rp.Cid().String(),
+			Links: outLinks,
+		}
+
 		res.SetOutput(output)
 	},
 	Marshalers: cmds.MarshalerMap{
@@ -208,15 +213,19 @@ This command outputs data in the following encodings:
 		cmdkit.StringArg("key", true, false, "Key of the object to retrieve, in base58-encoded multihash format.").EnableStdin(),
 	},
 	Run: func(req cmds.Request, res cmds.Response) {
-		n, err := req.InvocContext().GetNode()
+		api, err := req.InvocContext().GetApi()
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
 			return
 		}
 
-		fpath := path.Path(req.Arguments()[0])
+		path, err := coreapi.ParsePath(req.Arguments()[0])
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
 
-		object, err := core.Resolve(req.Context(), n.Namesys, n.Resolver, fpath)
+		object, err := api.Object().Get(req.Context(), path)
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
 			return
@@ -256,10 +265,42 @@ This command outputs data in the following encodings:
 				return nil, e.TypeErr(node, v)
 			}
 
-			// deserialize the Data field as text as this was the standard behaviour
-			object, err := deserializeNode(node, "text")
-			if err != nil {
-				return nil, err
+			object := new(dag.ProtoNode)
+			if !NodeEmpty(node) {
+				api, err := res.Request().InvocContext().GetApi()
+				if err != nil {
+					return nil, err
+				}
+
+				// the Data field holds the data as text as this was the
+				// standard behaviour, it is put back as base64 so that binary
+				// data survives the JSON encoding
+				in, err := json.Marshal(&Node{
+					Links: node.Links,
+					Data:  base64.StdEncoding.EncodeToString([]byte(node.Data)),
+				})
+				if err != nil {
+					return nil, err
+				}
+
+				ctx := res.Request().Context()
+				p, err := api.Object().Put(ctx, bytes.NewReader(in),
+					api.Object().WithInputEnc("json"),
+					api.Object().WithDataType("base64"))
+				if err != nil {
+					return nil, err
+				}
+
+				nd, err := api.Object().Get(ctx, p)
+				if err != nil {
+					return nil, err
+				}
+
+				pbnd, ok := nd.(*dag.ProtoNode)
+				if !ok {
+					return nil, dag.ErrNotProtobuf
+				}
+				object = pbnd
 			}
 
 			marshaled, err := object.Marshal()
@@ -290,26 +331,33 @@ var ObjectStatCmd = &cmds.Command{
 		cmdkit.StringArg("key", true, false, "Key of the object to retrieve, in base58-encoded multihash format.").EnableStdin(),
 	},
 	Run: func(req cmds.Request, res cmds.Response) {
-		n, err := req.InvocContext().GetNode()
+		api, err := req.InvocContext().GetApi()
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
 			return
 		}
 
-		fpath := path.Path(req.Arguments()[0])
-
-		object, err := core.Resolve(req.Context(), n.Namesys, n.Resolver, fpath)
+		path, err := coreapi.ParsePath(req.Arguments()[0])
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
 			return
 		}
 
-		ns, err := object.Stat()
+		stat, err := api.Object().Stat(req.Context(), path)
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
 			return
 		}
 
+		ns := &node.NodeStat{
+			Hash:           stat.Cid.String(),
+			NumLinks:       stat.NumLinks,
+			BlockSize:      stat.BlockSize,
+			LinksSize:      stat.LinksSize,
+			DataSize:       stat.DataSize,
+			CumulativeSize: stat.CumulativeSize,
+		}
+
 		res.SetOutput(ns)
 	},
 	Type: node.NodeStat{},
@@ -388,7 +436,7 @@ And then run:
 		cmdkit.BoolOption("quiet", "q", "Write minimal output."),
 	},
 	Run: func(req cmds.Request, res cmds.Response) {
-		n, err := req.InvocContext().GetNode()
+		api, err := req.InvocContext().GetApi()
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
 			return
@@ -418,11 +466,10 @@ And then run:
 			return
 		}
 
-		if dopin {
-			defer n.Blockstore.PinLock().Unlock()
-		}
-
-		objectCid, err := objectPut(n, input, inputenc, datafieldenc)
+		p, err := api.Object().Put(req.Context(), input,
+			api.Object().WithDataType(datafieldenc),
+			api.Object().WithInputEnc(inputenc),
+			api.Object().WithPin(dopin))
 		if err != nil {
 			errType := cmdkit.ErrNormal
 			if err == ErrUnknownObjectEnc {
@@ -432,16 +479,7 @@ And then run:
 			return
 		}
 
-		if dopin {
-			n.Pinning.PinWithMode(objectCid, pin.Recursive)
-			err = n.Pinning.Flush()
-			if err != nil {
-				res.SetError(err, cmdkit.ErrNormal)
-				return
-			}
-		}
-
-		res.SetOutput(&Object{Hash: objectCid.String()})
+		res.SetOutput(&Object{Hash: p.Cid().String()})
 	},
 	Marshalers: cmds.MarshalerMap{
 		cmds.Text: func(res cmds.Response) (io.Reader, error) {
@@ -487,29 +525,24 @@ Available templates:
 		cmdkit.StringArg("template", false, false, "Template to use. Optional."),
 	},
 	Run: func(req cmds.Request, res cmds.Response) {
-		n, err := req.InvocContext().GetNode()
+		api, err := req.InvocContext().GetApi()
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
 			return
 		}
 
-		node := new(dag.ProtoNode)
+		template := "empty"
 		if len(req.Arguments()) == 1 {
-			template := req.Arguments()[0]
-			var err error
-			node, err = nodeFromTemplate(template)
-			if err != nil {
-				res.SetError(err, cmdkit.ErrNormal)
-				return
-			}
+			template = req.Arguments()[0]
 		}
 
-		k, err := n.DAG.Add(node)
+		nd, err := api.Object().New(req.Context(), api.Object().WithType(template))
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
 			return
 		}
-		res.SetOutput(&Object{Hash: k.String()})
+
+		res.SetOutput(&Object{Hash: nd.Cid().String()})
 	},
 	Marshalers: cmds.MarshalerMap{
 		cmds.Text: func(res cmds.Response) (io.Reader, error) {
@@ -529,154 +562,11 @@ Available templates:
 	Type: Object{},
 }
 
-func nodeFromTemplate(template string) (*dag.ProtoNode, error) {
-	switch template {
-	case "unixfs-dir":
-		return ft.EmptyDirNode(), nil
-	default:
-		return nil, fmt.Errorf("template '%s' not found", template)
-	}
-}
-
 // ErrEmptyNode is returned when the input to 'ipfs object put' contains no data
-var ErrEmptyNode = errors.New("no data or links in this node")
-
-// objectPut takes a format option, serializes bytes from stdin and updates the dag with that data
-func objectPut(n *core.IpfsNode, input io.Reader, encoding string, dataFieldEncoding string) (*cid.Cid, error) {
-
-	data, err := ioutil.ReadAll(io.LimitReader(input, inputLimit+10))
-	if err != nil {
-		return nil, err
-	}
-
-	if len(data) >= inputLimit {
-		return nil, ErrObjectTooLarge
-	}
-
-	var dagnode *dag.ProtoNode
-	switch getObjectEnc(encoding) {
-	case objectEncodingJSON:
-		node := new(Node)
-		err = json.Unmarshal(data, node)
-		if err != nil {
-			return nil, err
-		}
-
-		// check that we have data in the Node to add
-		// otherwise we will add the empty object without raising an error
-		if NodeEmpty(node) {
-			return nil, ErrEmptyNode
-		}
-
-		dagnode, err = deserializeNode(node, dataFieldEncoding)
-		if err != nil {
-			return nil, err
-		}
-
-	case objectEncodingProtobuf:
-		dagnode, err = dag.DecodeProtobuf(data)
-
-	case objectEncodingXML:
-		node := new(Node)
-		err = xml.Unmarshal(data, node)
-		if err != nil {
-			return nil, err
-		}
-
-		// check that we have data in the Node to add
-		// otherwise we will add the empty object without raising an error
-		if NodeEmpty(node) {
-			return nil, ErrEmptyNode
-		}
-
-		dagnode, err = deserializeNode(node, dataFieldEncoding)
-		if err != nil {
-			return nil, err
-		}
-
-	default:
-		return nil, ErrUnknownObjectEnc
-	}
-
-	if err != nil {
-		return nil, err
-	}
-
-	_, err = n.DAG.Add(dagnode)
-	if err != nil {
-		return nil, err
-	}
-
-	return dagnode.Cid(), nil
-}
+var ErrEmptyNode = coreapi.ErrEmptyNode
 
 // ErrUnknownObjectEnc is returned if a invalid encoding is supplied
-var ErrUnknownObjectEnc = errors.New("unknown object encoding")
-
-type objectEncoding string
-
-const (
-	objectEncodingJSON     objectEncoding = "json"
-	objectEncodingProtobuf                = "protobuf"
-	objectEncodingXML                     = "xml"
-)
-
-func getObjectEnc(o interface{}) objectEncoding {
-	v, ok := o.(string)
-	if !ok {
-		// chosen as default because it's human readable
-		return objectEncodingJSON
-	}
-
-	return objectEncoding(v)
-}
-
-func getOutput(dagnode node.Node) (*Object, error) {
-	c := dagnode.Cid()
-	output := &Object{
-		Hash:  c.String(),
-		Links: make([]Link, len(dagnode.Links())),
-	}
-
-	for i, link := range dagnode.Links() {
-		output.Links[i] = Link{
-			Name: link.Name,
-			Hash: link.Cid.String(),
-			Size: link.Size,
-		}
-	}
-
-	return output, nil
-}
-
-// converts the Node object into a real dag.ProtoNode
-func deserializeNode(nd *Node, dataFieldEncoding string) (*dag.ProtoNode, error) {
-	dagnode := new(dag.ProtoNode)
-	switch dataFieldEncoding {
-	case "text":
-		dagnode.SetData([]byte(nd.Data))
-	case "base64":
-		data, _ := base64.StdEncoding.DecodeString(nd.Data)
-		dagnode.SetData(data)
-	default:
-		return nil, fmt.Errorf("Unkown data field encoding")
-	}
-
-	dagnode.SetLinks(make([]*node.Link, len(nd.Links)))
-	for i, link := range nd.Links {
-		c, err := cid.Decode(link.Hash)
-		if err != nil {
-			return nil, err
-		}
-		dagnode.Links()[i] = &node.Link{
-			Name: link.Name,
-			Size: link.Size,
-			Cid:  c,
-		}
-	}
-
-	return dagnode, nil
-}
+var ErrUnknownObjectEnc = coreapi.ErrUnknownObjectEnc
 
 func NodeEmpty(node *Node) bool {
 	return (node.Data == "" && len(node.Links) == 0)
@@ -5,20 +5,24 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"strings"
 	"time"
 
 	cmds "github.com/ipfs/go-ipfs/commands"
 	core "github.com/ipfs/go-ipfs/core"
 	e "github.com/ipfs/go-ipfs/core/commands/e"
+	coreapi "github.com/ipfs/go-ipfs/core/coreapi"
+	coreiface "github.com/ipfs/go-ipfs/core/coreapi/interface"
+	options "github.com/ipfs/go-ipfs/core/coreapi/interface/options"
 	corerepo "github.com/ipfs/go-ipfs/core/corerepo"
 	dag "github.com/ipfs/go-ipfs/merkledag"
 	path "github.com/ipfs/go-ipfs/path"
 	pin "github.com/ipfs/go-ipfs/pin"
+	gc "github.com/ipfs/go-ipfs/pin/gc"
 	uio "github.com/ipfs/go-ipfs/unixfs/io"
 
 	u "gx/ipfs/QmPsAfmDBnZN3kZGSuNwvCNDZiHneERSKmRcFyG3UkvcT3/go-ipfs-util"
 	"gx/ipfs/QmQp2a2Hhb7F6eK2A5hN8f9aJy4mtkEikL9Zj4cgB7d1dD/go-ipfs-cmdkit"
-	cid "gx/ipfs/QmeSrf6pzut73u6zLQkRFQ3ygt3k6XFT2kjdYP8Tnkwwyg/go-cid"
 )
 
 var PinCmd = &cmds.Command{
@@ -32,6 +36,7 @@ var PinCmd = &cmds.Command{
 		"ls":     listPinCmd,
 		"verify": verifyPinCmd,
 		"update": updatePinCmd,
+		"why":    whyPinCmd,
 	},
 }
 
@@ -56,17 +61,18 @@ var addPinCmd = &cmds.Command{
 	Options: []cmdkit.Option{
 		cmdkit.BoolOption("recursive", "r", "Recursively pin the object linked to by the specified object(s).").WithDefault(true),
 		cmdkit.BoolOption("progress", "Show progress"),
+		cmdkit.StringOption("name", "n", "A name for the pin."),
+		cmdkit.StringOption("label", "l", "Comma separated key=value labels to attach to the pin."),
+		cmdkit.StringOption("ttl", "Remove the pin after this duration (e.g. 72h). The daemon removes expired pins."),
 	},
 	Type: AddPinOutput{},
 	Run: func(req cmds.Request, res cmds.Response) {
-		n, err := req.InvocContext().GetNode()
+		api, err := req.InvocContext().GetApi()
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
 			return
 		}
 
-		defer n.Blockstore.PinLock().Unlock()
-
 		// set recursive flag
 		recursive, _, err := req.Option("recursive").Bool()
 		if err != nil {
@@ -75,13 +81,43 @@ var addPinCmd = &cmds.Command{
 		}
 		showProgress, _, _ := req.Option("progress").Bool()
 
+		opts := []options.PinAddOption{api.Pin().WithRecursive(recursive)}
+		name, _, _ := req.Option("name").String()
+		if name != "" {
+			opts = append(opts, api.Pin().WithName(name))
+		}
+
+		labelStr, _, _ := req.Option("label").String()
+		labels, err := parseLabels(labelStr)
+		if err != nil {
+			res.SetError(err, cmdkit.ErrClient)
+			return
+		}
+		for k, v := range labels {
+			opts = append(opts, api.Pin().WithLabel(k, v))
+		}
+
+		ttlStr, _, _ := req.Option("ttl").String()
+		if ttlStr != "" {
+			ttl, err := time.ParseDuration(ttlStr)
+			if err != nil {
+				res.SetError(err, cmdkit.ErrClient)
+				return
+			}
+			if ttl <= 0 {
+				res.SetError(fmt.Errorf("ttl must be positive"), cmdkit.ErrClient)
+				return
+			}
+			opts = append(opts, api.Pin().WithTTL(ttl))
+		}
+
 		if !showProgress {
-			added, err := corerepo.Pin(n, req.Context(), req.Arguments(), recursive)
+			added, err := pinAddMany(req.Context(), api, req.Arguments(), opts...)
 			if err != nil {
 				res.SetError(err, cmdkit.ErrNormal)
 				return
 			}
-			res.SetOutput(&AddPinOutput{Pins: cidsToStrings(added)})
+			res.SetOutput(&AddPinOutput{Pins: added})
 			return
 		}
 
@@ -91,12 +127,12 @@ var addPinCmd = &cmds.Command{
 		ctx := v.DeriveContext(req.Context())
 
 		type pinResult struct {
-			pins []*cid.Cid
+			pins []string
 			err  error
 		}
 		ch := make(chan pinResult, 1)
 		go func() {
-			added, err := corerepo.Pin(n, ctx, req.Arguments(), recursive)
+			added, err := pinAddMany(ctx, api, req.Arguments(), opts...)
 			ch <- pinResult{pins: added, err: err}
 		}()
 
@@ -114,7 +150,7 @@ var addPinCmd = &cmds.Command{
 				if pv := v.Value(); pv != 0 {
 					out <- &AddPinOutput{Progress: v.Value()}
 				}
-				out <- &AddPinOutput{Pins: cidsToStrings(val.pins)}
+				out <- &AddPinOutput{Pins: val.pins}
 				return
 			case <-ticker.C:
 				out <- &AddPinOutput{Progress: v.Value()}
@@ -184,7 +220,7 @@ collected if needed. (By default, recursively. Use -r=false for direct pins.)
 	},
 	Type: PinOutput{},
 	Run: func(req cmds.Request, res cmds.Response) {
-		n, err := req.InvocContext().GetNode()
+		api, err := req.InvocContext().GetApi()
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
 			return
@@ -197,13 +233,29 @@ collected if needed. (By default, recursively. Use -r=false for direct pins.)
 			return
 		}
 
-		removed, err := corerepo.Unpin(n, req.Context(), req.Arguments(), recursive)
-		if err != nil {
-			res.SetError(err, cmdkit.ErrNormal)
-			return
+		removed := make([]string, len(req.Arguments()))
+		for i, b := range req.Arguments() {
+			p, err := coreapi.ParsePath(b)
+			if err != nil {
+				res.SetError(err, cmdkit.ErrNormal)
+				return
+			}
+
+			rp, err := api.ResolvePath(req.Context(), p)
+			if err != nil {
+				res.SetError(err, cmdkit.ErrNormal)
+				return
+			}
+
+			err = api.Pin().Rm(req.Context(), rp, api.Pin().WithRmRecursive(recursive))
+			if err != nil {
+				res.SetError(err, cmdkit.ErrNormal)
+				return
+			}
+			removed[i] = rp.Cid().String()
 		}
 
-		res.SetOutput(&PinOutput{cidsToStrings(removed)})
+		res.SetOutput(&PinOutput{removed})
 	},
 	Marshalers: cmds.MarshalerMap{
 		cmds.Text: func(res cmds.Response) (io.Reader, error) {
@@ -253,6 +305,9 @@ With arguments, the command fails if any of the arguments is not a pinned
 object. And if --type=<type> is additionally used, the command will also fail
 if any of the arguments is not of the specified type.
 
+Use --name=<name> and --label=<key>=<value>[,<key>=<value>...] to list only
+the direct and recursive pins carrying that name and all of those labels.
+
 Example:
 	$ echo "hello" | ipfs add -q
 	QmZULkCELmmk5XNfCgTnCyFgAVxBRBXyDHGGMVoLFLiXEN
@@ -276,6 +331,8 @@ Example:
 	Options: []cmdkit.Option{
 		cmdkit.StringOption("type", "t", "The type of pinned keys to list. Can be \"direct\", \"indirect\", \"recursive\", or \"all\".").WithDefault("all"),
 		cmdkit.BoolOption("quiet", "q", "Write just hashes of objects."),
+		cmdkit.StringOption("name", "n", "Only list pins with this name."),
+		cmdkit.StringOption("label", "l", "Only list pins with all of these comma separated key=value labels."),
 	},
 	Run: func(req cmds.Request, res cmds.Response) {
 		n, err := req.InvocContext().GetNode()
@@ -298,12 +355,28 @@ Example:
 			return
 		}
 
+		name, _, _ := req.Option("name").String()
+		labelStr, _, _ := req.Option("label").String()
+		labels, err := parseLabels(labelStr)
+		if err != nil {
+			res.SetError(err, cmdkit.ErrClient)
+			return
+		}
+
 		var keys map[string]RefKeyObject
 
 		if len(req.Arguments()) > 0 {
-			keys, err = pinLsKeys(req.Arguments(), typeStr, req.Context(), n)
+			keys, err = pinLsKeys(req.Arguments(), typeStr, name, labels, req.Context(), n)
 		} else {
-			keys, err = pinLsAll(typeStr, req.Context(), n)
+			api := coreapi.NewCoreAPI(n)
+			opts := []options.PinLsOption{api.Pin().WithType(typeStr)}
+			if name != "" {
+				opts = append(opts, api.Pin().WithNameFilter(name))
+			}
+			for k, v := range labels {
+				opts = append(opts, api.Pin().WithLabelFilter(k, v))
+			}
+			keys, err = pinLsAll(req.Context(), api, opts...)
 		}
 
 		if err != nil {
@@ -333,9 +406,17 @@ Example:
 			for k, v := range keys.Keys {
 				if quiet {
 					fmt.Fprintf(out, "%s\n", k)
-				} else {
-					fmt.Fprintf(out, "%s %s\n", k, v.Type)
+					continue
+				}
+
+				fmt.Fprintf(out, "%s %s", k, v.Type)
+				if v.Name != "" {
+					fmt.Fprintf(out, " %s", v.Name)
 				}
+				if v.Expires != "" {
+					fmt.Fprintf(out, " (expires %s)", v.Expires)
+				}
+				fmt.Fprintln(out)
 			}
 			return out, nil
 		},
@@ -361,7 +442,7 @@ new pin and removing the old one.
 	},
 	Type: PinOutput{},
 	Run: func(req cmds.Request, res cmds.Response) {
-		n, err := req.InvocContext().GetNode()
+		api, err := req.InvocContext().GetApi()
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
 			return
@@ -373,36 +454,19 @@ new pin and removing the old one.
 			return
 		}
 
-		from, err := path.ParsePath(req.Arguments()[0])
-		if err != nil {
-			res.SetError(err, cmdkit.ErrNormal)
-			return
-		}
-
-		to, err := path.ParsePath(req.Arguments()[1])
+		from, err := coreapi.ParsePath(req.Arguments()[0])
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
 			return
 		}
 
-		r := &path.Resolver{
-			DAG:         n.DAG,
-			ResolveOnce: uio.ResolveUnixfsOnce,
-		}
-
-		fromc, err := core.ResolveToCid(req.Context(), n.Namesys, r, from)
+		to, err := coreapi.ParsePath(req.Arguments()[1])
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
 			return
 		}
 
-		toc, err := core.ResolveToCid(req.Context(), n.Namesys, r, to)
-		if err != nil {
-			res.SetError(err, cmdkit.ErrNormal)
-			return
-		}
-
-		err = n.Pinning.Update(req.Context(), fromc, toc, unpin)
+		err = api.Pin().Update(req.Context(), from, to, api.Pin().WithUnpin(unpin))
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
 			return
@@ -433,7 +497,7 @@ var verifyPinCmd = &cmds.Command{
 		cmdkit.BoolOption("quiet", "q", "Write just hashes of broken pins."),
 	},
 	Run: func(req cmds.Request, res cmds.Response) {
-		n, err := req.InvocContext().GetNode()
+		api, err := req.InvocContext().GetApi()
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
 			return
@@ -444,13 +508,18 @@ var verifyPinCmd = &cmds.Command{
 
 		if verbose && quiet {
 			res.SetError(fmt.Errorf("The --verbose and --quiet options can not be used at the same time"), cmdkit.ErrNormal)
+			return
 		}
 
 		opts := pinVerifyOpts{
 			explain:   !quiet,
 			includeOk: verbose,
 		}
-		out := pinVerify(req.Context(), n, opts)
+		out, err := pinVerify(req.Context(), api, opts)
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
 
 		res.SetOutput(out)
 	},
@@ -482,15 +551,134 @@ var verifyPinCmd = &cmds.Command{
 	},
 }
 
-type RefKeyObject struct {
+// PinReason is one of the roots keeping an object, as returned by 'pin why'
+type PinReason struct {
 	Type string
+	Root string
+	Path string `json:",omitempty"`
+}
+
+// PinWhyOutput is the output type of the 'pin why' command
+type PinWhyOutput struct {
+	Cid     string
+	Reasons []PinReason
+}
+
+var whyPinCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Explain why an object is kept in local storage.",
+		ShortDescription: `
+Lists every root that keeps the given object from being garbage collected:
+recursive and direct pins, internal pinning objects and the files root
+('best-effort'), along with the path of links from each root down to the
+object.
+`,
+		LongDescription: `
+Lists every root that keeps the given object from being garbage collected:
+recursive and direct pins, internal pinning objects and the files root
+('best-effort'), along with the path of links from each root down to the
+object. Links without a name are shown as the hash they point to.
+
+Example:
+	$ ipfs pin why QmZULkCELmmk5XNfCgTnCyFgAVxBRBXyDHGGMVoLFLiXEN
+	recursive QmbvB1yMBCuJWEsKwTJ7dKsMWrNK7N5yxHnrqUV7dRWXtW/hello.txt
+`,
+	},
+
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("ipfs-path", true, false, "Path to the object to explain."),
+	},
+	Type: PinWhyOutput{},
+	Run: func(req cmds.Request, res cmds.Response) {
+		n, err := req.InvocContext().GetNode()
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		p, err := path.ParsePath(req.Arguments()[0])
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		c, err := core.ResolveToCid(req.Context(), n.Namesys, n.Resolver, p)
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		roots, err := corerepo.BestEffortRoots(n.FilesRoot)
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		reasons, err := gc.Why(req.Context(), n.Pinning, n.DAG, roots, c)
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		out := &PinWhyOutput{Cid: c.String(), Reasons: make([]PinReason, 0, len(reasons))}
+		for _, r := range reasons {
+			out.Reasons = append(out.Reasons, PinReason{
+				Type: r.Type,
+				Root: r.Root.String(),
+				Path: strings.Join(r.Path, "/"),
+			})
+		}
+		res.SetOutput(out)
+	},
+	Marshalers: cmds.MarshalerMap{
+		cmds.Text: func(res cmds.Response) (io.Reader, error) {
+			v, err := unwrapOutput(res.Output())
+			if err != nil {
+				return nil, err
+			}
+
+			out, ok := v.(*PinWhyOutput)
+			if !ok {
+				return nil, e.TypeErr(out, v)
+			}
+
+			buf := new(bytes.Buffer)
+			if len(out.Reasons) == 0 {
+				fmt.Fprintf(buf, "%s is not kept by any pin\n", out.Cid)
+			}
+			for _, r := range out.Reasons {
+				if r.Path == "" {
+					fmt.Fprintf(buf, "%s %s\n", r.Type, r.Root)
+				} else {
+					fmt.Fprintf(buf, "%s %s/%s\n", r.Type, r.Root, r.Path)
+				}
+			}
+			return buf, nil
+		},
+	},
+}
+
+type RefKeyObject struct {
+	Type    string
+	Name    string            `json:",omitempty"`
+	Labels  map[string]string `json:",omitempty"`
+	Expires string            `json:",omitempty"` // RFC 3339
+}
+
+func formatExpiry(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
 }
 
 type RefKeyList struct {
 	Keys map[string]RefKeyObject
 }
 
-func pinLsKeys(args []string, typeStr string, ctx context.Context, n *core.IpfsNode) (map[string]RefKeyObject, error) {
+// pinLsKeys lists the pins of args, leaving out the ones whose metadata do not
+// match name and labels.
+func pinLsKeys(args []string, typeStr, name string, labels map[string]string, ctx context.Context, n *core.IpfsNode) (map[string]RefKeyObject, error) {
 
 	mode, ok := pin.StringToPinMode(typeStr)
 	if !ok {
@@ -524,49 +712,64 @@ func pinLsKeys(args []string, typeStr string, ctx context.Context, n *core.IpfsN
 			return nil, fmt.Errorf("path '%s' is not pinned", p)
 		}
 
+		meta, _ := n.Pinning.Metadata(c)
+		if !meta.Matches(name, labels) {
+			continue
+		}
+
 		switch pinType {
 		case "direct", "indirect", "recursive", "internal":
 		default:
 			pinType = "indirect through " + pinType
 		}
-		keys[c.String()] = RefKeyObject{
+		obj := RefKeyObject{
 			Type: pinType,
 		}
+		if meta != nil {
+			obj.Name = meta.Name
+			obj.Labels = meta.Labels
+			obj.Expires = formatExpiry(meta.ExpiresAt())
+		}
+		keys[c.String()] = obj
 	}
 
 	return keys, nil
 }
 
-func pinLsAll(typeStr string, ctx context.Context, n *core.IpfsNode) (map[string]RefKeyObject, error) {
+func pinLsAll(ctx context.Context, api coreiface.CoreAPI, opts ...options.PinLsOption) (map[string]RefKeyObject, error) {
+	pins, err := api.Pin().Ls(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
 
 	keys := make(map[string]RefKeyObject)
-
-	AddToResultKeys := func(keyList []*cid.Cid, typeStr string) {
-		for _, c := range keyList {
-			keys[c.String()] = RefKeyObject{
-				Type: typeStr,
-			}
+	for _, p := range pins {
+		keys[p.Path().Cid().String()] = RefKeyObject{
+			Type:    p.Type(),
+			Name:    p.Name(),
+			Labels:  p.Labels(),
+			Expires: formatExpiry(p.Expires()),
 		}
 	}
 
-	if typeStr == "direct" || typeStr == "all" {
-		AddToResultKeys(n.Pinning.DirectKeys(), "direct")
+	return keys, nil
+}
+
+// parseLabels parses a comma separated list of key=value pairs
+func parseLabels(s string) (map[string]string, error) {
+	labels := make(map[string]string)
+	if s == "" {
+		return labels, nil
 	}
-	if typeStr == "indirect" || typeStr == "all" {
-		set := cid.NewSet()
-		for _, k := range n.Pinning.RecursiveKeys() {
-			err := dag.EnumerateChildren(n.Context(), n.DAG.GetLinks, k, set.Visit)
-			if err != nil {
-				return nil, err
-			}
+
+	for _, kv := range strings.Split(s, ",") {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid label '%s', must be of the form key=value", kv)
 		}
-		AddToResultKeys(set.Keys(), "indirect")
+		labels[parts[0]] = parts[1]
 	}
-	if typeStr == "recursive" || typeStr == "all" {
-		AddToResultKeys(n.Pinning.RecursiveKeys(), "recursive")
-	}
-
-	return keys, nil
+	return labels, nil
 }
 
 // PinVerifyRes is the result returned for each pin checked in "pin verify"
@@ -592,53 +795,39 @@ type pinVerifyOpts struct {
 	includeOk bool
 }
 
-func pinVerify(ctx context.Context, n *core.IpfsNode, opts pinVerifyOpts) <-chan interface{} {
-	visited := make(map[string]PinStatus)
-	getLinks := n.DAG.GetOfflineLinkService().GetLinks
-	recPins := n.Pinning.RecursiveKeys()
-
-	var checkPin func(root *cid.Cid) PinStatus
-	checkPin = func(root *cid.Cid) PinStatus {
-		key := root.String()
-		if status, ok := visited[key]; ok {
-			return status
-		}
-
-		links, err := getLinks(ctx, root)
-		if err != nil {
-			status := PinStatus{Ok: false}
-			if opts.explain {
-				status.BadNodes = []BadNode{BadNode{Cid: key, Err: err.Error()}}
-			}
-			visited[key] = status
-			return status
-		}
-
-		status := PinStatus{Ok: true}
-		for _, lnk := range links {
-			res := checkPin(lnk.Cid)
-			if !res.Ok {
-				status.Ok = false
-				status.BadNodes = append(status.BadNodes, res.BadNodes...)
-			}
-		}
-
-		visited[key] = status
-		return status
+func pinVerify(ctx context.Context, api coreiface.CoreAPI, opts pinVerifyOpts) (<-chan interface{}, error) {
+	statuses, err := api.Pin().Verify(ctx)
+	if err != nil {
+		return nil, err
 	}
 
 	out := make(chan interface{})
 	go func() {
 		defer close(out)
-		for _, cid := range recPins {
-			pinStatus := checkPin(cid)
-			if !pinStatus.Ok || opts.includeOk {
-				out <- &PinVerifyRes{cid.String(), pinStatus}
+		for s := range statuses {
+			if s.Ok() && !opts.includeOk {
+				continue
+			}
+
+			status := PinStatus{Ok: s.Ok()}
+			if opts.explain {
+				for _, bn := range s.BadNodes() {
+					status.BadNodes = append(status.BadNodes, BadNode{
+						Cid: bn.Path().Cid().String(),
+						Err: bn.Err().Error(),
+					})
+				}
+			}
+
+			select {
+			case out <- &PinVerifyRes{s.Path().Cid().String(), status}:
+			case <-ctx.Done():
+				return
 			}
 		}
 	}()
 
-	return out
+	return out, nil
 }
 
 // Format formats PinVerifyRes
@@ -653,10 +842,25 @@ func (r PinVerifyRes) Format(out io.Writer) {
 	}
 }
 
-func cidsToStrings(cs []*cid.Cid) []string {
-	out := make([]string, 0, len(cs))
-	for _, c := range cs {
-		out = append(out, c.String())
+func pinAddMany(ctx context.Context, api coreiface.CoreAPI, paths []string, opts ...options.PinAddOption) ([]string, error) {
+	added := make([]string, len(paths))
+	for i, b := range paths {
+		p, err := coreapi.ParsePath(b)
+		if err != nil {
+			return nil, err
+		}
+
+		rp, err := api.ResolvePath(ctx, p)
+		if err != nil {
+			return nil, fmt.Errorf("pin: %s", err)
+		}
+
+		err = api.Pin().Add(ctx, rp, opts...)
+		if err != nil {
+			return nil, err
+		}
+		added[i] = rp.Cid().String()
 	}
-	return out
+
+	return added, nil
 }
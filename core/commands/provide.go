@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	e "github.com/ipfs/go-ipfs/core/commands/e"
+	rp "github.com/ipfs/go-ipfs/exchange/reprovide"
+
+	cmds "gx/ipfs/QmP9vZfc5WSjfGTXmwX2EcicMFzmZ6fXn7HTdKYat6ccmH/go-ipfs-cmds"
+	cmdkit "gx/ipfs/QmQp2a2Hhb7F6eK2A5hN8f9aJy4mtkEikL9Zj4cgB7d1dD/go-ipfs-cmdkit"
+)
+
+var ProvideCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Inspect the announcing of newly added content.",
+		ShortDescription: `
+Content added with 'ipfs add', 'ipfs block put' and 'ipfs dag put' is queued
+to be announced to the network in the background. The queue is kept in the
+repo, so that content is still announced if the daemon is restarted.
+`,
+	},
+
+	Subcommands: map[string]*cmds.Command{
+		"queue": provideQueueCmd,
+	},
+}
+
+var provideQueueCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "List the keys waiting to be announced.",
+		ShortDescription: `
+Lists the keys waiting to be announced, oldest first. Keys that could not be
+announced show how many attempts failed, and when they will be tried again.
+`,
+	},
+	Type: rp.QueueEntry{},
+	Run: func(req cmds.Request, res cmds.ResponseEmitter) {
+		nd, err := req.InvocContext().GetNode()
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
+		if !nd.OnlineMode() {
+			res.SetError(errNotOnline, cmdkit.ErrClient)
+			return
+		}
+
+		for _, ent := range nd.ProvideQueue.Entries() {
+			ent := ent
+			if err := res.Emit(&ent); err != nil {
+				log.Error(err)
+				return
+			}
+		}
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeEncoder(func(req cmds.Request, w io.Writer, v interface{}) error {
+			out, ok := v.(*rp.QueueEntry)
+			if !ok {
+				return e.TypeErr(out, v)
+			}
+
+			fmt.Fprintf(w, "%s\tqueued %s", out.Cid, out.Added.Format(time.RFC3339))
+			if out.Attempts > 0 {
+				fmt.Fprintf(w, ", %d failed attempts, retrying at %s: %s",
+					out.Attempts, out.NextAttempt.Format(time.RFC3339), out.LastError)
+			}
+			fmt.Fprintln(w)
+			return nil
+		}),
+	},
+}
@@ -13,6 +13,7 @@ import (
 	oldcmds "github.com/ipfs/go-ipfs/commands"
 	e "github.com/ipfs/go-ipfs/core/commands/e"
 	corerepo "github.com/ipfs/go-ipfs/core/corerepo"
+	gc "github.com/ipfs/go-ipfs/pin/gc"
 	config "github.com/ipfs/go-ipfs/repo/config"
 	fsrepo "github.com/ipfs/go-ipfs/repo/fsrepo"
 	lockfile "github.com/ipfs/go-ipfs/repo/fsrepo/lock"
@@ -48,7 +49,11 @@ var RepoCmd = &cmds.Command{
 // GcResult is the result returned by "repo gc" command.
 type GcResult struct {
 	Key   *cid.Cid
+	Size  uint64 `json:",omitempty"`
 	Error string `json:",omitempty"`
+
+	// Reclaimable is set on the last result of a dry run
+	Reclaimable *corerepo.ReclaimReport `json:",omitempty"`
 }
 
 var repoGcCmd = &oldcmds.Command{
@@ -58,11 +63,21 @@ var repoGcCmd = &oldcmds.Command{
 'ipfs repo gc' is a plumbing command that will sweep the local
 set of stored objects and remove ones that are not pinned in
 order to reclaim hard disk space.
+
+With --concurrent, pinned objects are marked without blocking 'ipfs add'
+and pinning, and unpinned objects are removed in small batches. Writes are
+only blocked while a batch is being removed.
+
+With --dry-run, nothing is removed. Instead, every object that would be
+removed is listed with its size, followed by the total number of bytes
+that would be reclaimed.
 `,
 	},
 	Options: []cmdkit.Option{
 		cmdkit.BoolOption("stream-errors", "Stream errors."),
 		cmdkit.BoolOption("quiet", "q", "Write minimal output."),
+		cmdkit.BoolOption("concurrent", "Allow adds and pins while collecting garbage."),
+		cmdkit.BoolOption("dry-run", "Only report what would be removed."),
 	},
 	Run: func(req oldcmds.Request, res oldcmds.Response) {
 		n, err := req.InvocContext().GetNode()
@@ -72,8 +87,17 @@ order to reclaim hard disk space.
 		}
 
 		streamErrors, _, _ := res.Request().Option("stream-errors").Bool()
-
-		gcOutChan := corerepo.GarbageCollectAsync(n, req.Context())
+		concurrent, _, _ := res.Request().Option("concurrent").Bool()
+		dryRun, _, _ := res.Request().Option("dry-run").Bool()
+
+		var gcOutChan <-chan gc.Result
+		if dryRun {
+			gcOutChan = corerepo.DryRunGarbageCollectAsync(n, req.Context())
+		} else if concurrent {
+			gcOutChan = corerepo.ConcurrentGarbageCollectAsync(n, req.Context())
+		} else {
+			gcOutChan = corerepo.GarbageCollectAsync(n, req.Context())
+		}
 
 		outChan := make(chan interface{})
 		res.SetOutput(outChan)
@@ -81,6 +105,25 @@ order to reclaim hard disk space.
 		go func() {
 			defer close(outChan)
 
+			if dryRun {
+				report, err := corerepo.CollectReclaimReport(req.Context(), gcOutChan, func(k *cid.Cid, size uint64) {
+					select {
+					case outChan <- &GcResult{Key: k, Size: size}:
+					case <-req.Context().Done():
+					}
+				})
+				if err != nil {
+					res.SetError(err, cmdkit.ErrNormal)
+					return
+				}
+
+				select {
+				case outChan <- &GcResult{Reclaimable: report}:
+				case <-req.Context().Done():
+				}
+				return
+			}
+
 			if streamErrors {
 				errs := false
 				for res := range gcOutChan {
@@ -138,8 +181,20 @@ order to reclaim hard disk space.
 				return nil, nil
 			}
 
+			if obj.Reclaimable != nil {
+				if quiet {
+					return nil, nil
+				}
+				return bytes.NewBufferString(fmt.Sprintf("would reclaim %d bytes in %d blocks\n",
+					obj.Reclaimable.Bytes, obj.Reclaimable.Blocks)), nil
+			}
+
+			dryRun, _, _ := res.Request().Option("dry-run").Bool()
+
 			msg := obj.Key.String() + "\n"
-			if !quiet {
+			if !quiet && dryRun {
+				msg = fmt.Sprintf("would remove %s (%d bytes)\n", obj.Key, obj.Size)
+			} else if !quiet {
 				msg = "removed " + msg
 			}
 
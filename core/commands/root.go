@@ -105,6 +105,7 @@ var rootSubcommands = map[string]*cmds.Command{
 	"commands":  CommandsDaemonCmd,
 	"filestore": FileStoreCmd,
 	"get":       GetCmd,
+	"provide":   ProvideCmd,
 	"pubsub":    PubsubCmd,
 	"repo":      RepoCmd,
 	"stats":     StatsCmd,
@@ -177,6 +178,7 @@ var rootROOldSubcommands = map[string]*oldcmds.Command{
 		Subcommands: map[string]*oldcmds.Command{
 			"get":     dag.DagGetCmd,
 			"resolve": dag.DagResolveCmd,
+			"export":  dag.DagExportCmd,
 		},
 	},
 	"refs":    RefsROCmd,
@@ -62,41 +62,40 @@ var swarmPeersCmd = &cmds.Command{
 		cmdkit.BoolOption("latency", "Also list information about latency to each peer"),
 	},
 	Run: func(req cmds.Request, res cmds.Response) {
-
-		n, err := req.InvocContext().GetNode()
+		api, err := req.InvocContext().GetApi()
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
 			return
 		}
 
-		if n.PeerHost == nil {
-			res.SetError(errNotOnline, cmdkit.ErrClient)
-			return
-		}
-
 		verbose, _, _ := req.Option("verbose").Bool()
 		latency, _, _ := req.Option("latency").Bool()
 		streams, _, _ := req.Option("streams").Bool()
 
-		conns := n.PeerHost.Network().Conns()
+		conns, err := api.Swarm().Peers(req.Context())
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
 
 		var out connInfos
 		for _, c := range conns {
-			pid := c.RemotePeer()
-			addr := c.RemoteMultiaddr()
-
 			ci := connInfo{
-				Addr: addr.String(),
-				Peer: pid.Pretty(),
+				Addr: c.Address().String(),
+				Peer: c.ID().Pretty(),
 			}
 
-			swcon, ok := c.(*swarm.Conn)
-			if ok {
-				ci.Muxer = fmt.Sprintf("%T", swcon.StreamConn().Conn())
+			if m, ok := c.(muxerInfo); ok {
+				ci.Muxer = m.Muxer()
 			}
 
 			if verbose || latency {
-				lat := n.Peerstore.LatencyEWMA(pid)
+				lat, err := c.Latency()
+				if err != nil {
+					res.SetError(err, cmdkit.ErrNormal)
+					return
+				}
+
 				if lat == 0 {
 					ci.Latency = "n/a"
 				} else {
@@ -104,14 +103,14 @@ var swarmPeersCmd = &cmds.Command{
 				}
 			}
 			if verbose || streams {
-				strs, err := c.GetStreams()
+				strs, err := c.Streams()
 				if err != nil {
 					res.SetError(err, cmdkit.ErrNormal)
 					return
 				}
 
 				for _, s := range strs {
-					ci.Streams = append(ci.Streams, streamInfo{Protocol: string(s.Protocol())})
+					ci.Streams = append(ci.Streams, streamInfo{Protocol: string(s)})
 				}
 			}
 			sort.Sort(&ci)
@@ -162,6 +161,12 @@ var swarmPeersCmd = &cmds.Command{
 	Type: connInfos{},
 }
 
+// muxerInfo is implemented by connections which know the name of the stream
+// muxer they use
+type muxerInfo interface {
+	Muxer() string
+}
+
 type streamInfo struct {
 	Protocol string
 }
@@ -214,29 +219,27 @@ var swarmAddrsCmd = &cmds.Command{
 		"listen": swarmAddrsListenCmd,
 	},
 	Run: func(req cmds.Request, res cmds.Response) {
-
-		n, err := req.InvocContext().GetNode()
+		api, err := req.InvocContext().GetApi()
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
 			return
 		}
 
-		if n.PeerHost == nil {
-			res.SetError(errNotOnline, cmdkit.ErrClient)
+		addrs, err := api.Swarm().KnownAddrs(req.Context())
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
 			return
 		}
 
-		addrs := make(map[string][]string)
-		ps := n.PeerHost.Network().Peerstore()
-		for _, p := range ps.Peers() {
+		out := make(map[string][]string)
+		for p, paddrs := range addrs {
 			s := p.Pretty()
-			for _, a := range ps.Addrs(p) {
-				addrs[s] = append(addrs[s], a.String())
+			for _, a := range paddrs {
+				out[s] = append(out[s], a.String())
 			}
-			sort.Sort(sort.StringSlice(addrs[s]))
 		}
 
-		res.SetOutput(&addrMap{Addrs: addrs})
+		res.SetOutput(&addrMap{Addrs: out})
 	},
 	Marshalers: cmds.MarshalerMap{
 		cmds.Text: func(res cmds.Response) (io.Reader, error) {
@@ -289,16 +292,23 @@ var swarmAddrsLocalCmd = &cmds.Command{
 			return
 		}
 
-		if n.PeerHost == nil {
-			res.SetError(errNotOnline, cmdkit.ErrClient)
+		api, err := iCtx.GetApi()
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
 			return
 		}
 
 		showid, _, _ := req.Option("id").Bool()
 		id := n.Identity.Pretty()
 
+		maddrs, err := api.Swarm().LocalAddrs(req.Context())
+		if err != nil {
+			res.SetError(err, cmdkit.ErrNormal)
+			return
+		}
+
 		var addrs []string
-		for _, addr := range n.PeerHost.Addrs() {
+		for _, addr := range maddrs {
 			saddr := addr.String()
 			if showid {
 				saddr = path.Join(saddr, "ipfs", id)
@@ -322,25 +332,19 @@ var swarmAddrsListenCmd = &cmds.Command{
 `,
 	},
 	Run: func(req cmds.Request, res cmds.Response) {
-
-		n, err := req.InvocContext().GetNode()
+		api, err := req.InvocContext().GetApi()
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
 			return
 		}
 
-		if n.PeerHost == nil {
-			res.SetError(errNotOnline, cmdkit.ErrClient)
-			return
-		}
-
-		var addrs []string
-		maddrs, err := n.PeerHost.Network().InterfaceListenAddresses()
+		maddrs, err := api.Swarm().ListenAddrs(req.Context())
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
 			return
 		}
 
+		var addrs []string
 		for _, addr := range maddrs {
 			addrs = append(addrs, addr.String())
 		}
@@ -371,7 +375,7 @@ ipfs swarm connect /ip4/104.131.131.82/tcp/4001/ipfs/QmaCpDMGvV2BGHeYERUEnRQAwe3
 	Run: func(req cmds.Request, res cmds.Response) {
 		ctx := req.Context()
 
-		n, err := req.InvocContext().GetNode()
+		api, err := req.InvocContext().GetApi()
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
 			return
@@ -379,19 +383,6 @@ ipfs swarm connect /ip4/104.131.131.82/tcp/4001/ipfs/QmaCpDMGvV2BGHeYERUEnRQAwe3
 
 		addrs := req.Arguments()
 
-		if n.PeerHost == nil {
-			res.SetError(errNotOnline, cmdkit.ErrClient)
-			return
-		}
-
-		snet, ok := n.PeerHost.Network().(*swarm.Network)
-		if !ok {
-			res.SetError(fmt.Errorf("peerhost network was not swarm"), cmdkit.ErrNormal)
-			return
-		}
-
-		swrm := snet.Swarm()
-
 		pis, err := peersWithAddresses(addrs)
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
@@ -400,11 +391,9 @@ ipfs swarm connect /ip4/104.131.131.82/tcp/4001/ipfs/QmaCpDMGvV2BGHeYERUEnRQAwe3
 
 		output := make([]string, len(pis))
 		for i, pi := range pis {
-			swrm.Backoff().Clear(pi.ID)
-
 			output[i] = "connect " + pi.ID.Pretty()
 
-			err := n.PeerHost.Connect(ctx, pi)
+			err := api.Swarm().Connect(ctx, pi)
 			if err != nil {
 				res.SetError(fmt.Errorf("%s failure: %s", output[i], err), cmdkit.ErrNormal)
 				return
@@ -437,20 +426,13 @@ it will reconnect.
 		cmdkit.StringArg("address", true, true, "Address of peer to disconnect from.").EnableStdin(),
 	},
 	Run: func(req cmds.Request, res cmds.Response) {
-		n, err := req.InvocContext().GetNode()
+		api, err := req.InvocContext().GetApi()
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
 			return
 		}
 
-		addrs := req.Arguments()
-
-		if n.PeerHost == nil {
-			res.SetError(errNotOnline, cmdkit.ErrClient)
-			return
-		}
-
-		iaddrs, err := parseAddresses(addrs)
+		iaddrs, err := parseAddresses(req.Arguments())
 		if err != nil {
 			res.SetError(err, cmdkit.ErrNormal)
 			return
@@ -458,27 +440,12 @@ it will reconnect.
 
 		output := make([]string, len(iaddrs))
 		for i, addr := range iaddrs {
-			taddr := addr.Transport()
 			output[i] = "disconnect " + addr.ID().Pretty()
 
-			found := false
-			conns := n.PeerHost.Network().ConnsToPeer(addr.ID())
-			for _, conn := range conns {
-				if !conn.RemoteMultiaddr().Equal(taddr) {
-					continue
-				}
-
-				if err := conn.Close(); err != nil {
-					output[i] += " failure: " + err.Error()
-				} else {
-					output[i] += " success"
-				}
-				found = true
-				break
-			}
-
-			if !found {
-				output[i] += " failure: conn not found"
+			if err := api.Swarm().Disconnect(req.Context(), addr.Multiaddr()); err != nil {
+				output[i] += " failure: " + err.Error()
+			} else {
+				output[i] += " success"
 			}
 		}
 		res.SetOutput(&stringList{output})
@@ -25,6 +25,7 @@ import (
 	bserv "github.com/ipfs/go-ipfs/blockservice"
 	exchange "github.com/ipfs/go-ipfs/exchange"
 	bitswap "github.com/ipfs/go-ipfs/exchange/bitswap"
+	decision "github.com/ipfs/go-ipfs/exchange/bitswap/decision"
 	bsnet "github.com/ipfs/go-ipfs/exchange/bitswap/network"
 	rp "github.com/ipfs/go-ipfs/exchange/reprovide"
 	filestore "github.com/ipfs/go-ipfs/filestore"
@@ -46,6 +47,7 @@ import (
 	floodsub "gx/ipfs/QmP1T1SGU6276R2MHKP2owbck37Fnzd6ZkpyNJvnG2LoTG/go-libp2p-floodsub"
 	p2phost "gx/ipfs/QmP46LGWhzVZTMmt5akNNLfoV8qL4h5wTwmzQxLyDafggd/go-libp2p-host"
 	routing "gx/ipfs/QmPCGUjMRuBcPybZFpjhzpifwPP9wPRoiy5geTQKU4vqWA/go-libp2p-routing"
+	humanize "gx/ipfs/QmPSBJL4momYnE7DcUyk2DVhD6rH488ZmHBGLbxNdhU44K/go-humanize"
 	u "gx/ipfs/QmPsAfmDBnZN3kZGSuNwvCNDZiHneERSKmRcFyG3UkvcT3/go-ipfs-util"
 	mplex "gx/ipfs/QmREBy6TSjLQMtYFhjf97cypsUTzBagcwamWocKHFCTb1e/go-smux-multiplex"
 	ifconnmgr "gx/ipfs/QmSAJm4QdTJ3EGF2cvgNcQyXTEbxqWSW1x4kCVV1aJQUQr/go-libp2p-interface-connmgr"
@@ -132,6 +134,7 @@ type IpfsNode struct {
 	Namesys      namesys.NameSystem  // the name system, resolves paths to hashes
 	Ping         *ping.PingService
 	Reprovider   *rp.Reprovider // the value reprovider system
+	ProvideQueue *rp.Queue      // announces newly added content
 	IpnsRepub    *ipnsrp.Republisher
 
 	Floodsub *floodsub.PubSub
@@ -301,21 +304,12 @@ func (n *IpfsNode) startLateOnlineServices(ctx context.Context) error {
 		return err
 	}
 
-	var keyProvider rp.KeyChanFunc
-
-	switch cfg.Reprovider.Strategy {
-	case "all":
-		fallthrough
-	case "":
-		keyProvider = rp.NewBlockstoreProvider(n.Blockstore)
-	case "roots":
-		keyProvider = rp.NewPinnedProvider(n.Pinning, n.DAG, true)
-	case "pinned":
-		keyProvider = rp.NewPinnedProvider(n.Pinning, n.DAG, false)
-	default:
-		return fmt.Errorf("unknown reprovider strategy '%s'", cfg.Reprovider.Strategy)
+	keyProvider, err := n.makeReproviderKeyProvider(cfg.Reprovider.Strategy)
+	if err != nil {
+		return err
 	}
-	n.Reprovider = rp.NewReprovider(ctx, n.Routing, keyProvider)
+	n.Reprovider = rp.NewReprovider(ctx, n.Routing, keyProvider, n.Repo.Datastore())
+	n.Reprovider.SetLimits(cfg.Reprovider.Workers, cfg.Reprovider.Rate)
 
 	reproviderInterval := kReprovideFrequency
 	if cfg.Reprovider.Interval != "" {
@@ -329,9 +323,56 @@ func (n *IpfsNode) startLateOnlineServices(ctx context.Context) error {
 
 	go n.Reprovider.Run(reproviderInterval)
 
+	n.ProvideQueue, err = rp.NewQueue(ctx, n.Routing, n.Repo.Datastore())
+	if err != nil {
+		return err
+	}
+	go n.ProvideQueue.Run(rp.DefaultQueueWorkers)
+
 	return nil
 }
 
+// makeReproviderKeyProvider returns the key provider for a reprovider
+// strategy. Strategies can be combined with '+', e.g. "pinned+mfs".
+func (n *IpfsNode) makeReproviderKeyProvider(strategy string) (rp.KeyChanFunc, error) {
+	if strategy == "" {
+		strategy = "all"
+	}
+
+	var all bool
+	var providers []rp.KeyChanFunc
+	for _, s := range strings.Split(strategy, "+") {
+		switch s {
+		case "all":
+			all = true
+		case "roots":
+			providers = append(providers, rp.NewPinnedProvider(n.Pinning, n.DAG, true))
+		case "pinned":
+			providers = append(providers, rp.NewPinnedProvider(n.Pinning, n.DAG, false))
+		case "mfs":
+			providers = append(providers, rp.NewMFSProvider(n.FilesRoot, n.DAG, false))
+		case "mfs-roots":
+			providers = append(providers, rp.NewMFSProvider(n.FilesRoot, n.DAG, true))
+		case "filestore":
+			if n.Filestore == nil {
+				return nil, fmt.Errorf("reprovider strategy 'filestore' requires the filestore to be enabled")
+			}
+			providers = append(providers, rp.NewFilestoreProvider(n.Filestore))
+		default:
+			return nil, fmt.Errorf("unknown reprovider strategy '%s'", s)
+		}
+	}
+
+	if all {
+		// everything else is in the blockstore too
+		return rp.NewBlockstoreProvider(n.Blockstore), nil
+	}
+	if len(providers) == 1 {
+		return providers[0], nil
+	}
+	return rp.NewCombinedProvider(providers...), nil
+}
+
 func makeAddrsFactory(cfg config.Addresses) (p2pbhost.AddrsFactory, error) {
 	var annAddrs []ma.Multiaddr
 	for _, addr := range cfg.Announce {
@@ -446,9 +487,23 @@ func (n *IpfsNode) startOnlineServicesWithHost(ctx context.Context, host p2phost
 	n.PeerHost = rhost.Wrap(host, n.Routing)
 
 	// setup exchange service
-	const alwaysSendToPeer = true // use YesManStrategy
+	cfg, err := n.Repo.Config()
+	if err != nil {
+		return err
+	}
+	strategy, err := makeBitswapStrategy(n.Repo)
+	if err != nil {
+		return err
+	}
+	quotas, err := makeBitswapQuotas(n.Repo)
+	if err != nil {
+		return err
+	}
 	bitswapNetwork := bsnet.NewFromIpfsHost(n.PeerHost, n.Routing)
-	n.Exchange = bitswap.New(ctx, n.Identity, bitswapNetwork, n.Blockstore, alwaysSendToPeer)
+	bs := bitswap.NewWithStrategy(ctx, n.Identity, bitswapNetwork, n.Blockstore, n.Repo.Datastore(), strategy).(*bitswap.Bitswap)
+	bs.SetQuotas(quotas)
+	bs.SetRequestSplitting(!cfg.Bitswap.DisableRequestSplitting)
+	n.Exchange = bs
 
 	size, err := n.getCacheSize()
 	if err != nil {
@@ -462,6 +517,49 @@ func (n *IpfsNode) startOnlineServicesWithHost(ctx context.Context, host p2phost
 	return n.setupIpnsRepublisher()
 }
 
+// makeBitswapStrategy returns the bitswap decision strategy selected in the
+// config.
+func makeBitswapStrategy(r repo.Repo) (decision.Strategy, error) {
+	cfg, err := r.Config()
+	if err != nil {
+		return nil, err
+	}
+
+	var allowlist []peer.ID
+	for _, s := range cfg.Bitswap.Allowlist {
+		p, err := peer.IDB58Decode(s)
+		if err != nil {
+			return nil, fmt.Errorf("parsing Bitswap.Allowlist: %s", err)
+		}
+		allowlist = append(allowlist, p)
+	}
+
+	return decision.NewStrategy(cfg.Bitswap.Strategy, allowlist)
+}
+
+// makeBitswapQuotas returns the bitswap send quotas set in the config.
+func makeBitswapQuotas(r repo.Repo) (decision.Quotas, error) {
+	cfg, err := r.Config()
+	if err != nil {
+		return decision.Quotas{}, err
+	}
+
+	q := decision.Quotas{MaxOutstanding: cfg.Bitswap.PeerMaxOutstanding}
+	if cfg.Bitswap.SendRate != "" {
+		q.Rate, err = humanize.ParseBytes(cfg.Bitswap.SendRate)
+		if err != nil {
+			return q, fmt.Errorf("parsing Bitswap.SendRate: %s", err)
+		}
+	}
+	if cfg.Bitswap.PeerSendRate != "" {
+		q.PeerRate, err = humanize.ParseBytes(cfg.Bitswap.PeerSendRate)
+		if err != nil {
+			return q, fmt.Errorf("parsing Bitswap.PeerSendRate: %s", err)
+		}
+	}
+	return q, nil
+}
+
 // getCacheSize returns cache life and cache size
 func (n *IpfsNode) getCacheSize() (int, error) {
 	cfg, err := n.Repo.Config()
@@ -758,6 +856,10 @@ func (n *IpfsNode) loadFilesRoot() error {
 		return err
 	}
 
+	if err := mr.LoadTransactions(n.Repo.Datastore()); err != nil {
+		return err
+	}
+
 	n.FilesRoot = mr
 	return nil
 }
@@ -0,0 +1,170 @@
+package coreapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	util "github.com/ipfs/go-ipfs/blocks/blockstore/util"
+	coreiface "github.com/ipfs/go-ipfs/core/coreapi/interface"
+	caopts "github.com/ipfs/go-ipfs/core/coreapi/interface/options"
+	pin "github.com/ipfs/go-ipfs/pin"
+
+	blocks "gx/ipfs/QmYsEQydGrsxNZfAiskvQ76N2xE9hDQtSAkRSynwMiUK3c/go-block-format"
+	cid "gx/ipfs/QmeSrf6pzut73u6zLQkRFQ3ygt3k6XFT2kjdYP8Tnkwwyg/go-cid"
+)
+
+type BlockAPI struct {
+	*CoreAPI
+	*caopts.BlockOptions
+}
+
+type BlockStat struct {
+	path coreiface.Path
+	size int
+}
+
+func (api *BlockAPI) Put(ctx context.Context, src io.Reader, opts ...caopts.BlockPutOption) (coreiface.BlockStat, error) {
+	settings, err := caopts.BlockPutOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadAll(src)
+	if err != nil {
+		return nil, err
+	}
+
+	var pref cid.Prefix
+	pref.Version = 1
+
+	formatval, ok := cid.Codecs[settings.Codec]
+	if !ok {
+		return nil, fmt.Errorf("unrecognized format: %s", settings.Codec)
+	}
+	if settings.Codec == "v0" {
+		pref.Version = 0
+	}
+	pref.Codec = formatval
+
+	pref.MhType = settings.MhType
+	pref.MhLength = settings.MhLength
+
+	bcid, err := pref.Sum(data)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := blocks.NewBlockWithCid(data, bcid)
+	if err != nil {
+		return nil, err
+	}
+
+	if settings.Pin {
+		defer api.node.Blockstore.PinLock().Unlock()
+	}
+
+	k, err := api.node.Blocks.AddBlock(b)
+	if err != nil {
+		return nil, err
+	}
+
+	if api.node.ProvideQueue != nil {
+		if err := api.node.ProvideQueue.Enqueue(k); err != nil {
+			return nil, err
+		}
+	}
+
+	if settings.Pin {
+		api.node.Pinning.PinWithMode(k, pin.Recursive)
+		if err := api.node.Pinning.Flush(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &BlockStat{path: ParseCid(k), size: len(data)}, nil
+}
+
+func (api *BlockAPI) Get(ctx context.Context, p coreiface.Path) (io.Reader, error) {
+	rp, err := api.core().ResolvePath(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := api.node.Blocks.GetBlock(ctx, rp.Cid())
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.NewReader(b.RawData()), nil
+}
+
+func (api *BlockAPI) Rm(ctx context.Context, p coreiface.Path, opts ...caopts.BlockRmOption) error {
+	rp, err := api.core().ResolvePath(ctx, p)
+	if err != nil {
+		return err
+	}
+
+	settings, err := caopts.BlockRmOptions(opts...)
+	if err != nil {
+		return err
+	}
+	cids := []*cid.Cid{rp.Cid()}
+	o := util.RmBlocksOpts{Force: settings.Force}
+
+	out, err := util.RmBlocks(api.node.Blockstore, api.node.Pinning, cids, o)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case res, ok := <-out:
+		if !ok {
+			return nil
+		}
+
+		remBlock, ok := res.(*util.RemovedBlock)
+		if !ok {
+			return errors.New("got unexpected output from util.RmBlocks")
+		}
+
+		if remBlock.Error != "" {
+			return errors.New(remBlock.Error)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (api *BlockAPI) Stat(ctx context.Context, p coreiface.Path) (coreiface.BlockStat, error) {
+	rp, err := api.core().ResolvePath(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := api.node.Blocks.GetBlock(ctx, rp.Cid())
+	if err != nil {
+		return nil, err
+	}
+
+	return &BlockStat{
+		path: ParseCid(b.Cid()),
+		size: len(b.RawData()),
+	}, nil
+}
+
+func (bs *BlockStat) Size() int {
+	return bs.size
+}
+
+func (bs *BlockStat) Path() coreiface.Path {
+	return bs.path
+}
+
+func (api *BlockAPI) core() coreiface.CoreAPI {
+	return api.CoreAPI
+}
@@ -0,0 +1,164 @@
+package coreapi_test
+
+import (
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	mh "gx/ipfs/QmYeKnKpubCMRiq3PGZcTREErthbb5Q9cXsCoSkD9bjEBd/go-multihash"
+)
+
+func TestBlockPut(t *testing.T) {
+	ctx := context.Background()
+	_, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	res, err := api.Block().Put(ctx, strings.NewReader(`Hello`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Path().Cid().String() != "QmPyo15ynbVrSTVdJL9th7JysHaAbXt9dM9tXk1bMHbRtk" {
+		t.Errorf("got wrong cid: %s", res.Path().Cid().String())
+	}
+
+	if res.Size() != 5 {
+		t.Errorf("got wrong size: %d", res.Size())
+	}
+}
+
+func TestBlockPutFormat(t *testing.T) {
+	ctx := context.Background()
+	_, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	res, err := api.Block().Put(ctx, strings.NewReader(`Hello`), api.Block().WithFormat("cbor"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Path().Cid().String() != "zdpuAn4amuLWo8Widi5v6VQpuo2dnpnwbVE3oB6qqs7mDSeoa" {
+		t.Errorf("got wrong cid: %s", res.Path().Cid().String())
+	}
+}
+
+func TestBlockPutHash(t *testing.T) {
+	ctx := context.Background()
+	_, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	res, err := api.Block().Put(ctx, strings.NewReader(`Hello`), api.Block().WithHash(mh.KECCAK_512, -1), api.Block().WithFormat("cbor"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Path().Cid().Prefix().MhType != mh.KECCAK_512 {
+		t.Errorf("got wrong hash function: %s", res.Path().Cid().String())
+	}
+}
+
+func TestBlockGet(t *testing.T) {
+	ctx := context.Background()
+	_, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	res, err := api.Block().Put(ctx, strings.NewReader(`Hello`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := api.Block().Get(ctx, res.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(d) != "Hello" {
+		t.Error("didn't get correct data back")
+	}
+}
+
+func TestBlockRm(t *testing.T) {
+	ctx := context.Background()
+	_, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	res, err := api.Block().Put(ctx, strings.NewReader(`Hello`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = api.Block().Rm(ctx, res.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = api.Block().Rm(ctx, res.Path())
+	if err == nil {
+		t.Error("expected err to exist")
+	}
+
+	err = api.Block().Rm(ctx, res.Path(), api.Block().WithForce(true))
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBlockRmPinned(t *testing.T) {
+	ctx := context.Background()
+	_, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	res, err := api.Block().Put(ctx, strings.NewReader(`Hello`), api.Block().WithPin(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = api.Block().Rm(ctx, res.Path())
+	if err == nil {
+		t.Error("expected pinned block not to be removed")
+	}
+}
+
+func TestBlockStat(t *testing.T) {
+	ctx := context.Background()
+	_, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	res, err := api.Block().Put(ctx, strings.NewReader(`Hello`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stat, err := api.Block().Stat(ctx, res.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stat.Path().String() != res.Path().String() {
+		t.Error("paths don't match")
+	}
+
+	if stat.Size() != len("Hello") {
+		t.Error("length doesn't match")
+	}
+}
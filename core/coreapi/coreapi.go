@@ -8,9 +8,12 @@ import (
 	ipfspath "github.com/ipfs/go-ipfs/path"
 	uio "github.com/ipfs/go-ipfs/unixfs/io"
 
+	logging "gx/ipfs/QmSpJByNKFX1sCsHBEp3R73FL4NF6FnQTEGyNAXHm2GS52/go-log"
 	cid "gx/ipfs/QmeSrf6pzut73u6zLQkRFQ3ygt3k6XFT2kjdYP8Tnkwwyg/go-cid"
 )
 
+var log = logging.Logger("core/coreapi")
+
 type CoreAPI struct {
 	node *core.IpfsNode
 }
@@ -37,6 +40,30 @@ func (api *CoreAPI) Key() coreiface.KeyAPI {
 	return &KeyAPI{api, nil}
 }
 
+func (api *CoreAPI) Pin() coreiface.PinAPI {
+	return &PinAPI{api, nil}
+}
+
+func (api *CoreAPI) Block() coreiface.BlockAPI {
+	return &BlockAPI{api, nil}
+}
+
+func (api *CoreAPI) Object() coreiface.ObjectAPI {
+	return &ObjectAPI{api, nil}
+}
+
+func (api *CoreAPI) Dht() coreiface.DhtAPI {
+	return &DhtAPI{api, nil}
+}
+
+func (api *CoreAPI) Swarm() coreiface.SwarmAPI {
+	return &SwarmAPI{api}
+}
+
+func (api *CoreAPI) PubSub() coreiface.PubSubAPI {
+	return &PubSubAPI{api, nil}
+}
+
 func (api *CoreAPI) ResolveNode(ctx context.Context, p coreiface.Path) (coreiface.Node, error) {
 	p, err := api.ResolvePath(ctx, p)
 	if err != nil {
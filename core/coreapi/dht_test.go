@@ -0,0 +1,99 @@
+package coreapi_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	core "github.com/ipfs/go-ipfs/core"
+
+	pstore "gx/ipfs/QmYijbtjCxFEjSXaudaQAUz3LN5VKLssm8WCUsRoqzXmQR/go-libp2p-peerstore"
+)
+
+func connectAll(ctx context.Context, t *testing.T, nds []*core.IpfsNode) {
+	for i := 1; i < len(nds); i++ {
+		err := nds[0].PeerHost.Connect(ctx, pstore.PeerInfo{
+			ID:    nds[i].Identity,
+			Addrs: nds[i].PeerHost.Addrs(),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDhtFindPeer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nds, apis, err := makeAPISwarm(ctx, true, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	connectAll(ctx, t, nds)
+
+	pi, err := apis[2].Dht().FindPeer(ctx, nds[1].Identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pi.ID != nds[1].Identity {
+		t.Errorf("got unexpected peer: %s", pi.ID)
+	}
+
+	if len(pi.Addrs) == 0 {
+		t.Error("expected peer addresses")
+	}
+}
+
+func TestDhtFindProviders(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nds, apis, err := makeAPISwarm(ctx, true, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	connectAll(ctx, t, nds)
+
+	p, err := apis[0].Unixfs().Add(ctx, strings.NewReader("foo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = apis[0].Dht().Provide(ctx, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := apis[2].Dht().FindProviders(ctx, p, apis[2].Dht().WithNumProviders(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	provider := <-out
+	if provider.ID != nds[0].Identity {
+		t.Errorf("got wrong provider: %s != %s", provider.ID, nds[0].Identity)
+	}
+}
+
+func TestDhtProvideMissing(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nds, apis, err := makeAPISwarm(ctx, true, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	connectAll(ctx, t, nds)
+
+	p, err := apis[1].Unixfs().Add(ctx, strings.NewReader("bar"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = apis[0].Dht().Provide(ctx, p)
+	if err == nil {
+		t.Error("expected an error when providing a missing block")
+	}
+}
@@ -0,0 +1,166 @@
+// Package httpapi implements the IPFS Core API (coreiface.CoreAPI) on top of
+// the HTTP API exposed by a running go-ipfs daemon under /api/v0.
+//
+// It allows programs written against the CoreAPI interfaces to switch between
+// an embedded node (core/coreapi) and a remote daemon without further changes.
+package httpapi
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	coreapi "github.com/ipfs/go-ipfs/core/coreapi"
+	coreiface "github.com/ipfs/go-ipfs/core/coreapi/interface"
+	ipfspath "github.com/ipfs/go-ipfs/path"
+
+	// register decoders for the default ipld formats
+	_ "github.com/ipfs/go-ipfs/merkledag"
+
+	node "gx/ipfs/QmNwUEK7QbwSqyKBu3mMtToo8SUc6wQJ7gdZq4gGGJqfnf/go-ipld-format"
+	logging "gx/ipfs/QmSpJByNKFX1sCsHBEp3R73FL4NF6FnQTEGyNAXHm2GS52/go-log"
+	blocks "gx/ipfs/QmYsEQydGrsxNZfAiskvQ76N2xE9hDQtSAkRSynwMiUK3c/go-block-format"
+	cid "gx/ipfs/QmeSrf6pzut73u6zLQkRFQ3ygt3k6XFT2kjdYP8Tnkwwyg/go-cid"
+)
+
+var log = logging.Logger("core/coreapi/httpapi")
+
+const apiPath = "/api/v0"
+
+// HttpApi implements github.com/ipfs/go-ipfs/core/coreapi/interface.CoreAPI
+// using the HTTP API of an IPFS daemon.
+type HttpApi struct {
+	url    string
+	client *http.Client
+}
+
+// NewApi constructs a new HttpApi talking to the daemon listening on the given
+// address, e.g. "127.0.0.1:5001" or "http://127.0.0.1:5001".
+func NewApi(addr string) coreiface.CoreAPI {
+	return NewApiWithClient(addr, http.DefaultClient)
+}
+
+// NewApiWithClient is like NewApi, but uses the provided http client for all
+// requests.
+func NewApiWithClient(addr string, c *http.Client) coreiface.CoreAPI {
+	if !strings.HasPrefix(addr, "http://") && !strings.HasPrefix(addr, "https://") {
+		addr = "http://" + addr
+	}
+
+	return &HttpApi{
+		url:    strings.TrimSuffix(addr, "/") + apiPath,
+		client: c,
+	}
+}
+
+func (api *HttpApi) request(command string, args ...string) *RequestBuilder {
+	return &RequestBuilder{
+		command: command,
+		args:    args,
+		shell:   api,
+	}
+}
+
+func (api *HttpApi) Unixfs() coreiface.UnixfsAPI {
+	return (*UnixfsAPI)(api)
+}
+
+func (api *HttpApi) Dag() coreiface.DagAPI {
+	return &DagAPI{api, nil}
+}
+
+func (api *HttpApi) Name() coreiface.NameAPI {
+	return &NameAPI{api, nil}
+}
+
+func (api *HttpApi) Key() coreiface.KeyAPI {
+	return &KeyAPI{api, nil}
+}
+
+func (api *HttpApi) Pin() coreiface.PinAPI {
+	return &PinAPI{api, nil}
+}
+
+func (api *HttpApi) Block() coreiface.BlockAPI {
+	return &BlockAPI{api, nil}
+}
+
+func (api *HttpApi) Object() coreiface.ObjectAPI {
+	return &ObjectAPI{api, nil}
+}
+
+func (api *HttpApi) Dht() coreiface.DhtAPI {
+	return &DhtAPI{api, nil}
+}
+
+func (api *HttpApi) Swarm() coreiface.SwarmAPI {
+	return &SwarmAPI{api}
+}
+
+func (api *HttpApi) PubSub() coreiface.PubSubAPI {
+	return &PubSubAPI{api, nil}
+}
+
+// ResolvePath resolves the path using the daemon's resolver
+func (api *HttpApi) ResolvePath(ctx context.Context, p coreiface.Path) (coreiface.Path, error) {
+	if p.Resolved() {
+		return p, nil
+	}
+
+	var out struct {
+		Path string
+	}
+
+	err := api.request("resolve", p.String()).
+		Option("recursive", true).
+		Exec(ctx, &out)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := cid.Decode(strings.TrimPrefix(out.Path, "/ipfs/"))
+	if err != nil {
+		return nil, err
+	}
+
+	var root *cid.Cid
+	if ipfspath.FromString(p.String()).IsJustAKey() {
+		root = c
+	}
+
+	return coreapi.ResolvedPath(p.String(), c, root), nil
+}
+
+// ResolveNode resolves the path (if not resolved already), fetches the raw
+// block from the daemon and decodes it locally
+func (api *HttpApi) ResolveNode(ctx context.Context, p coreiface.Path) (coreiface.Node, error) {
+	rp, err := api.ResolvePath(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := api.request("block/get", rp.Cid().String()).Send(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Close()
+
+	data, err := ioutil.ReadAll(resp.Output)
+	if err != nil {
+		return nil, err
+	}
+
+	blk, err := blocks.NewBlockWithCid(data, rp.Cid())
+	if err != nil {
+		return nil, err
+	}
+
+	nd, err := node.Decode(blk)
+	if err != nil {
+		return nil, fmt.Errorf("decoding %s: %s", rp.Cid(), err)
+	}
+
+	return nd, nil
+}
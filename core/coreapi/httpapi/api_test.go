@@ -0,0 +1,339 @@
+package httpapi_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net"
+	"strings"
+	"testing"
+
+	coreapi "github.com/ipfs/go-ipfs/core/coreapi"
+	httpapi "github.com/ipfs/go-ipfs/core/coreapi/httpapi"
+	coreiface "github.com/ipfs/go-ipfs/core/coreapi/interface"
+	corehttp "github.com/ipfs/go-ipfs/core/corehttp"
+	coremock "github.com/ipfs/go-ipfs/core/mock"
+)
+
+// `echo -n 'hello, world!' | ipfs add`
+var hello = "/ipfs/QmQy2Dw4Wk7rdJKjThjYXzfFJNaRKRHhHP5gHHXroJMYxk"
+var helloStr = "hello, world!"
+
+// makeAPI starts an offline node serving the HTTP API on a random local port
+// and returns a client for it
+func makeAPI(t *testing.T) coreiface.CoreAPI {
+	cctx, err := coremock.MockCmdsCtx()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := cctx.ConstructNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go corehttp.Serve(n, lis, corehttp.CommandsOption(cctx))
+
+	return httpapi.NewApi(lis.Addr().String())
+}
+
+func TestUnixfsAddCat(t *testing.T) {
+	ctx := context.Background()
+	api := makeAPI(t)
+
+	p, err := api.Unixfs().Add(ctx, strings.NewReader(helloStr))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.String() != hello {
+		t.Fatalf("expected path %s, got: %s", hello, p)
+	}
+
+	r, err := api.Unixfs().Cat(ctx, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	buf, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(buf) != helloStr {
+		t.Fatalf("expected [%s], got [%s]", helloStr, buf)
+	}
+
+	_, err = r.Seek(7, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf, err = ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(buf) != "world!" {
+		t.Fatalf("expected [world!], got [%s]", buf)
+	}
+}
+
+func TestUnixfsLs(t *testing.T) {
+	ctx := context.Background()
+	api := makeAPI(t)
+
+	p, err := api.Unixfs().Add(ctx, strings.NewReader(helloStr))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := api.Object().New(ctx, api.Object().WithType("unixfs-dir"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dp, err := api.Object().AddLink(ctx, coreapi.ParseCid(dir.Cid()), "hello", p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	links, err := api.Unixfs().Ls(ctx, dp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(links) != 1 {
+		t.Fatalf("expected 1 link, got %d", len(links))
+	}
+
+	if links[0].Name != "hello" || links[0].Cid.String() != p.Cid().String() {
+		t.Errorf("unexpected link: %s %s", links[0].Name, links[0].Cid)
+	}
+
+	_, err = api.Unixfs().Cat(ctx, dp)
+	if err != coreiface.ErrIsDir {
+		t.Errorf("expected ErrIsDir, got: %v", err)
+	}
+}
+
+func TestResolveNode(t *testing.T) {
+	ctx := context.Background()
+	api := makeAPI(t)
+
+	p, err := api.Unixfs().Add(ctx, strings.NewReader(helloStr))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nd, err := api.ResolveNode(ctx, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if nd.Cid().String() != p.Cid().String() {
+		t.Errorf("unexpected node: %s", nd.Cid())
+	}
+
+	rp, err := api.ResolvePath(ctx, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rp.Cid().String() != p.Cid().String() {
+		t.Errorf("unexpected resolved path: %s", rp.Cid())
+	}
+}
+
+func TestBlock(t *testing.T) {
+	ctx := context.Background()
+	api := makeAPI(t)
+
+	res, err := api.Block().Put(ctx, strings.NewReader(`Hello`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Path().Cid().String() != "QmPyo15ynbVrSTVdJL9th7JysHaAbXt9dM9tXk1bMHbRtk" {
+		t.Errorf("got wrong cid: %s", res.Path().Cid().String())
+	}
+
+	r, err := api.Block().Get(ctx, res.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(d) != "Hello" {
+		t.Error("didn't get correct data back")
+	}
+
+	// the response is closed once read, or by closing the reader early
+	if _, ok := r.(io.Closer); !ok {
+		t.Error("expected the block reader to be closable")
+	}
+
+	stat, err := api.Block().Stat(ctx, res.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stat.Size() != len("Hello") {
+		t.Errorf("unexpected block size: %d", stat.Size())
+	}
+
+	err = api.Block().Rm(ctx, res.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = api.Block().Rm(ctx, res.Path())
+	if err == nil {
+		t.Error("expected an error removing a missing block")
+	}
+
+	err = api.Block().Rm(ctx, res.Path(), api.Block().WithForce(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestObject(t *testing.T) {
+	ctx := context.Background()
+	api := makeAPI(t)
+
+	p, err := api.Object().Put(ctx, strings.NewReader(`{"Data":"foo"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := api.Object().Data(ctx, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != "foo" {
+		t.Errorf("unexpected data: %s", data)
+	}
+
+	p2, err := api.Object().AppendData(ctx, p, strings.NewReader("bar"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stat, err := api.Object().Stat(ctx, p2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stat.DataSize != len("foobar") {
+		t.Errorf("unexpected data size: %d", stat.DataSize)
+	}
+}
+
+func TestPin(t *testing.T) {
+	ctx := context.Background()
+	api := makeAPI(t)
+
+	p, err := api.Unixfs().Add(ctx, strings.NewReader("foo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = api.Pin().Add(ctx, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := api.Pin().Ls(ctx, api.Pin().WithType("recursive"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(list) != 1 || list[0].Path().Cid().String() != p.Cid().String() {
+		t.Fatalf("unexpected pin list: %v", list)
+	}
+
+	statuses, err := api.Pin().Verify(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for s := range statuses {
+		if !s.Ok() {
+			t.Errorf("pin %s is broken", s.Path())
+		}
+	}
+
+	err = api.Pin().Rm(ctx, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list, err = api.Pin().Ls(ctx, api.Pin().WithType("recursive"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(list) != 0 {
+		t.Errorf("unexpected pin list len: %d", len(list))
+	}
+}
+
+func TestDag(t *testing.T) {
+	ctx := context.Background()
+	api := makeAPI(t)
+
+	p, err := api.Dag().Put(ctx, strings.NewReader(`{"lnk": {"/": "`+strings.TrimPrefix(hello, "/ipfs/")+`"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nd, err := api.Dag().Get(ctx, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if nd.Cid().String() != p.Cid().String() {
+		t.Errorf("unexpected node: %s", nd.Cid())
+	}
+
+	if !bytes.Contains(nd.RawData(), []byte("lnk")) {
+		t.Error("expected node to contain the link")
+	}
+}
+
+func TestApiError(t *testing.T) {
+	ctx := context.Background()
+	api := makeAPI(t)
+
+	p, err := coreapi.ParsePath("/ipfs/QmPyo15ynbVrSTVdJL9th7JysHaAbXt9dM9tXk1bMHbRtk")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = api.Pin().Rm(ctx, p)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	if _, ok := err.(*httpapi.Error); !ok {
+		t.Errorf("expected *httpapi.Error, got %T", err)
+	}
+}
@@ -0,0 +1,144 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	coreapi "github.com/ipfs/go-ipfs/core/coreapi"
+	coreiface "github.com/ipfs/go-ipfs/core/coreapi/interface"
+	caopts "github.com/ipfs/go-ipfs/core/coreapi/interface/options"
+
+	mh "gx/ipfs/QmYeKnKpubCMRiq3PGZcTREErthbb5Q9cXsCoSkD9bjEBd/go-multihash"
+	cid "gx/ipfs/QmeSrf6pzut73u6zLQkRFQ3ygt3k6XFT2kjdYP8Tnkwwyg/go-cid"
+)
+
+type BlockAPI struct {
+	*HttpApi
+	*caopts.BlockOptions
+}
+
+// blockStat implements coreiface.BlockStat
+type blockStat struct {
+	Key   string
+	BSize int `json:"Size"`
+
+	cid *cid.Cid
+}
+
+func (s *blockStat) Size() int {
+	return s.BSize
+}
+
+func (s *blockStat) Path() coreiface.Path {
+	return coreapi.ParseCid(s.cid)
+}
+
+func (s *blockStat) decodeCid() error {
+	c, err := cid.Decode(s.Key)
+	if err != nil {
+		return err
+	}
+	s.cid = c
+	return nil
+}
+
+func (api *BlockAPI) Put(ctx context.Context, r io.Reader, opts ...caopts.BlockPutOption) (coreiface.BlockStat, error) {
+	options, err := caopts.BlockPutOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	mht, ok := mh.Codes[options.MhType]
+	if !ok {
+		return nil, fmt.Errorf("unknown mhType %d", options.MhType)
+	}
+
+	var out blockStat
+	err = api.request("block/put").
+		Option("format", options.Codec).
+		Option("mhtype", mht).
+		Option("mhlen", options.MhLength).
+		Option("pin", options.Pin).
+		Body(r).
+		Exec(ctx, &out)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := out.decodeCid(); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (api *BlockAPI) Get(ctx context.Context, p coreiface.Path) (io.Reader, error) {
+	rp, err := api.ResolvePath(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := api.request("block/get", rp.Cid().String()).Send(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Reader(), nil
+}
+
+func (api *BlockAPI) Rm(ctx context.Context, p coreiface.Path, opts ...caopts.BlockRmOption) error {
+	options, err := caopts.BlockRmOptions(opts...)
+	if err != nil {
+		return err
+	}
+
+	rp, err := api.ResolvePath(ctx, p)
+	if err != nil {
+		return err
+	}
+
+	var out struct {
+		Hash  string
+		Error string
+	}
+
+	resp, err := api.request("block/rm", rp.Cid().String()).
+		Option("force", options.Force).
+		Send(ctx)
+	if err != nil {
+		return err
+	}
+	defer resp.Close()
+
+	// nothing is reported for missing blocks when force is set
+	err = resp.Decode(&out)
+	if err == io.EOF {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if out.Error != "" {
+		return errors.New(out.Error)
+	}
+	return nil
+}
+
+func (api *BlockAPI) Stat(ctx context.Context, p coreiface.Path) (coreiface.BlockStat, error) {
+	rp, err := api.ResolvePath(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	var out blockStat
+	err = api.request("block/stat", rp.Cid().String()).Exec(ctx, &out)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := out.decodeCid(); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
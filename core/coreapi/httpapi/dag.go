@@ -0,0 +1,87 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	gopath "path"
+
+	coreapi "github.com/ipfs/go-ipfs/core/coreapi"
+	coreiface "github.com/ipfs/go-ipfs/core/coreapi/interface"
+	caopts "github.com/ipfs/go-ipfs/core/coreapi/interface/options"
+
+	mh "gx/ipfs/QmYeKnKpubCMRiq3PGZcTREErthbb5Q9cXsCoSkD9bjEBd/go-multihash"
+	cid "gx/ipfs/QmeSrf6pzut73u6zLQkRFQ3ygt3k6XFT2kjdYP8Tnkwwyg/go-cid"
+)
+
+type DagAPI struct {
+	*HttpApi
+	*caopts.DagOptions
+}
+
+func (api *DagAPI) Put(ctx context.Context, src io.Reader, opts ...caopts.DagPutOption) (coreiface.Path, error) {
+	settings, err := caopts.DagPutOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	codec, ok := cid.CodecToStr[settings.Codec]
+	if !ok {
+		return nil, fmt.Errorf("invalid codec %d", settings.Codec)
+	}
+
+	if settings.MhLength != -1 {
+		return nil, errors.New("dag put: custom hash length is not supported by the http api")
+	}
+
+	req := api.request("dag/put").
+		Option("format", codec).
+		Option("input-enc", settings.InputEnc)
+
+	if settings.MhType != math.MaxUint64 {
+		mht, ok := mh.Codes[settings.MhType]
+		if !ok {
+			return nil, fmt.Errorf("unknown multihash type %d", settings.MhType)
+		}
+		req.Option("hash", mht)
+	}
+
+	var out struct {
+		Cid *cid.Cid
+	}
+
+	err = req.Body(src).Exec(ctx, &out)
+	if err != nil {
+		return nil, err
+	}
+
+	return coreapi.ParseCid(out.Cid), nil
+}
+
+func (api *DagAPI) Get(ctx context.Context, path coreiface.Path) (coreiface.Node, error) {
+	return api.ResolveNode(ctx, path)
+}
+
+func (api *DagAPI) Tree(ctx context.Context, p coreiface.Path, opts ...caopts.DagTreeOption) ([]coreiface.Path, error) {
+	settings, err := caopts.DagTreeOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := api.Get(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	paths := n.Tree("", settings.Depth)
+	out := make([]coreiface.Path, len(paths))
+	for n, p2 := range paths {
+		out[n], err = coreapi.ParsePath(gopath.Join(p.String(), p2))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return out, nil
+}
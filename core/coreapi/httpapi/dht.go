@@ -0,0 +1,137 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	coreiface "github.com/ipfs/go-ipfs/core/coreapi/interface"
+	caopts "github.com/ipfs/go-ipfs/core/coreapi/interface/options"
+
+	notif "gx/ipfs/QmPCGUjMRuBcPybZFpjhzpifwPP9wPRoiy5geTQKU4vqWA/go-libp2p-routing/notifications"
+	peer "gx/ipfs/QmWNY7dV54ZDYmTA1ykVdwNCqC11mpU4zSUp6XDpLTH9eG/go-libp2p-peer"
+	pstore "gx/ipfs/QmYijbtjCxFEjSXaudaQAUz3LN5VKLssm8WCUsRoqzXmQR/go-libp2p-peerstore"
+)
+
+type DhtAPI struct {
+	*HttpApi
+	*caopts.DhtOptions
+}
+
+// queryEvent mirrors notif.QueryEvent as sent by the dht commands
+type queryEvent struct {
+	Type      notif.QueryEventType
+	Responses []*pstore.PeerInfo
+	Extra     string
+}
+
+func (api *DhtAPI) FindPeer(ctx context.Context, p peer.ID) (pstore.PeerInfo, error) {
+	resp, err := api.request("dht/findpeer", p.Pretty()).Send(ctx)
+	if err != nil {
+		return pstore.PeerInfo{}, err
+	}
+	defer resp.Close()
+
+	for {
+		var out queryEvent
+		err := resp.Decode(&out)
+		if err == io.EOF {
+			return pstore.PeerInfo{}, errors.New("dht findpeer: peer not found")
+		} else if err != nil {
+			return pstore.PeerInfo{}, err
+		}
+
+		switch out.Type {
+		case notif.FinalPeer:
+			if len(out.Responses) == 0 {
+				continue
+			}
+			return *out.Responses[0], nil
+		case notif.QueryError:
+			return pstore.PeerInfo{}, errors.New(out.Extra)
+		}
+	}
+}
+
+func (api *DhtAPI) FindProviders(ctx context.Context, p coreiface.Path, opts ...caopts.DhtFindProvidersOption) (<-chan pstore.PeerInfo, error) {
+	options, err := caopts.DhtFindProvidersOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	rp, err := api.ResolvePath(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := api.request("dht/findprovs", rp.Cid().String()).
+		Option("num-providers", options.NumProviders).
+		Send(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan pstore.PeerInfo)
+	go func() {
+		defer resp.Close()
+		defer close(out)
+		for {
+			var ev queryEvent
+			err := resp.Decode(&ev)
+			if err != nil {
+				if err != io.EOF {
+					log.Error("dht findprovs: ", err)
+				}
+				return
+			}
+
+			if ev.Type != notif.Provider {
+				continue
+			}
+
+			for _, pi := range ev.Responses {
+				select {
+				case out <- *pi:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+func (api *DhtAPI) Provide(ctx context.Context, p coreiface.Path, opts ...caopts.DhtProvideOption) error {
+	options, err := caopts.DhtProvideOptions(opts...)
+	if err != nil {
+		return err
+	}
+
+	rp, err := api.ResolvePath(ctx, p)
+	if err != nil {
+		return err
+	}
+
+	resp, err := api.request("dht/provide", rp.Cid().String()).
+		Option("recursive", options.Recursive).
+		Send(ctx)
+	if err != nil {
+		return err
+	}
+	defer resp.Close()
+
+	for {
+		var ev queryEvent
+		err := resp.Decode(&ev)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if ev.Type == notif.QueryError {
+			return errors.New(ev.Extra)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+
+	coreapi "github.com/ipfs/go-ipfs/core/coreapi"
+	coreiface "github.com/ipfs/go-ipfs/core/coreapi/interface"
+	caopts "github.com/ipfs/go-ipfs/core/coreapi/interface/options"
+)
+
+type KeyAPI struct {
+	*HttpApi
+	*caopts.KeyOptions
+}
+
+// keyOutput mirrors the output of the key commands
+type keyOutput struct {
+	Name string
+	Id   string
+}
+
+// key implements coreiface.Key
+type key struct {
+	name   string
+	peerId string
+}
+
+func (k *key) Name() string {
+	return k.name
+}
+
+func (k *key) Path() coreiface.Path {
+	p, err := coreapi.ParsePath("/ipns/" + k.peerId)
+	if err != nil {
+		return nil
+	}
+	return p
+}
+
+func (api *KeyAPI) Generate(ctx context.Context, name string, opts ...caopts.KeyGenerateOption) (coreiface.Key, error) {
+	options, err := caopts.KeyGenerateOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	req := api.request("key/gen", name).
+		Option("type", options.Algorithm)
+	if options.Size != -1 {
+		req.Option("size", options.Size)
+	}
+
+	var out keyOutput
+	err = req.Exec(ctx, &out)
+	if err != nil {
+		return nil, err
+	}
+
+	return &key{name: out.Name, peerId: out.Id}, nil
+}
+
+func (api *KeyAPI) Rename(ctx context.Context, oldName string, newName string, opts ...caopts.KeyRenameOption) (coreiface.Key, bool, error) {
+	options, err := caopts.KeyRenameOptions(opts...)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var out struct {
+		Was       string
+		Now       string
+		Id        string
+		Overwrite bool
+	}
+
+	err = api.request("key/rename", oldName, newName).
+		Option("force", options.Force).
+		Exec(ctx, &out)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return &key{name: out.Now, peerId: out.Id}, out.Overwrite, nil
+}
+
+func (api *KeyAPI) List(ctx context.Context) ([]coreiface.Key, error) {
+	var out struct {
+		Keys []keyOutput
+	}
+
+	err := api.request("key/list").
+		Option("l", true).
+		Exec(ctx, &out)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]coreiface.Key, len(out.Keys))
+	for i, k := range out.Keys {
+		res[i] = &key{name: k.Name, peerId: k.Id}
+	}
+
+	return res, nil
+}
+
+func (api *KeyAPI) Remove(ctx context.Context, name string) (coreiface.Path, error) {
+	var out struct {
+		Keys []keyOutput
+	}
+
+	err := api.request("key/rm", name).Exec(ctx, &out)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(out.Keys) != 1 {
+		return nil, errors.New("key rm: unexpected number of removed keys")
+	}
+
+	return (&key{name: out.Keys[0].Name, peerId: out.Keys[0].Id}).Path(), nil
+}
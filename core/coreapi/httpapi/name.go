@@ -0,0 +1,76 @@
+package httpapi
+
+import (
+	"context"
+
+	coreapi "github.com/ipfs/go-ipfs/core/coreapi"
+	coreiface "github.com/ipfs/go-ipfs/core/coreapi/interface"
+	caopts "github.com/ipfs/go-ipfs/core/coreapi/interface/options"
+)
+
+type NameAPI struct {
+	*HttpApi
+	*caopts.NameOptions
+}
+
+type ipnsEntry struct {
+	name  string
+	value coreiface.Path
+}
+
+func (e *ipnsEntry) Name() string {
+	return e.name
+}
+
+func (e *ipnsEntry) Value() coreiface.Path {
+	return e.value
+}
+
+func (api *NameAPI) Publish(ctx context.Context, p coreiface.Path, opts ...caopts.NamePublishOption) (coreiface.IpnsEntry, error) {
+	options, err := caopts.NamePublishOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var out struct {
+		Name  string
+		Value string
+	}
+
+	err = api.request("name/publish", p.String()).
+		Option("lifetime", options.ValidTime.String()).
+		Option("key", options.Key).
+		Exec(ctx, &out)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := coreapi.ParsePath(out.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ipnsEntry{name: out.Name, value: value}, nil
+}
+
+func (api *NameAPI) Resolve(ctx context.Context, name string, opts ...caopts.NameResolveOption) (coreiface.Path, error) {
+	options, err := caopts.NameResolveOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var out struct {
+		Path string
+	}
+
+	err = api.request("name/resolve", name).
+		Option("recursive", options.Recursive).
+		Option("local", options.Local).
+		Option("nocache", !options.Cache).
+		Exec(ctx, &out)
+	if err != nil {
+		return nil, err
+	}
+
+	return coreapi.ParsePath(out.Path)
+}
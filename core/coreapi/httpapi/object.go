@@ -0,0 +1,188 @@
+package httpapi
+
+import (
+	"context"
+	"io"
+
+	coreapi "github.com/ipfs/go-ipfs/core/coreapi"
+	coreiface "github.com/ipfs/go-ipfs/core/coreapi/interface"
+	caopts "github.com/ipfs/go-ipfs/core/coreapi/interface/options"
+
+	cid "gx/ipfs/QmeSrf6pzut73u6zLQkRFQ3ygt3k6XFT2kjdYP8Tnkwwyg/go-cid"
+)
+
+type ObjectAPI struct {
+	*HttpApi
+	*caopts.ObjectOptions
+}
+
+// objectOut mirrors the output of object commands returning a new object
+type objectOut struct {
+	Hash  string
+	Links []struct {
+		Name, Hash string
+		Size       uint64
+	}
+}
+
+func (o *objectOut) path() (coreiface.Path, error) {
+	c, err := cid.Decode(o.Hash)
+	if err != nil {
+		return nil, err
+	}
+	return coreapi.ParseCid(c), nil
+}
+
+func (api *ObjectAPI) New(ctx context.Context, opts ...caopts.ObjectNewOption) (coreiface.Node, error) {
+	options, err := caopts.ObjectNewOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var out objectOut
+	err = api.request("object/new", options.Type).Exec(ctx, &out)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := out.path()
+	if err != nil {
+		return nil, err
+	}
+
+	return api.ResolveNode(ctx, p)
+}
+
+func (api *ObjectAPI) Put(ctx context.Context, r io.Reader, opts ...caopts.ObjectPutOption) (coreiface.Path, error) {
+	options, err := caopts.ObjectPutOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var out objectOut
+	err = api.request("object/put").
+		Option("inputenc", options.InputEnc).
+		Option("datafieldenc", options.DataType).
+		Option("pin", options.Pin).
+		Body(r).
+		Exec(ctx, &out)
+	if err != nil {
+		return nil, err
+	}
+
+	return out.path()
+}
+
+func (api *ObjectAPI) Get(ctx context.Context, p coreiface.Path) (coreiface.Node, error) {
+	return api.ResolveNode(ctx, p)
+}
+
+func (api *ObjectAPI) Data(ctx context.Context, p coreiface.Path) (io.Reader, error) {
+	resp, err := api.request("object/data", p.String()).Send(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Reader(), nil
+}
+
+func (api *ObjectAPI) Links(ctx context.Context, p coreiface.Path) ([]*coreiface.Link, error) {
+	var out objectOut
+	err := api.request("object/links", p.String()).Exec(ctx, &out)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*coreiface.Link, len(out.Links))
+	for i, l := range out.Links {
+		c, err := cid.Decode(l.Hash)
+		if err != nil {
+			return nil, err
+		}
+
+		res[i] = &coreiface.Link{Name: l.Name, Size: l.Size, Cid: c}
+	}
+
+	return res, nil
+}
+
+func (api *ObjectAPI) Stat(ctx context.Context, p coreiface.Path) (*coreiface.ObjectStat, error) {
+	var out struct {
+		Hash           string
+		NumLinks       int
+		BlockSize      int
+		LinksSize      int
+		DataSize       int
+		CumulativeSize int
+	}
+
+	err := api.request("object/stat", p.String()).Exec(ctx, &out)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := cid.Decode(out.Hash)
+	if err != nil {
+		return nil, err
+	}
+
+	return &coreiface.ObjectStat{
+		Cid:            c,
+		NumLinks:       out.NumLinks,
+		BlockSize:      out.BlockSize,
+		LinksSize:      out.LinksSize,
+		DataSize:       out.DataSize,
+		CumulativeSize: out.CumulativeSize,
+	}, nil
+}
+
+func (api *ObjectAPI) AddLink(ctx context.Context, base coreiface.Path, name string, child coreiface.Path, opts ...caopts.ObjectAddLinkOption) (coreiface.Path, error) {
+	options, err := caopts.ObjectAddLinkOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var out objectOut
+	err = api.request("object/patch/add-link", base.String(), name, child.String()).
+		Option("create", options.Create).
+		Exec(ctx, &out)
+	if err != nil {
+		return nil, err
+	}
+
+	return out.path()
+}
+
+func (api *ObjectAPI) RmLink(ctx context.Context, base coreiface.Path, link string) (coreiface.Path, error) {
+	var out objectOut
+	err := api.request("object/patch/rm-link", base.String(), link).Exec(ctx, &out)
+	if err != nil {
+		return nil, err
+	}
+
+	return out.path()
+}
+
+func (api *ObjectAPI) AppendData(ctx context.Context, p coreiface.Path, r io.Reader) (coreiface.Path, error) {
+	var out objectOut
+	err := api.request("object/patch/append-data", p.String()).
+		Body(r).
+		Exec(ctx, &out)
+	if err != nil {
+		return nil, err
+	}
+
+	return out.path()
+}
+
+func (api *ObjectAPI) SetData(ctx context.Context, p coreiface.Path, r io.Reader) (coreiface.Path, error) {
+	var out objectOut
+	err := api.request("object/patch/set-data", p.String()).
+		Body(r).
+		Exec(ctx, &out)
+	if err != nil {
+		return nil, err
+	}
+
+	return out.path()
+}
@@ -0,0 +1,252 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	coreapi "github.com/ipfs/go-ipfs/core/coreapi"
+	coreiface "github.com/ipfs/go-ipfs/core/coreapi/interface"
+	caopts "github.com/ipfs/go-ipfs/core/coreapi/interface/options"
+
+	cid "gx/ipfs/QmeSrf6pzut73u6zLQkRFQ3ygt3k6XFT2kjdYP8Tnkwwyg/go-cid"
+)
+
+type PinAPI struct {
+	*HttpApi
+	*caopts.PinOptions
+}
+
+// pinInfo implements coreiface.Pin
+type pinInfo struct {
+	pinType string
+	path    coreiface.Path
+	name    string
+	labels  map[string]string
+	expires time.Time
+}
+
+func (p *pinInfo) Path() coreiface.Path {
+	return p.path
+}
+
+func (p *pinInfo) Type() string {
+	return p.pinType
+}
+
+func (p *pinInfo) Name() string {
+	return p.name
+}
+
+func (p *pinInfo) Labels() map[string]string {
+	return p.labels
+}
+
+func (p *pinInfo) Expires() time.Time {
+	return p.expires
+}
+
+// encodeLabels formats labels the way the pin commands expect them
+func encodeLabels(labels map[string]string) string {
+	parts := make([]string, 0, len(labels))
+	for k, v := range labels {
+		parts = append(parts, k+"="+v)
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
+
+func (api *PinAPI) Add(ctx context.Context, p coreiface.Path, opts ...caopts.PinAddOption) error {
+	options, err := caopts.PinAddOptions(opts...)
+	if err != nil {
+		return err
+	}
+
+	req := api.request("pin/add", p.String()).
+		Option("recursive", options.Recursive)
+	if options.Name != "" {
+		req.Option("name", options.Name)
+	}
+	if len(options.Labels) > 0 {
+		req.Option("label", encodeLabels(options.Labels))
+	}
+	if options.TTL > 0 {
+		req.Option("ttl", options.TTL.String())
+	}
+
+	return req.Exec(ctx, nil)
+}
+
+func (api *PinAPI) Ls(ctx context.Context, opts ...caopts.PinLsOption) ([]coreiface.Pin, error) {
+	options, err := caopts.PinLsOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var out struct {
+		Keys map[string]struct {
+			Type    string
+			Name    string
+			Labels  map[string]string
+			Expires string
+		}
+	}
+
+	req := api.request("pin/ls").
+		Option("type", options.Type)
+	if options.Name != "" {
+		req.Option("name", options.Name)
+	}
+	if len(options.Labels) > 0 {
+		req.Option("label", encodeLabels(options.Labels))
+	}
+
+	err = req.Exec(ctx, &out)
+	if err != nil {
+		return nil, err
+	}
+
+	pins := make([]coreiface.Pin, 0, len(out.Keys))
+	for hash, p := range out.Keys {
+		c, err := cid.Decode(hash)
+		if err != nil {
+			return nil, err
+		}
+		info := &pinInfo{
+			pinType: p.Type,
+			path:    coreapi.ParseCid(c),
+			name:    p.Name,
+			labels:  p.Labels,
+		}
+		if p.Expires != "" {
+			info.expires, err = time.Parse(time.RFC3339, p.Expires)
+			if err != nil {
+				return nil, err
+			}
+		}
+		pins = append(pins, info)
+	}
+
+	return pins, nil
+}
+
+func (api *PinAPI) Rm(ctx context.Context, p coreiface.Path, opts ...caopts.PinRmOption) error {
+	options, err := caopts.PinRmOptions(opts...)
+	if err != nil {
+		return err
+	}
+
+	return api.request("pin/rm", p.String()).
+		Option("recursive", options.Recursive).
+		Exec(ctx, nil)
+}
+
+func (api *PinAPI) Update(ctx context.Context, from coreiface.Path, to coreiface.Path, opts ...caopts.PinUpdateOption) error {
+	options, err := caopts.PinUpdateOptions(opts...)
+	if err != nil {
+		return err
+	}
+
+	return api.request("pin/update", from.String(), to.String()).
+		Option("unpin", options.Unpin).
+		Exec(ctx, nil)
+}
+
+// pinVerifyRes mirrors the output of 'pin verify' command
+type pinVerifyRes struct {
+	Cid      string
+	Ok       bool
+	BadNodes []struct {
+		Cid string
+		Err string
+	}
+}
+
+// pinStatus implements coreiface.PinStatus
+type pinStatus struct {
+	path     coreiface.Path
+	ok       bool
+	badNodes []coreiface.BadPinNode
+}
+
+func (s *pinStatus) Path() coreiface.Path {
+	return s.path
+}
+
+func (s *pinStatus) Ok() bool {
+	return s.ok
+}
+
+func (s *pinStatus) BadNodes() []coreiface.BadPinNode {
+	return s.badNodes
+}
+
+// badNode implements coreiface.BadPinNode
+type badNode struct {
+	path coreiface.Path
+	err  error
+}
+
+func (n *badNode) Path() coreiface.Path {
+	return n.path
+}
+
+func (n *badNode) Err() error {
+	return n.err
+}
+
+func (api *PinAPI) Verify(ctx context.Context) (<-chan coreiface.PinStatus, error) {
+	resp, err := api.request("pin/verify").
+		Option("verbose", true).
+		Send(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan coreiface.PinStatus)
+	go func() {
+		defer resp.Close()
+		defer close(out)
+		for {
+			var res pinVerifyRes
+			err := resp.Decode(&res)
+			if err != nil {
+				if err != io.EOF {
+					log.Error("pin verify: ", err)
+				}
+				return
+			}
+
+			c, err := cid.Decode(res.Cid)
+			if err != nil {
+				log.Error("pin verify: ", err)
+				return
+			}
+
+			status := &pinStatus{path: coreapi.ParseCid(c), ok: res.Ok}
+			for _, bn := range res.BadNodes {
+				bc, err := cid.Decode(bn.Cid)
+				if err != nil {
+					log.Error("pin verify: ", err)
+					return
+				}
+
+				status.badNodes = append(status.badNodes, &badNode{
+					path: coreapi.ParseCid(bc),
+					err:  errors.New(bn.Err),
+				})
+			}
+
+			select {
+			case out <- status:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
@@ -0,0 +1,135 @@
+package httpapi
+
+import (
+	"context"
+	"io"
+
+	coreiface "github.com/ipfs/go-ipfs/core/coreapi/interface"
+	caopts "github.com/ipfs/go-ipfs/core/coreapi/interface/options"
+
+	peer "gx/ipfs/QmWNY7dV54ZDYmTA1ykVdwNCqC11mpU4zSUp6XDpLTH9eG/go-libp2p-peer"
+)
+
+type PubSubAPI struct {
+	*HttpApi
+	*caopts.PubSubOptions
+}
+
+// pubsubMessage implements coreiface.PubSubMessage and mirrors the output of
+// 'pubsub sub' command
+type pubsubMessage struct {
+	JFrom     []byte   `json:"from,omitempty"`
+	JData     []byte   `json:"data,omitempty"`
+	JSeqno    []byte   `json:"seqno,omitempty"`
+	JTopicIDs []string `json:"topicIDs,omitempty"`
+}
+
+func (msg *pubsubMessage) From() peer.ID {
+	return peer.ID(msg.JFrom)
+}
+
+func (msg *pubsubMessage) Data() []byte {
+	return msg.JData
+}
+
+func (msg *pubsubMessage) Seq() []byte {
+	return msg.JSeqno
+}
+
+func (msg *pubsubMessage) Topics() []string {
+	return msg.JTopicIDs
+}
+
+// pubsubSub implements coreiface.PubSubSubscription
+type pubsubSub struct {
+	resp *Response
+}
+
+// Next returns the next message. The underlying request is bound to the
+// context passed to Subscribe, ctx is only checked before reading
+func (s *pubsubSub) Next(ctx context.Context) (coreiface.PubSubMessage, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var msg pubsubMessage
+	if err := s.resp.Decode(&msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+func (s *pubsubSub) Close() error {
+	return s.resp.Close()
+}
+
+func (api *PubSubAPI) Ls(ctx context.Context) ([]string, error) {
+	return api.stringList(ctx, api.request("pubsub/ls"))
+}
+
+func (api *PubSubAPI) Peers(ctx context.Context, opts ...caopts.PubSubPeersOption) ([]peer.ID, error) {
+	options, err := caopts.PubSubPeersOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	req := api.request("pubsub/peers")
+	if options.Topic != "" {
+		req.Arguments(options.Topic)
+	}
+
+	strs, err := api.stringList(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]peer.ID, len(strs))
+	for i, s := range strs {
+		res[i], err = peer.IDB58Decode(s)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+func (api *PubSubAPI) Publish(ctx context.Context, topic string, data []byte) error {
+	return api.request("pubsub/pub", topic, string(data)).Exec(ctx, nil)
+}
+
+func (api *PubSubAPI) Subscribe(ctx context.Context, topic string, opts ...caopts.PubSubSubscribeOption) (coreiface.PubSubSubscription, error) {
+	options, err := caopts.PubSubSubscribeOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := api.request("pubsub/sub", topic).
+		Option("discover", options.Discover).
+		Send(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pubsubSub{resp}, nil
+}
+
+// stringList reads a stream of strings from the response to req
+func (api *PubSubAPI) stringList(ctx context.Context, req *RequestBuilder) ([]string, error) {
+	resp, err := req.Send(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Close()
+
+	var out []string
+	for {
+		var s string
+		err := resp.Decode(&s)
+		if err == io.EOF {
+			return out, nil
+		} else if err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+}
@@ -0,0 +1,137 @@
+package httpapi
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+)
+
+// RequestBuilder is an IPFS commands request builder.
+type RequestBuilder struct {
+	command string
+	args    []string
+	opts    map[string]string
+	body    io.Reader
+
+	shell *HttpApi
+}
+
+// Arguments adds the arguments to the args.
+func (r *RequestBuilder) Arguments(args ...string) *RequestBuilder {
+	r.args = append(r.args, args...)
+	return r
+}
+
+// Body sets the request body to the given reader. The body is sent as a single
+// file argument.
+func (r *RequestBuilder) Body(body io.Reader) *RequestBuilder {
+	r.body = body
+	return r
+}
+
+// Option sets the given option.
+func (r *RequestBuilder) Option(key string, value interface{}) *RequestBuilder {
+	var s string
+	switch v := value.(type) {
+	case bool:
+		s = strconv.FormatBool(v)
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		// slow case.
+		s = fmt.Sprint(value)
+	}
+	if r.opts == nil {
+		r.opts = make(map[string]string, 1)
+	}
+	r.opts[key] = s
+	return r
+}
+
+// Send sends the request and return the response.
+func (r *RequestBuilder) Send(ctx context.Context) (*Response, error) {
+	values := make(url.Values)
+	for _, arg := range r.args {
+		values.Add("arg", arg)
+	}
+	for k, v := range r.opts {
+		values.Add(k, v)
+	}
+	values.Set("encoding", "json")
+	values.Set("stream-channels", "true")
+
+	u := fmt.Sprintf("%s/%s?%s", r.shell.url, r.command, values.Encode())
+
+	var body io.Reader
+	contentType := ""
+	if r.body != nil {
+		pr, pw := io.Pipe()
+		mw := multipart.NewWriter(pw)
+		contentType = mw.FormDataContentType()
+
+		go func() {
+			h := make(textproto.MIMEHeader)
+			h.Set("Content-Disposition", `file; filename=""`)
+			h.Set("Content-Type", "application/octet-stream")
+
+			part, err := mw.CreatePart(h)
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+
+			if _, err := io.Copy(part, r.body); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+
+			pw.CloseWithError(mw.Close())
+		}()
+
+		body = pr
+	}
+
+	req, err := http.NewRequest("POST", u, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := r.shell.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return newResponse(r.command, resp)
+}
+
+// Exec sends the request and decodes a single JSON value of the response into
+// res. If res is nil, the response is discarded.
+func (r *RequestBuilder) Exec(ctx context.Context, res interface{}) error {
+	resp, err := r.Send(ctx)
+	if err != nil {
+		return err
+	}
+	defer resp.Close()
+
+	if res == nil {
+		return resp.Drain()
+	}
+
+	err = resp.Decode(res)
+	if err == io.EOF {
+		return fmt.Errorf("%s: empty response", r.command)
+	}
+	return err
+}
@@ -0,0 +1,122 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// streamErrHeader is the trailer used by the daemon to report errors which
+// happened after the response headers were sent
+const streamErrHeader = "X-Stream-Error"
+
+// Error is an error returned by the daemon
+type Error struct {
+	Command string
+	Message string
+	Code    int
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Command, e.Message)
+}
+
+// Response is a response to a command request
+type Response struct {
+	Output io.ReadCloser
+
+	command string
+	resp    *http.Response
+	dec     *json.Decoder
+}
+
+func newResponse(command string, resp *http.Response) (*Response, error) {
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+
+		e := &Error{Command: command}
+		data, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(data, e); err != nil || e.Message == "" {
+			e.Message = fmt.Sprintf("%s (%d)", string(data), resp.StatusCode)
+		}
+		e.Command = command
+
+		return nil, e
+	}
+
+	return &Response{
+		Output:  resp.Body,
+		command: command,
+		resp:    resp,
+	}, nil
+}
+
+// Decode decodes the next JSON value from the response stream into v. It
+// returns io.EOF when there are no more values
+func (r *Response) Decode(v interface{}) error {
+	if r.dec == nil {
+		r.dec = json.NewDecoder(r.Output)
+	}
+
+	err := r.dec.Decode(v)
+	if err == io.EOF {
+		return r.streamError(io.EOF)
+	}
+	return err
+}
+
+// Drain reads the response until the end, returning error reported by the
+// daemon, if any
+func (r *Response) Drain() error {
+	_, err := io.Copy(ioutil.Discard, r.Output)
+	if err != nil {
+		return err
+	}
+	return r.streamError(nil)
+}
+
+// Close closes the response body
+func (r *Response) Close() error {
+	return r.Output.Close()
+}
+
+// streamError returns the error set in response trailers, or def if there was
+// none. It's only valid after the body was read completely
+func (r *Response) streamError(def error) error {
+	if msg := r.resp.Trailer.Get(streamErrHeader); msg != "" {
+		return &Error{Command: r.command, Message: msg}
+	}
+	return def
+}
+
+// Reader returns a reader of the response output, which closes the response
+// once it was read to the end or failed. Errors reported by the daemon after
+// the output are returned instead of io.EOF.
+func (r *Response) Reader() io.ReadCloser {
+	return &respReader{resp: r}
+}
+
+type respReader struct {
+	resp *Response
+}
+
+func (r *respReader) Read(b []byte) (int, error) {
+	n, err := r.resp.Output.Read(b)
+	if err == io.EOF {
+		err = r.resp.streamError(io.EOF)
+	}
+	if err != nil {
+		r.resp.Close()
+	}
+	return n, err
+}
+
+func (r *respReader) Close() error {
+	return r.resp.Close()
+}
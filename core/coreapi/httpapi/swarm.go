@@ -0,0 +1,202 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	coreiface "github.com/ipfs/go-ipfs/core/coreapi/interface"
+
+	ma "gx/ipfs/QmW8s4zTsUoX1Q6CeYxVKPyqSKbF7H1YDUyTostBtZ8DaG/go-multiaddr"
+	peer "gx/ipfs/QmWNY7dV54ZDYmTA1ykVdwNCqC11mpU4zSUp6XDpLTH9eG/go-libp2p-peer"
+	pstore "gx/ipfs/QmYijbtjCxFEjSXaudaQAUz3LN5VKLssm8WCUsRoqzXmQR/go-libp2p-peerstore"
+	protocol "gx/ipfs/QmZNkThpqfVXs9GNbexPrfBbXSLNYeKrE7jwFM2oqHbyqN/go-libp2p-protocol"
+)
+
+type SwarmAPI struct {
+	*HttpApi
+}
+
+// connInfo implements coreiface.ConnectionInfo
+type connInfo struct {
+	addr    ma.Multiaddr
+	peer    peer.ID
+	latency time.Duration
+	muxer   string
+	streams []protocol.ID
+}
+
+func (c *connInfo) ID() peer.ID {
+	return c.peer
+}
+
+func (c *connInfo) Address() ma.Multiaddr {
+	return c.addr
+}
+
+func (c *connInfo) Latency() (time.Duration, error) {
+	return c.latency, nil
+}
+
+func (c *connInfo) Streams() ([]protocol.ID, error) {
+	return c.streams, nil
+}
+
+// Muxer returns the name of stream muxer used by the connection, if known
+func (c *connInfo) Muxer() string {
+	return c.muxer
+}
+
+func (api *SwarmAPI) Connect(ctx context.Context, pi pstore.PeerInfo) error {
+	pidma, err := ma.NewMultiaddr("/ipfs/" + pi.ID.Pretty())
+	if err != nil {
+		return err
+	}
+
+	addrs := make([]string, len(pi.Addrs))
+	for i, addr := range pi.Addrs {
+		addrs[i] = addr.Encapsulate(pidma).String()
+	}
+	if len(addrs) == 0 {
+		addrs = append(addrs, pidma.String())
+	}
+
+	return api.request("swarm/connect", addrs...).Exec(ctx, nil)
+}
+
+func (api *SwarmAPI) Disconnect(ctx context.Context, addr ma.Multiaddr) error {
+	var out struct {
+		Strings []string
+	}
+
+	err := api.request("swarm/disconnect", addr.String()).Exec(ctx, &out)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range out.Strings {
+		if i := strings.Index(s, " failure: "); i >= 0 {
+			return errors.New(s[i+len(" failure: "):])
+		}
+	}
+	return nil
+}
+
+func (api *SwarmAPI) Peers(ctx context.Context) ([]coreiface.ConnectionInfo, error) {
+	var out struct {
+		Peers []struct {
+			Addr    string
+			Peer    string
+			Latency string
+			Muxer   string
+			Streams []struct {
+				Protocol string
+			}
+		}
+	}
+
+	err := api.request("swarm/peers").
+		Option("verbose", true).
+		Exec(ctx, &out)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]coreiface.ConnectionInfo, len(out.Peers))
+	for i, conn := range out.Peers {
+		pid, err := peer.IDB58Decode(conn.Peer)
+		if err != nil {
+			return nil, err
+		}
+
+		addr, err := ma.NewMultiaddr(conn.Addr)
+		if err != nil {
+			return nil, err
+		}
+
+		ci := &connInfo{
+			addr:  addr,
+			peer:  pid,
+			muxer: conn.Muxer,
+		}
+
+		// latency is reported as "n/a" when unknown
+		if lat, err := time.ParseDuration(conn.Latency); err == nil {
+			ci.latency = lat
+		}
+
+		for _, s := range conn.Streams {
+			ci.streams = append(ci.streams, protocol.ID(s.Protocol))
+		}
+
+		res[i] = ci
+	}
+
+	return res, nil
+}
+
+func (api *SwarmAPI) KnownAddrs(ctx context.Context) (map[peer.ID][]ma.Multiaddr, error) {
+	var out struct {
+		Addrs map[string][]string
+	}
+
+	err := api.request("swarm/addrs").Exec(ctx, &out)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[peer.ID][]ma.Multiaddr)
+	for spid, saddrs := range out.Addrs {
+		pid, err := peer.IDB58Decode(spid)
+		if err != nil {
+			return nil, err
+		}
+
+		addrs, err := parseAddrs(saddrs)
+		if err != nil {
+			return nil, err
+		}
+		res[pid] = addrs
+	}
+
+	return res, nil
+}
+
+func (api *SwarmAPI) LocalAddrs(ctx context.Context) ([]ma.Multiaddr, error) {
+	var out struct {
+		Strings []string
+	}
+
+	err := api.request("swarm/addrs/local").Exec(ctx, &out)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseAddrs(out.Strings)
+}
+
+func (api *SwarmAPI) ListenAddrs(ctx context.Context) ([]ma.Multiaddr, error) {
+	var out struct {
+		Strings []string
+	}
+
+	err := api.request("swarm/addrs/listen").Exec(ctx, &out)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseAddrs(out.Strings)
+}
+
+func parseAddrs(saddrs []string) ([]ma.Multiaddr, error) {
+	addrs := make([]ma.Multiaddr, len(saddrs))
+	for i, s := range saddrs {
+		a, err := ma.NewMultiaddr(s)
+		if err != nil {
+			return nil, err
+		}
+		addrs[i] = a
+	}
+	return addrs, nil
+}
@@ -0,0 +1,192 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	coreapi "github.com/ipfs/go-ipfs/core/coreapi"
+	coreiface "github.com/ipfs/go-ipfs/core/coreapi/interface"
+	uio "github.com/ipfs/go-ipfs/unixfs/io"
+
+	cid "gx/ipfs/QmeSrf6pzut73u6zLQkRFQ3ygt3k6XFT2kjdYP8Tnkwwyg/go-cid"
+)
+
+// contentLengthHeader is set by the daemon on responses of known length
+const contentLengthHeader = "X-Content-Length"
+
+type UnixfsAPI HttpApi
+
+func (api *UnixfsAPI) Add(ctx context.Context, r io.Reader) (coreiface.Path, error) {
+	resp, err := api.core().request("add").
+		Option("pin", false).
+		Option("progress", false).
+		Body(r).
+		Send(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Close()
+
+	var last string
+	for {
+		var out struct {
+			Hash string
+		}
+
+		err := resp.Decode(&out)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		if out.Hash != "" {
+			last = out.Hash
+		}
+	}
+
+	if last == "" {
+		return nil, errors.New("add: no hash returned")
+	}
+
+	c, err := cid.Decode(last)
+	if err != nil {
+		return nil, err
+	}
+	return coreapi.ParseCid(c), nil
+}
+
+func (api *UnixfsAPI) Cat(ctx context.Context, p coreiface.Path) (coreiface.Reader, error) {
+	r := &catReader{
+		ctx:  ctx,
+		api:  api.core(),
+		path: p,
+	}
+
+	err := r.open()
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (api *UnixfsAPI) Ls(ctx context.Context, p coreiface.Path) ([]*coreiface.Link, error) {
+	var out struct {
+		Objects []struct {
+			Hash  string
+			Links []struct {
+				Name, Hash string
+				Size       uint64
+			}
+		}
+	}
+
+	err := api.core().request("ls", p.String()).
+		Option("resolve-type", false).
+		Exec(ctx, &out)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(out.Objects) != 1 {
+		return nil, fmt.Errorf("ls: unexpected number of objects: %d", len(out.Objects))
+	}
+
+	links := make([]*coreiface.Link, len(out.Objects[0].Links))
+	for i, l := range out.Objects[0].Links {
+		c, err := cid.Decode(l.Hash)
+		if err != nil {
+			return nil, err
+		}
+		links[i] = &coreiface.Link{Name: l.Name, Size: l.Size, Cid: c}
+	}
+	return links, nil
+}
+
+func (api *UnixfsAPI) core() *HttpApi {
+	return (*HttpApi)(api)
+}
+
+// catReader implements coreiface.Reader. Seeking re-issues the cat request
+// with an offset
+type catReader struct {
+	ctx  context.Context
+	api  *HttpApi
+	path coreiface.Path
+
+	offset int64
+	size   int64
+	resp   *Response
+}
+
+func (r *catReader) open() error {
+	req := r.api.request("cat", r.path.String())
+	if r.offset > 0 {
+		req.Option("offset", r.offset)
+	}
+
+	resp, err := req.Send(r.ctx)
+	if err != nil {
+		if e, ok := err.(*Error); ok && e.Message == uio.ErrIsDir.Error() {
+			return coreiface.ErrIsDir
+		}
+		return err
+	}
+
+	// the daemon reports the length of the remaining data
+	r.size = -1
+	if l, err := strconv.ParseInt(resp.resp.Header.Get(contentLengthHeader), 10, 64); err == nil {
+		r.size = r.offset + l
+	}
+
+	r.resp = resp
+	return nil
+}
+
+func (r *catReader) Read(b []byte) (int, error) {
+	n, err := r.resp.Output.Read(b)
+	r.offset += int64(n)
+	if err == io.EOF {
+		if serr := r.resp.streamError(nil); serr != nil {
+			return n, serr
+		}
+	}
+	return n, err
+}
+
+func (r *catReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		if r.size < 0 {
+			return r.offset, errors.New("cat: file size unknown")
+		}
+		offset += r.size
+	default:
+		return r.offset, errors.New("cat: unsupported seek whence")
+	}
+
+	if offset < 0 {
+		return r.offset, errors.New("cat: invalid offset")
+	}
+
+	if offset == r.offset {
+		return offset, nil
+	}
+
+	if err := r.resp.Close(); err != nil {
+		return r.offset, err
+	}
+
+	r.offset = offset
+	return offset, r.open()
+}
+
+func (r *catReader) Close() error {
+	return r.resp.Close()
+}
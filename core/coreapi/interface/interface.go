@@ -11,6 +11,10 @@ import (
 	options "github.com/ipfs/go-ipfs/core/coreapi/interface/options"
 
 	ipld "gx/ipfs/QmNwUEK7QbwSqyKBu3mMtToo8SUc6wQJ7gdZq4gGGJqfnf/go-ipld-format"
+	ma "gx/ipfs/QmW8s4zTsUoX1Q6CeYxVKPyqSKbF7H1YDUyTostBtZ8DaG/go-multiaddr"
+	peer "gx/ipfs/QmWNY7dV54ZDYmTA1ykVdwNCqC11mpU4zSUp6XDpLTH9eG/go-libp2p-peer"
+	pstore "gx/ipfs/QmYijbtjCxFEjSXaudaQAUz3LN5VKLssm8WCUsRoqzXmQR/go-libp2p-peerstore"
+	protocol "gx/ipfs/QmZNkThpqfVXs9GNbexPrfBbXSLNYeKrE7jwFM2oqHbyqN/go-libp2p-protocol"
 	cid "gx/ipfs/QmeSrf6pzut73u6zLQkRFQ3ygt3k6XFT2kjdYP8Tnkwwyg/go-cid"
 )
 
@@ -43,6 +47,114 @@ type Key interface {
 	Path() Path
 }
 
+// Pin holds information about pinned resource
+type Pin interface {
+	// Path to the pinned object
+	Path() Path
+
+	// Type of the pin
+	Type() string
+
+	// Name of the pin, empty if it has none
+	Name() string
+
+	// Labels attached to the pin
+	Labels() map[string]string
+
+	// Expires returns the time the pin will be removed, or the zero time if
+	// it never expires
+	Expires() time.Time
+}
+
+// PinStatus holds information about pin health
+type PinStatus interface {
+	// Path of the verified pin root
+	Path() Path
+
+	// Ok indicates whether the pin has been verified to be correct
+	Ok() bool
+
+	// BadNodes returns any bad (usually missing) nodes from the pin
+	BadNodes() []BadPinNode
+}
+
+// BlockStat contains information about a block
+type BlockStat interface {
+	// Size is the size of a block
+	Size() int
+
+	// Path returns path to the block
+	Path() Path
+}
+
+// ObjectStat provides information about dag nodes
+type ObjectStat struct {
+	// Cid is the CID of the node
+	Cid *cid.Cid
+
+	// NumLinks is number of links the node contains
+	NumLinks int
+
+	// BlockSize is size of the raw serialized node
+	BlockSize int
+
+	// LinksSize is size of the links block section
+	LinksSize int
+
+	// DataSize is the size of data block section
+	DataSize int
+
+	// CumulativeSize is size of the tree (BlockSize + link sizes)
+	CumulativeSize int
+}
+
+// BadPinNode is a node that has been marked as bad by Pin.Verify
+type BadPinNode interface {
+	// Path is the path of the node
+	Path() Path
+
+	// Err is the reason why the node has been marked as bad
+	Err() error
+}
+
+// ConnectionInfo contains information about a peer
+type ConnectionInfo interface {
+	// ID returns PeerID
+	ID() peer.ID
+
+	// Address returns the multiaddress via which we are connected with the peer
+	Address() ma.Multiaddr
+
+	// Latency returns last known round trip time to the peer
+	Latency() (time.Duration, error)
+
+	// Streams returns list of streams established with the peer
+	Streams() ([]protocol.ID, error)
+}
+
+// PubSubMessage is a single PubSub message
+type PubSubMessage interface {
+	// From returns id of a peer from which the message has arrived
+	From() peer.ID
+
+	// Data returns the message body
+	Data() []byte
+
+	// Seq returns message identifier
+	Seq() []byte
+
+	// Topics returns list of topics this message was set to
+	Topics() []string
+}
+
+// PubSubSubscription is an active PubSub subscription
+type PubSubSubscription interface {
+	io.Closer
+
+	// Next return the next incoming message
+	Next(context.Context) (PubSubMessage, error)
+}
+
 // CoreAPI defines an unified interface to IPFS for Go programs.
 type CoreAPI interface {
 	// Unixfs returns an implementation of Unixfs API
@@ -50,6 +162,12 @@ type CoreAPI interface {
 	Dag() DagAPI
 	Name() NameAPI
 	Key() KeyAPI
+	Pin() PinAPI
+	Block() BlockAPI
+	Object() ObjectAPI
+	Dht() DhtAPI
+	Swarm() SwarmAPI
+	PubSub() PubSubAPI
 
 	// ResolvePath resolves the path using Unixfs resolver
 	ResolvePath(context.Context, Path) (Path, error)
@@ -178,27 +296,238 @@ type KeyAPI interface {
 	Remove(ctx context.Context, name string) (Path, error)
 }
 
-// type ObjectAPI interface {
-// 	New() (cid.Cid, Object)
-// 	Get(string) (Object, error)
-// 	Links(string) ([]*Link, error)
-// 	Data(string) (Reader, error)
-// 	Stat(string) (ObjectStat, error)
-// 	Put(Object) (cid.Cid, error)
-// 	SetData(string, Reader) (cid.Cid, error)
-// 	AppendData(string, Data) (cid.Cid, error)
-// 	AddLink(string, string, string) (cid.Cid, error)
-// 	RmLink(string, string) (cid.Cid, error)
-// }
-
-// type ObjectStat struct {
-// 	Cid            cid.Cid
-// 	NumLinks       int
-// 	BlockSize      int
-// 	LinksSize      int
-// 	DataSize       int
-// 	CumulativeSize int
-// }
+// PinAPI specifies the interface to pinning
+type PinAPI interface {
+	// Add creates new pin, by default recursive - pinning the whole referenced
+	// tree
+	Add(ctx context.Context, path Path, opts ...options.PinAddOption) error
+
+	// WithRecursive is an option for Add which specifies whether to pin an entire
+	// object tree or just one object. Default: true
+	WithRecursive(recursive bool) options.PinAddOption
+
+	// WithName is an option for Add which attaches a name to the pin
+	WithName(name string) options.PinAddOption
+
+	// WithLabel is an option for Add which attaches a key/value label to the
+	// pin. It can be given multiple times
+	WithLabel(key, value string) options.PinAddOption
+
+	// WithTTL is an option for Add which makes the pin expire after the given
+	// duration. Expired pins are removed by the daemon. Default: never expire
+	WithTTL(ttl time.Duration) options.PinAddOption
+
+	// Ls returns list of pinned objects on this node
+	Ls(ctx context.Context, opts ...options.PinLsOption) ([]Pin, error)
+
+	// WithType is an option for Ls which allows to specify which pin types should
+	// be returned
+	//
+	// Supported values:
+	// * "direct" - directly pinned objects
+	// * "recursive" - roots of recursive pins
+	// * "indirect" - indirectly pinned objects (referenced by recursively pinned
+	//    objects)
+	// * "all" - all pinned objects (default)
+	WithType(typeStr string) options.PinLsOption
+
+	// WithNameFilter is an option for Ls which only returns pins with the
+	// given name. Indirect pins never match a filter
+	WithNameFilter(name string) options.PinLsOption
+
+	// WithLabelFilter is an option for Ls which only returns pins carrying
+	// the given label. It can be given multiple times
+	WithLabelFilter(key, value string) options.PinLsOption
+
+	// Rm removes pin for object specified by the path
+	Rm(ctx context.Context, path Path, opts ...options.PinRmOption) error
+
+	// WithRmRecursive is an option for Rm which specifies whether to remove
+	// a recursive pin. Use false to remove direct pins only. Default: true
+	WithRmRecursive(recursive bool) options.PinRmOption
+
+	// Update changes one pin to another, skipping checks for matching paths in
+	// the old tree
+	Update(ctx context.Context, from Path, to Path, opts ...options.PinUpdateOption) error
+
+	// WithUnpin is an option for Update which specifies whether to remove the
+	// old pin. Default is true.
+	WithUnpin(unpin bool) options.PinUpdateOption
+
+	// Verify verifies the integrity of recursively pinned objects
+	Verify(ctx context.Context) (<-chan PinStatus, error)
+}
+
+// BlockAPI specifies the interface to the block layer
+type BlockAPI interface {
+	// Put imports raw block data, hashing it using specified settings.
+	Put(ctx context.Context, src io.Reader, opts ...options.BlockPutOption) (BlockStat, error)
+
+	// WithFormat is an option for Put which specifies the multicodec to use to
+	// serialize the object. Default is "v0"
+	WithFormat(codec string) options.BlockPutOption
+
+	// WithHash is an option for Put which specifies the multihash settings to use
+	// when hashing the object. Default is mh.SHA2_256 (0x12).
+	// If mhLen is set to -1, default length for the hash will be used
+	WithHash(mhType uint64, mhLen int) options.BlockPutOption
+
+	// WithPin is an option for Put which specifies whether to pin the created
+	// block. Default is false
+	WithPin(pin bool) options.BlockPutOption
+
+	// Get attempts to resolve the path and return a reader for data in the block
+	Get(ctx context.Context, path Path) (io.Reader, error)
+
+	// Rm removes the block specified by the path from local blockstore.
+	// By default an error will be returned if the block can't be found locally.
+	//
+	// NOTE: If the specified block is pinned it won't be removed and an error
+	// describing the pin will be returned
+	Rm(ctx context.Context, path Path, opts ...options.BlockRmOption) error
+
+	// WithForce is an option for Rm which, when set to true, will ignore
+	// non-existing blocks
+	WithForce(force bool) options.BlockRmOption
+
+	// Stat returns information on a block
+	Stat(ctx context.Context, path Path) (BlockStat, error)
+}
+
+// ObjectAPI specifies the interface to MerkleDAG and contains useful utilities
+// for manipulating MerkleDAG data structures.
+type ObjectAPI interface {
+	// New creates new, empty (by default) dag-node.
+	New(ctx context.Context, opts ...options.ObjectNewOption) (Node, error)
+
+	// WithType is an option for New which allows to change the type of created
+	// dag node.
+	//
+	// Supported Types:
+	// * 'empty' - Empty node
+	// * 'unixfs-dir' - Empty UnixFS directory
+	WithType(t string) options.ObjectNewOption
+
+	// Put imports the data into merkledag
+	Put(ctx context.Context, src io.Reader, opts ...options.ObjectPutOption) (Path, error)
+
+	// WithInputEnc is an option for Put which specifies the input encoding of the
+	// data. Default is "json".
+	//
+	// Supported encodings:
+	// * "protobuf"
+	// * "json"
+	// * "xml"
+	WithInputEnc(e string) options.ObjectPutOption
+
+	// WithDataType specifies the encoding of data field when using Json or XML
+	// input encoding.
+	//
+	// Supported types:
+	// * "text" (default)
+	// * "base64"
+	WithDataType(t string) options.ObjectPutOption
+
+	// WithPin is an option for Put which specifies whether to pin the added
+	// object recursively. Default is false
+	WithPin(pin bool) options.ObjectPutOption
+
+	// Get returns the node for the path
+	Get(ctx context.Context, path Path) (Node, error)
+
+	// Data returns reader for data of the node
+	Data(ctx context.Context, path Path) (io.Reader, error)
+
+	// Links returns the links the node contains
+	Links(ctx context.Context, path Path) ([]*Link, error)
+
+	// Stat returns information about the node
+	Stat(ctx context.Context, path Path) (*ObjectStat, error)
+
+	// AddLink adds a link under the specified path. child path can point to a
+	// subdirectory within the parent which must be present (can be overridden
+	// with WithCreate option).
+	AddLink(ctx context.Context, base Path, name string, child Path, opts ...options.ObjectAddLinkOption) (Path, error)
+
+	// WithCreate is an option for AddLink which specifies whether create required
+	// directories for the child
+	WithCreate(create bool) options.ObjectAddLinkOption
+
+	// RmLink removes a link from the node
+	RmLink(ctx context.Context, base Path, link string) (Path, error)
+
+	// AppendData appends data to the node
+	AppendData(ctx context.Context, path Path, data io.Reader) (Path, error)
+
+	// SetData sets the data contained in the node
+	SetData(ctx context.Context, path Path, data io.Reader) (Path, error)
+}
+
+// DhtAPI specifies the interface to the DHT
+type DhtAPI interface {
+	// FindPeer queries the DHT for all of the multiaddresses associated with a
+	// Peer ID
+	FindPeer(context.Context, peer.ID) (pstore.PeerInfo, error)
+
+	// FindProviders finds peers in the DHT who can provide a specific value
+	// given a key.
+	FindProviders(context.Context, Path, ...options.DhtFindProvidersOption) (<-chan pstore.PeerInfo, error)
+
+	// WithNumProviders is an option for FindProviders which specifies the
+	// number of peers to look for. Default is 20
+	WithNumProviders(numProviders int) options.DhtFindProvidersOption
+
+	// Provide announces to the network that you are providing given values
+	Provide(context.Context, Path, ...options.DhtProvideOption) error
+
+	// WithRecursive is an option for Provide which specifies whether to provide
+	// the given path recursively
+	WithRecursive(recursive bool) options.DhtProvideOption
+}
+
+// SwarmAPI specifies the interface to libp2p swarm
+type SwarmAPI interface {
+	// Connect to a given peer
+	Connect(context.Context, pstore.PeerInfo) error
+
+	// Disconnect from a given address
+	Disconnect(context.Context, ma.Multiaddr) error
+
+	// Peers returns the list of peers we are connected to
+	Peers(context.Context) ([]ConnectionInfo, error)
+
+	// KnownAddrs returns the list of all addresses this node is aware of
+	KnownAddrs(context.Context) (map[peer.ID][]ma.Multiaddr, error)
+
+	// LocalAddrs returns the list of announced listening addresses
+	LocalAddrs(context.Context) ([]ma.Multiaddr, error)
+
+	// ListenAddrs returns the list of all listening addresses
+	ListenAddrs(context.Context) ([]ma.Multiaddr, error)
+}
+
+// PubSubAPI specifies the interface to PubSub
+type PubSubAPI interface {
+	// Ls lists subscribed topics by name
+	Ls(context.Context) ([]string, error)
+
+	// Peers list peers we are currently pubsubbing with
+	Peers(context.Context, ...options.PubSubPeersOption) ([]peer.ID, error)
+
+	// WithTopic is an option for Peers which specifies a topic filter for the
+	// function
+	WithTopic(topic string) options.PubSubPeersOption
+
+	// Publish a message to a given pubsub topic
+	Publish(context.Context, string, []byte) error
+
+	// Subscribe to messages on a given topic
+	Subscribe(context.Context, string, ...options.PubSubSubscribeOption) (PubSubSubscription, error)
+
+	// WithDiscover is an option for Subscribe which specifies whether to try to
+	// discover other peers subscribed to the same topic
+	WithDiscover(discover bool) options.PubSubSubscribeOption
+}
 
 var ErrIsDir = errors.New("object is a directory")
 var ErrOffline = errors.New("can't resolve, ipfs node is offline")
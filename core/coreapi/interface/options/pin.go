@@ -0,0 +1,162 @@
+package options
+
+import (
+	"time"
+)
+
+type PinAddSettings struct {
+	Recursive bool
+	Name      string
+	Labels    map[string]string
+	TTL       time.Duration
+}
+
+type PinLsSettings struct {
+	Type   string
+	Name   string
+	Labels map[string]string
+}
+
+type PinRmSettings struct {
+	Recursive bool
+}
+
+type PinUpdateSettings struct {
+	Unpin bool
+}
+
+type PinAddOption func(*PinAddSettings) error
+type PinLsOption func(*PinLsSettings) error
+type PinRmOption func(*PinRmSettings) error
+type PinUpdateOption func(*PinUpdateSettings) error
+
+func PinAddOptions(opts ...PinAddOption) (*PinAddSettings, error) {
+	options := &PinAddSettings{
+		Recursive: true,
+	}
+
+	for _, opt := range opts {
+		err := opt(options)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return options, nil
+}
+
+func PinLsOptions(opts ...PinLsOption) (*PinLsSettings, error) {
+	options := &PinLsSettings{
+		Type: "all",
+	}
+
+	for _, opt := range opts {
+		err := opt(options)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return options, nil
+}
+
+func PinRmOptions(opts ...PinRmOption) (*PinRmSettings, error) {
+	options := &PinRmSettings{
+		Recursive: true,
+	}
+
+	for _, opt := range opts {
+		err := opt(options)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return options, nil
+}
+
+func PinUpdateOptions(opts ...PinUpdateOption) (*PinUpdateSettings, error) {
+	options := &PinUpdateSettings{
+		Unpin: true,
+	}
+
+	for _, opt := range opts {
+		err := opt(options)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return options, nil
+}
+
+type PinOptions struct{}
+
+func (api *PinOptions) WithRecursive(recursive bool) PinAddOption {
+	return func(settings *PinAddSettings) error {
+		settings.Recursive = recursive
+		return nil
+	}
+}
+
+func (api *PinOptions) WithName(name string) PinAddOption {
+	return func(settings *PinAddSettings) error {
+		settings.Name = name
+		return nil
+	}
+}
+
+func (api *PinOptions) WithLabel(key, value string) PinAddOption {
+	return func(settings *PinAddSettings) error {
+		if settings.Labels == nil {
+			settings.Labels = make(map[string]string)
+		}
+		settings.Labels[key] = value
+		return nil
+	}
+}
+
+func (api *PinOptions) WithTTL(ttl time.Duration) PinAddOption {
+	return func(settings *PinAddSettings) error {
+		settings.TTL = ttl
+		return nil
+	}
+}
+
+func (api *PinOptions) WithType(t string) PinLsOption {
+	return func(settings *PinLsSettings) error {
+		settings.Type = t
+		return nil
+	}
+}
+
+func (api *PinOptions) WithNameFilter(name string) PinLsOption {
+	return func(settings *PinLsSettings) error {
+		settings.Name = name
+		return nil
+	}
+}
+
+func (api *PinOptions) WithLabelFilter(key, value string) PinLsOption {
+	return func(settings *PinLsSettings) error {
+		if settings.Labels == nil {
+			settings.Labels = make(map[string]string)
+		}
+		settings.Labels[key] = value
+		return nil
+	}
+}
+
+func (api *PinOptions) WithRmRecursive(recursive bool) PinRmOption {
+	return func(settings *PinRmSettings) error {
+		settings.Recursive = recursive
+		return nil
+	}
+}
+
+func (api *PinOptions) WithUnpin(unpin bool) PinUpdateOption {
+	return func(settings *PinUpdateSettings) error {
+		settings.Unpin = unpin
+		return nil
+	}
+}
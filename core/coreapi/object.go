@@ -0,0 +1,355 @@
+package coreapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	coreiface "github.com/ipfs/go-ipfs/core/coreapi/interface"
+	caopts "github.com/ipfs/go-ipfs/core/coreapi/interface/options"
+	dag "github.com/ipfs/go-ipfs/merkledag"
+	dagutils "github.com/ipfs/go-ipfs/merkledag/utils"
+	pin "github.com/ipfs/go-ipfs/pin"
+	ft "github.com/ipfs/go-ipfs/unixfs"
+
+	node "gx/ipfs/QmNwUEK7QbwSqyKBu3mMtToo8SUc6wQJ7gdZq4gGGJqfnf/go-ipld-format"
+	cid "gx/ipfs/QmeSrf6pzut73u6zLQkRFQ3ygt3k6XFT2kjdYP8Tnkwwyg/go-cid"
+)
+
+const inputLimit = 2 << 20
+
+// ErrObjectTooLarge is returned when too much data was read from the input.
+// current limit 2m
+var ErrObjectTooLarge = errors.New("input object was too large. limit is 2mbytes")
+
+// ErrEmptyNode is returned when the input to ObjectAPI.Put contains no data
+var ErrEmptyNode = errors.New("no data or links in this node")
+
+// ErrUnknownObjectEnc is returned if a invalid encoding is supplied
+var ErrUnknownObjectEnc = errors.New("unknown object encoding")
+
+type ObjectAPI struct {
+	*CoreAPI
+	*caopts.ObjectOptions
+}
+
+type Link struct {
+	Name, Hash string
+	Size       uint64
+}
+
+type Node struct {
+	Links []Link
+	Data  string
+}
+
+func (api *ObjectAPI) New(ctx context.Context, opts ...caopts.ObjectNewOption) (coreiface.Node, error) {
+	options, err := caopts.ObjectNewOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var n node.Node
+	switch options.Type {
+	case "empty":
+		n = new(dag.ProtoNode)
+	case "unixfs-dir":
+		n = ft.EmptyDirNode()
+	default:
+		return nil, fmt.Errorf("template '%s' not found", options.Type)
+	}
+
+	_, err = api.node.DAG.Add(n)
+	if err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+func (api *ObjectAPI) Put(ctx context.Context, src io.Reader, opts ...caopts.ObjectPutOption) (coreiface.Path, error) {
+	options, err := caopts.ObjectPutOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(src, inputLimit+10))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) >= inputLimit {
+		return nil, ErrObjectTooLarge
+	}
+
+	var dagnode *dag.ProtoNode
+	switch options.InputEnc {
+	case "json":
+		node := new(Node)
+		err = json.Unmarshal(data, node)
+		if err != nil {
+			return nil, err
+		}
+
+		// check that we have data in the Node to add
+		// otherwise we will add the empty object without raising an error
+		if nodeEmpty(node) {
+			return nil, ErrEmptyNode
+		}
+
+		dagnode, err = deserializeNode(node, options.DataType)
+		if err != nil {
+			return nil, err
+		}
+
+	case "protobuf":
+		dagnode, err = dag.DecodeProtobuf(data)
+
+	case "xml":
+		node := new(Node)
+		err = xml.Unmarshal(data, node)
+		if err != nil {
+			return nil, err
+		}
+
+		// check that we have data in the Node to add
+		// otherwise we will add the empty object without raising an error
+		if nodeEmpty(node) {
+			return nil, ErrEmptyNode
+		}
+
+		dagnode, err = deserializeNode(node, options.DataType)
+		if err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, ErrUnknownObjectEnc
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if options.Pin {
+		defer api.node.Blockstore.PinLock().Unlock()
+	}
+
+	_, err = api.node.DAG.Add(dagnode)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.Pin {
+		api.node.Pinning.PinWithMode(dagnode.Cid(), pin.Recursive)
+		err = api.node.Pinning.Flush()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ParseCid(dagnode.Cid()), nil
+}
+
+func (api *ObjectAPI) Get(ctx context.Context, path coreiface.Path) (coreiface.Node, error) {
+	return api.core().ResolveNode(ctx, path)
+}
+
+func (api *ObjectAPI) Data(ctx context.Context, path coreiface.Path) (io.Reader, error) {
+	nd, err := api.core().ResolveNode(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	pbnd, ok := nd.(*dag.ProtoNode)
+	if !ok {
+		return nil, dag.ErrNotProtobuf
+	}
+
+	return bytes.NewReader(pbnd.Data()), nil
+}
+
+func (api *ObjectAPI) Links(ctx context.Context, path coreiface.Path) ([]*coreiface.Link, error) {
+	nd, err := api.core().ResolveNode(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	links := nd.Links()
+	out := make([]*coreiface.Link, len(links))
+	for n, l := range links {
+		out[n] = (*coreiface.Link)(l)
+	}
+
+	return out, nil
+}
+
+func (api *ObjectAPI) Stat(ctx context.Context, path coreiface.Path) (*coreiface.ObjectStat, error) {
+	nd, err := api.core().ResolveNode(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	stat, err := nd.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	out := &coreiface.ObjectStat{
+		Cid:            nd.Cid(),
+		NumLinks:       stat.NumLinks,
+		BlockSize:      stat.BlockSize,
+		LinksSize:      stat.LinksSize,
+		DataSize:       stat.DataSize,
+		CumulativeSize: stat.CumulativeSize,
+	}
+
+	return out, nil
+}
+
+func (api *ObjectAPI) AddLink(ctx context.Context, base coreiface.Path, name string, child coreiface.Path, opts ...caopts.ObjectAddLinkOption) (coreiface.Path, error) {
+	options, err := caopts.ObjectAddLinkOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	baseNd, err := api.core().ResolveNode(ctx, base)
+	if err != nil {
+		return nil, err
+	}
+
+	childNd, err := api.core().ResolveNode(ctx, child)
+	if err != nil {
+		return nil, err
+	}
+
+	basePb, ok := baseNd.(*dag.ProtoNode)
+	if !ok {
+		return nil, dag.ErrNotProtobuf
+	}
+
+	var createfunc func() *dag.ProtoNode
+	if options.Create {
+		createfunc = ft.EmptyDirNode
+	}
+
+	e := dagutils.NewDagEditor(basePb, api.node.DAG)
+
+	err = e.InsertNodeAtPath(ctx, name, childNd, createfunc)
+	if err != nil {
+		return nil, err
+	}
+
+	nnode, err := e.Finalize(api.node.DAG)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseCid(nnode.Cid()), nil
+}
+
+func (api *ObjectAPI) RmLink(ctx context.Context, base coreiface.Path, link string) (coreiface.Path, error) {
+	baseNd, err := api.core().ResolveNode(ctx, base)
+	if err != nil {
+		return nil, err
+	}
+
+	basePb, ok := baseNd.(*dag.ProtoNode)
+	if !ok {
+		return nil, dag.ErrNotProtobuf
+	}
+
+	e := dagutils.NewDagEditor(basePb, api.node.DAG)
+
+	err = e.RmLink(ctx, link)
+	if err != nil {
+		return nil, err
+	}
+
+	nnode, err := e.Finalize(api.node.DAG)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseCid(nnode.Cid()), nil
+}
+
+func (api *ObjectAPI) AppendData(ctx context.Context, path coreiface.Path, r io.Reader) (coreiface.Path, error) {
+	return api.patchData(ctx, path, r, true)
+}
+
+func (api *ObjectAPI) SetData(ctx context.Context, path coreiface.Path, r io.Reader) (coreiface.Path, error) {
+	return api.patchData(ctx, path, r, false)
+}
+
+func (api *ObjectAPI) patchData(ctx context.Context, path coreiface.Path, r io.Reader, appendData bool) (coreiface.Path, error) {
+	nd, err := api.core().ResolveNode(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	pbnd, ok := nd.(*dag.ProtoNode)
+	if !ok {
+		return nil, dag.ErrNotProtobuf
+	}
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if appendData {
+		data = append(pbnd.Data(), data...)
+	}
+	pbnd.SetData(data)
+
+	_, err = api.node.DAG.Add(pbnd)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseCid(pbnd.Cid()), nil
+}
+
+func (api *ObjectAPI) core() coreiface.CoreAPI {
+	return api.CoreAPI
+}
+
+func nodeEmpty(node *Node) bool {
+	return (node.Data == "" && len(node.Links) == 0)
+}
+
+// converts the Node object into a real dag.ProtoNode
+func deserializeNode(nd *Node, dataFieldEncoding string) (*dag.ProtoNode, error) {
+	dagnode := new(dag.ProtoNode)
+	switch dataFieldEncoding {
+	case "text":
+		dagnode.SetData([]byte(nd.Data))
+	case "base64":
+		data, _ := base64.StdEncoding.DecodeString(nd.Data)
+		dagnode.SetData(data)
+	default:
+		return nil, fmt.Errorf("Unkown data field encoding")
+	}
+
+	links := make([]*node.Link, len(nd.Links))
+	for i, link := range nd.Links {
+		c, err := cid.Decode(link.Hash)
+		if err != nil {
+			return nil, err
+		}
+		links[i] = &node.Link{
+			Name: link.Name,
+			Size: link.Size,
+			Cid:  c,
+		}
+	}
+	dagnode.SetLinks(links)
+
+	return dagnode, nil
+}
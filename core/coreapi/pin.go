@@ -0,0 +1,293 @@
+package coreapi
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	coreiface "github.com/ipfs/go-ipfs/core/coreapi/interface"
+	caopts "github.com/ipfs/go-ipfs/core/coreapi/interface/options"
+	merkledag "github.com/ipfs/go-ipfs/merkledag"
+	pin "github.com/ipfs/go-ipfs/pin"
+
+	cid "gx/ipfs/QmeSrf6pzut73u6zLQkRFQ3ygt3k6XFT2kjdYP8Tnkwwyg/go-cid"
+)
+
+type PinAPI struct {
+	*CoreAPI
+	*caopts.PinOptions
+}
+
+func (api *PinAPI) Add(ctx context.Context, p coreiface.Path, opts ...caopts.PinAddOption) error {
+	settings, err := caopts.PinAddOptions(opts...)
+	if err != nil {
+		return err
+	}
+
+	defer api.node.Blockstore.PinLock().Unlock()
+
+	dagNode, err := api.core().ResolveNode(ctx, p)
+	if err != nil {
+		return fmt.Errorf("pin: %s", err)
+	}
+
+	err = api.node.Pinning.Pin(ctx, dagNode, settings.Recursive)
+	if err != nil {
+		return fmt.Errorf("pin: %s", err)
+	}
+
+	// a plain re-pin drops any expiry, name and label updates merge into
+	// the metadata already stored for the pin
+	meta, ok := api.node.Pinning.Metadata(dagNode.Cid())
+	if !ok {
+		meta = &pin.Metadata{}
+	}
+	if settings.Name == "" && len(settings.Labels) == 0 {
+		meta.Expires = 0
+	}
+	if settings.Name != "" {
+		meta.Name = settings.Name
+	}
+	if len(settings.Labels) > 0 {
+		labels := make(map[string]string, len(meta.Labels)+len(settings.Labels))
+		for k, v := range meta.Labels {
+			labels[k] = v
+		}
+		for k, v := range settings.Labels {
+			labels[k] = v
+		}
+		meta.Labels = labels
+	}
+	if settings.TTL > 0 {
+		meta.Expires = time.Now().Add(settings.TTL).Unix()
+	}
+	if err := api.node.Pinning.SetMetadata(dagNode.Cid(), meta); err != nil {
+		return fmt.Errorf("pin: %s", err)
+	}
+
+	return api.node.Pinning.Flush()
+}
+
+func (api *PinAPI) Ls(ctx context.Context, opts ...caopts.PinLsOption) ([]coreiface.Pin, error) {
+	settings, err := caopts.PinLsOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	switch settings.Type {
+	case "all", "direct", "indirect", "recursive":
+	default:
+		return nil, fmt.Errorf("invalid type '%s', must be one of {direct, indirect, recursive, all}", settings.Type)
+	}
+
+	return api.pinLsAll(ctx, settings)
+}
+
+func (api *PinAPI) Rm(ctx context.Context, p coreiface.Path, opts ...caopts.PinRmOption) error {
+	settings, err := caopts.PinRmOptions(opts...)
+	if err != nil {
+		return err
+	}
+
+	rp, err := api.core().ResolvePath(ctx, p)
+	if err != nil {
+		return err
+	}
+
+	err = api.node.Pinning.Unpin(ctx, rp.Cid(), settings.Recursive)
+	if err != nil {
+		return err
+	}
+
+	return api.node.Pinning.Flush()
+}
+
+func (api *PinAPI) Update(ctx context.Context, from coreiface.Path, to coreiface.Path, opts ...caopts.PinUpdateOption) error {
+	settings, err := caopts.PinUpdateOptions(opts...)
+	if err != nil {
+		return err
+	}
+
+	fp, err := api.core().ResolvePath(ctx, from)
+	if err != nil {
+		return err
+	}
+
+	tp, err := api.core().ResolvePath(ctx, to)
+	if err != nil {
+		return err
+	}
+
+	defer api.node.Blockstore.PinLock().Unlock()
+
+	err = api.node.Pinning.Update(ctx, fp.Cid(), tp.Cid(), settings.Unpin)
+	if err != nil {
+		return err
+	}
+
+	return api.node.Pinning.Flush()
+}
+
+// pinStatus implements coreiface.PinStatus
+type pinStatus struct {
+	cid      *cid.Cid
+	ok       bool
+	badNodes []coreiface.BadPinNode
+}
+
+// badNode implements coreiface.BadPinNode
+type badNode struct {
+	cid *cid.Cid
+	err error
+}
+
+func (s *pinStatus) Path() coreiface.Path {
+	return ParseCid(s.cid)
+}
+
+func (s *pinStatus) Ok() bool {
+	return s.ok
+}
+
+func (s *pinStatus) BadNodes() []coreiface.BadPinNode {
+	return s.badNodes
+}
+
+func (n *badNode) Path() coreiface.Path {
+	return ParseCid(n.cid)
+}
+
+func (n *badNode) Err() error {
+	return n.err
+}
+
+func (api *PinAPI) Verify(ctx context.Context) (<-chan coreiface.PinStatus, error) {
+	visited := make(map[string]*pinStatus)
+	getLinks := api.node.DAG.GetOfflineLinkService().GetLinks
+	recPins := api.node.Pinning.RecursiveKeys()
+
+	var checkPin func(root *cid.Cid) *pinStatus
+	checkPin = func(root *cid.Cid) *pinStatus {
+		key := root.String()
+		if status, ok := visited[key]; ok {
+			return status
+		}
+
+		links, err := getLinks(ctx, root)
+		if err != nil {
+			status := &pinStatus{ok: false, cid: root}
+			status.badNodes = []coreiface.BadPinNode{&badNode{cid: root, err: err}}
+			visited[key] = status
+			return status
+		}
+
+		status := &pinStatus{ok: true, cid: root}
+		for _, lnk := range links {
+			res := checkPin(lnk.Cid)
+			if !res.ok {
+				status.ok = false
+				status.badNodes = append(status.badNodes, res.badNodes...)
+			}
+		}
+
+		visited[key] = status
+		return status
+	}
+
+	out := make(chan coreiface.PinStatus)
+	go func() {
+		defer close(out)
+		for _, c := range recPins {
+			select {
+			case out <- checkPin(c):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// pinInfo implements coreiface.Pin
+type pinInfo struct {
+	pinType string
+	path    coreiface.Path
+	meta    *pin.Metadata
+}
+
+func (p *pinInfo) Path() coreiface.Path {
+	return p.path
+}
+
+func (p *pinInfo) Type() string {
+	return p.pinType
+}
+
+func (p *pinInfo) Name() string {
+	if p.meta == nil {
+		return ""
+	}
+	return p.meta.Name
+}
+
+func (p *pinInfo) Labels() map[string]string {
+	if p.meta == nil {
+		return nil
+	}
+	return p.meta.Labels
+}
+
+func (p *pinInfo) Expires() time.Time {
+	return p.meta.ExpiresAt()
+}
+
+func (api *PinAPI) pinLsAll(ctx context.Context, settings *caopts.PinLsSettings) ([]coreiface.Pin, error) {
+	typeStr := settings.Type
+	filtered := settings.Name != "" || len(settings.Labels) > 0
+	keys := make(map[string]*pinInfo)
+
+	AddToResultKeys := func(keyList []*cid.Cid, typeStr string) {
+		for _, c := range keyList {
+			meta, _ := api.node.Pinning.Metadata(c)
+			if filtered && (meta == nil || !meta.Matches(settings.Name, settings.Labels)) {
+				continue
+			}
+
+			keys[c.String()] = &pinInfo{
+				pinType: typeStr,
+				path:    ParseCid(c),
+				meta:    meta,
+			}
+		}
+	}
+
+	if typeStr == "direct" || typeStr == "all" {
+		AddToResultKeys(api.node.Pinning.DirectKeys(), "direct")
+	}
+	// metadata only exists on direct and recursive pins
+	if (typeStr == "indirect" || typeStr == "all") && !filtered {
+		set := cid.NewSet()
+		for _, k := range api.node.Pinning.RecursiveKeys() {
+			err := merkledag.EnumerateChildren(ctx, api.node.DAG.GetLinks, k, set.Visit)
+			if err != nil {
+				return nil, err
+			}
+		}
+		AddToResultKeys(set.Keys(), "indirect")
+	}
+	if typeStr == "recursive" || typeStr == "all" {
+		AddToResultKeys(api.node.Pinning.RecursiveKeys(), "recursive")
+	}
+
+	out := make([]coreiface.Pin, 0, len(keys))
+	for _, v := range keys {
+		out = append(out, v)
+	}
+
+	return out, nil
+}
+
+func (api *PinAPI) core() coreiface.CoreAPI {
+	return api.CoreAPI
+}
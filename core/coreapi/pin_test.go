@@ -0,0 +1,328 @@
+package coreapi_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPinAdd(t *testing.T) {
+	ctx := context.Background()
+	_, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	p, err := api.Unixfs().Add(ctx, strings.NewReader("foo"))
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = api.Pin().Add(ctx, p)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPinSimple(t *testing.T) {
+	ctx := context.Background()
+	_, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	p, err := api.Unixfs().Add(ctx, strings.NewReader("foo"))
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = api.Pin().Add(ctx, p)
+	if err != nil {
+		t.Error(err)
+	}
+
+	list, err := api.Pin().Ls(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(list) != 1 {
+		t.Errorf("unexpected pin list len: %d", len(list))
+	}
+
+	if list[0].Path().Cid().String() != p.Cid().String() {
+		t.Error("paths don't match")
+	}
+
+	if list[0].Type() != "recursive" {
+		t.Error("unexpected pin type")
+	}
+
+	err = api.Pin().Rm(ctx, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list, err = api.Pin().Ls(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(list) != 0 {
+		t.Errorf("unexpected pin list len: %d", len(list))
+	}
+}
+
+func TestPinDirect(t *testing.T) {
+	ctx := context.Background()
+	_, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	p, err := api.Unixfs().Add(ctx, strings.NewReader("foo"))
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = api.Pin().Add(ctx, p, api.Pin().WithRecursive(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := api.Pin().Ls(ctx, api.Pin().WithType("direct"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(list) != 1 {
+		t.Fatalf("unexpected pin list len: %d", len(list))
+	}
+
+	if list[0].Type() != "direct" {
+		t.Errorf("unexpected pin type: %s", list[0].Type())
+	}
+
+	list, err = api.Pin().Ls(ctx, api.Pin().WithType("recursive"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(list) != 0 {
+		t.Errorf("unexpected pin list len: %d", len(list))
+	}
+
+	_, err = api.Pin().Ls(ctx, api.Pin().WithType("foo"))
+	if err == nil {
+		t.Error("expected error for invalid pin type")
+	}
+
+	err = api.Pin().Rm(ctx, p, api.Pin().WithRmRecursive(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPinVerify(t *testing.T) {
+	ctx := context.Background()
+	_, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	p, err := api.Unixfs().Add(ctx, strings.NewReader("foo"))
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = api.Pin().Add(ctx, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statuses, err := api.Pin().Verify(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n := 0
+	for s := range statuses {
+		n++
+		if !s.Ok() {
+			t.Errorf("pin %s is broken", s.Path())
+		}
+	}
+
+	if n != 1 {
+		t.Errorf("unexpected number of verified pins: %d", n)
+	}
+}
+
+func TestPinMetadata(t *testing.T) {
+	ctx := context.Background()
+	_, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p1, err := api.Unixfs().Add(ctx, strings.NewReader("foo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p2, err := api.Unixfs().Add(ctx, strings.NewReader("bar"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = api.Pin().Add(ctx, p1, api.Pin().WithName("foo"), api.Pin().WithLabel("env", "prod"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = api.Pin().Add(ctx, p2, api.Pin().WithLabel("env", "dev"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := api.Pin().Ls(ctx, api.Pin().WithLabelFilter("env", "prod"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(list) != 1 {
+		t.Fatalf("unexpected pin list len: %d", len(list))
+	}
+
+	if list[0].Path().Cid().String() != p1.Cid().String() {
+		t.Error("paths don't match")
+	}
+
+	if list[0].Name() != "foo" || list[0].Labels()["env"] != "prod" {
+		t.Errorf("unexpected metadata: %s %v", list[0].Name(), list[0].Labels())
+	}
+
+	list, err = api.Pin().Ls(ctx, api.Pin().WithNameFilter("bar"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(list) != 0 {
+		t.Errorf("unexpected pin list len: %d", len(list))
+	}
+}
+
+func TestPinTTL(t *testing.T) {
+	ctx := context.Background()
+	_, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := api.Unixfs().Add(ctx, strings.NewReader("foo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	before := time.Now()
+	err = api.Pin().Add(ctx, p, api.Pin().WithTTL(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := api.Pin().Ls(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(list) != 1 {
+		t.Fatalf("unexpected pin list len: %d", len(list))
+	}
+
+	exp := list[0].Expires()
+	if exp.Before(before.Add(time.Hour).Truncate(time.Second)) || exp.After(time.Now().Add(time.Hour)) {
+		t.Errorf("unexpected expiry: %s", exp)
+	}
+}
+
+func TestPinRepinClearsTTL(t *testing.T) {
+	ctx := context.Background()
+	_, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := api.Unixfs().Add(ctx, strings.NewReader("foo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = api.Pin().Add(ctx, p, api.Pin().WithTTL(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = api.Pin().Add(ctx, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := api.Pin().Ls(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(list) != 1 {
+		t.Fatalf("unexpected pin list len: %d", len(list))
+	}
+
+	if !list[0].Expires().IsZero() {
+		t.Errorf("expected no expiry, got %s", list[0].Expires())
+	}
+}
+
+func TestPinMetadataMerge(t *testing.T) {
+	ctx := context.Background()
+	_, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := api.Unixfs().Add(ctx, strings.NewReader("foo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = api.Pin().Add(ctx, p, api.Pin().WithLabel("env", "prod"), api.Pin().WithTTL(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = api.Pin().Add(ctx, p, api.Pin().WithName("foo"), api.Pin().WithLabel("team", "web"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := api.Pin().Ls(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(list) != 1 {
+		t.Fatalf("unexpected pin list len: %d", len(list))
+	}
+
+	pin := list[0]
+	if pin.Name() != "foo" {
+		t.Errorf("unexpected name: %s", pin.Name())
+	}
+
+	if pin.Labels()["env"] != "prod" || pin.Labels()["team"] != "web" {
+		t.Errorf("unexpected labels: %v", pin.Labels())
+	}
+
+	if pin.Expires().IsZero() {
+		t.Error("expected the expiry to be kept")
+	}
+}
@@ -0,0 +1,163 @@
+package coreapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	coreiface "github.com/ipfs/go-ipfs/core/coreapi/interface"
+
+	inet "gx/ipfs/QmU4vCDZTPLDqSDKguWbHCiUe46mZUtmM2g2suBZ9NE8ko/go-libp2p-net"
+	swarm "gx/ipfs/QmUhvp4VoQ9cKDVLqAxciEKdm8ymBx2Syx4C1Tv6SmSTPa/go-libp2p-swarm"
+	ma "gx/ipfs/QmW8s4zTsUoX1Q6CeYxVKPyqSKbF7H1YDUyTostBtZ8DaG/go-multiaddr"
+	peer "gx/ipfs/QmWNY7dV54ZDYmTA1ykVdwNCqC11mpU4zSUp6XDpLTH9eG/go-libp2p-peer"
+	pstore "gx/ipfs/QmYijbtjCxFEjSXaudaQAUz3LN5VKLssm8WCUsRoqzXmQR/go-libp2p-peerstore"
+	protocol "gx/ipfs/QmZNkThpqfVXs9GNbexPrfBbXSLNYeKrE7jwFM2oqHbyqN/go-libp2p-protocol"
+	iaddr "gx/ipfs/QmdMeXVB1V1SAZcFzoCuM3zR9K8PeuzCYg4zXNHcHh6dHU/go-ipfs-addr"
+)
+
+// ErrConnNotFound is returned by SwarmAPI.Disconnect when there is no open
+// connection matching the given address
+var ErrConnNotFound = errors.New("conn not found")
+
+type SwarmAPI struct {
+	*CoreAPI
+}
+
+// connInfo implements coreiface.ConnectionInfo
+type connInfo struct {
+	api  *CoreAPI
+	conn inet.Conn
+
+	addr ma.Multiaddr
+	peer peer.ID
+}
+
+func (api *SwarmAPI) Connect(ctx context.Context, pi pstore.PeerInfo) error {
+	if api.node.PeerHost == nil {
+		return coreiface.ErrOffline
+	}
+
+	if swrm, ok := api.node.PeerHost.Network().(*swarm.Network); ok {
+		swrm.Swarm().Backoff().Clear(pi.ID)
+	}
+
+	return api.node.PeerHost.Connect(ctx, pi)
+}
+
+func (api *SwarmAPI) Disconnect(ctx context.Context, addr ma.Multiaddr) error {
+	if api.node.PeerHost == nil {
+		return coreiface.ErrOffline
+	}
+
+	ia, err := iaddr.ParseMultiaddr(addr)
+	if err != nil {
+		return err
+	}
+
+	taddr := ia.Transport()
+
+	conns := api.node.PeerHost.Network().ConnsToPeer(ia.ID())
+	for _, conn := range conns {
+		if !conn.RemoteMultiaddr().Equal(taddr) {
+			continue
+		}
+
+		return conn.Close()
+	}
+
+	return ErrConnNotFound
+}
+
+func (api *SwarmAPI) KnownAddrs(context.Context) (map[peer.ID][]ma.Multiaddr, error) {
+	if api.node.PeerHost == nil {
+		return nil, coreiface.ErrOffline
+	}
+
+	addrs := make(map[peer.ID][]ma.Multiaddr)
+	ps := api.node.PeerHost.Network().Peerstore()
+	for _, p := range ps.Peers() {
+		addrs[p] = append(addrs[p], ps.Addrs(p)...)
+		sort.Slice(addrs[p], func(i, j int) bool {
+			return addrs[p][i].String() < addrs[p][j].String()
+		})
+	}
+
+	return addrs, nil
+}
+
+func (api *SwarmAPI) LocalAddrs(context.Context) ([]ma.Multiaddr, error) {
+	if api.node.PeerHost == nil {
+		return nil, coreiface.ErrOffline
+	}
+
+	return api.node.PeerHost.Addrs(), nil
+}
+
+func (api *SwarmAPI) ListenAddrs(context.Context) ([]ma.Multiaddr, error) {
+	if api.node.PeerHost == nil {
+		return nil, coreiface.ErrOffline
+	}
+
+	return api.node.PeerHost.Network().InterfaceListenAddresses()
+}
+
+func (api *SwarmAPI) Peers(context.Context) ([]coreiface.ConnectionInfo, error) {
+	if api.node.PeerHost == nil {
+		return nil, coreiface.ErrOffline
+	}
+
+	conns := api.node.PeerHost.Network().Conns()
+
+	var out []coreiface.ConnectionInfo
+	for _, c := range conns {
+		ci := &connInfo{
+			api:  api.CoreAPI,
+			conn: c,
+
+			addr: c.RemoteMultiaddr(),
+			peer: c.RemotePeer(),
+		}
+
+		out = append(out, ci)
+	}
+
+	return out, nil
+}
+
+func (ci *connInfo) ID() peer.ID {
+	return ci.peer
+}
+
+func (ci *connInfo) Address() ma.Multiaddr {
+	return ci.addr
+}
+
+func (ci *connInfo) Latency() (time.Duration, error) {
+	return ci.api.node.Peerstore.LatencyEWMA(ci.ID()), nil
+}
+
+func (ci *connInfo) Streams() ([]protocol.ID, error) {
+	streams, err := ci.conn.GetStreams()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]protocol.ID, len(streams))
+	for i, s := range streams {
+		out[i] = s.Protocol()
+	}
+
+	return out, nil
+}
+
+// Muxer returns the name of stream muxer used by the connection, if known
+func (ci *connInfo) Muxer() string {
+	swcon, ok := ci.conn.(*swarm.Conn)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("%T", swcon.StreamConn().Conn())
+}
@@ -0,0 +1,61 @@
+package coreapi_test
+
+import (
+	"context"
+	"testing"
+
+	pstore "gx/ipfs/QmYijbtjCxFEjSXaudaQAUz3LN5VKLssm8WCUsRoqzXmQR/go-libp2p-peerstore"
+)
+
+func TestSwarmConnect(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nds, apis, err := makeAPISwarm(ctx, true, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = apis[0].Swarm().Connect(ctx, pstore.PeerInfo{
+		ID:    nds[1].Identity,
+		Addrs: nds[1].PeerHost.Addrs(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	peers, err := apis[0].Swarm().Peers(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(peers) != 1 {
+		t.Fatalf("unexpected number of peers: %d", len(peers))
+	}
+
+	if peers[0].ID() != nds[1].Identity {
+		t.Errorf("unexpected peer: %s", peers[0].ID())
+	}
+
+	addrs, err := apis[0].Swarm().KnownAddrs(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(addrs[nds[1].Identity]) == 0 {
+		t.Error("expected to know addresses of the connected peer")
+	}
+}
+
+func TestSwarmOffline(t *testing.T) {
+	ctx := context.Background()
+	_, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = api.Swarm().Peers(ctx)
+	if err == nil {
+		t.Error("expected an error on offline node")
+	}
+}
@@ -13,6 +13,7 @@ import (
 	coreapi "github.com/ipfs/go-ipfs/core/coreapi"
 	coreiface "github.com/ipfs/go-ipfs/core/coreapi/interface"
 	coreunix "github.com/ipfs/go-ipfs/core/coreunix"
+	mock "github.com/ipfs/go-ipfs/core/mock"
 	keystore "github.com/ipfs/go-ipfs/keystore"
 	mdag "github.com/ipfs/go-ipfs/merkledag"
 	repo "github.com/ipfs/go-ipfs/repo"
@@ -21,6 +22,7 @@ import (
 	unixfs "github.com/ipfs/go-ipfs/unixfs"
 
 	peer "gx/ipfs/QmWNY7dV54ZDYmTA1ykVdwNCqC11mpU4zSUp6XDpLTH9eG/go-libp2p-peer"
+	mocknet "gx/ipfs/Qma23bpHwQrQyvKeBemaeJh7sAoRHggPkgnge1B9489ff5/go-libp2p/p2p/net/mock"
 	ci "gx/ipfs/QmaPbCnUMBohSGo3KnxEa2bHqyJVVeEEcwtqJAYxerieBo/go-libp2p-crypto"
 	cbor "gx/ipfs/QmeZv9VXw2SfVbX55LV6kGTWASKBc9ZxAVqGBeJcDGdoXy/go-ipld-cbor"
 )
@@ -35,47 +37,82 @@ var emptyDir = coreapi.ResolvedPath("/ipfs/QmUNLLsPACCz1vLxQVkXqqLX5R1X345qqfHbs
 // `echo -n | ipfs add`
 var emptyFile = coreapi.ResolvedPath("/ipfs/QmbFMke1KXqnYyBBWxB74N4c5SBnJMVAiMNRcGu6x1AwQH", nil, nil)
 
-func makeAPIIdent(ctx context.Context, fullIdentity bool) (*core.IpfsNode, coreiface.CoreAPI, error) {
-	var ident config.Identity
-	if fullIdentity {
-		sk, pk, err := ci.GenerateKeyPair(ci.RSA, 512)
-		if err != nil {
-			return nil, nil, err
+func makeAPISwarm(ctx context.Context, fullIdentity bool, n int) ([]*core.IpfsNode, []coreiface.CoreAPI, error) {
+	mn := mocknet.New(ctx)
+
+	nodes := make([]*core.IpfsNode, n)
+	apis := make([]coreiface.CoreAPI, n)
+
+	for i := 0; i < n; i++ {
+		var ident config.Identity
+		if fullIdentity {
+			sk, pk, err := ci.GenerateKeyPair(ci.RSA, 512)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			id, err := peer.IDFromPublicKey(pk)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			kbytes, err := sk.Bytes()
+			if err != nil {
+				return nil, nil, err
+			}
+
+			ident = config.Identity{
+				PeerID:  id.Pretty(),
+				PrivKey: base64.StdEncoding.EncodeToString(kbytes),
+			}
+		} else {
+			ident = config.Identity{
+				PeerID: "Qmfoo",
+			}
 		}
 
-		id, err := peer.IDFromPublicKey(pk)
-		if err != nil {
-			return nil, nil, err
+		r := &repo.Mock{
+			C: config.Config{
+				Identity: ident,
+			},
+			D: ds2.ThreadSafeCloserMapDatastore(),
+			K: keystore.NewMemKeystore(),
 		}
 
-		kbytes, err := sk.Bytes()
-		if err != nil {
-			return nil, nil, err
+		// when more than one node is requested, nodes are started online and
+		// connected to the mock network
+		cfg := &core.BuildCfg{Repo: r}
+		if n > 1 {
+			cfg.Online = true
+			cfg.Host = mock.MockHostOption(mn)
+			cfg.ExtraOpts = map[string]bool{
+				"pubsub": true,
+			}
 		}
 
-		ident = config.Identity{
-			PeerID:  id.Pretty(),
-			PrivKey: base64.StdEncoding.EncodeToString(kbytes),
-		}
-	} else {
-		ident = config.Identity{
-			PeerID: "Qmfoo",
+		node, err := core.NewNode(ctx, cfg)
+		if err != nil {
+			return nil, nil, err
 		}
+		nodes[i] = node
+		apis[i] = coreapi.NewCoreAPI(node)
 	}
 
-	r := &repo.Mock{
-		C: config.Config{
-			Identity: ident,
-		},
-		D: ds2.ThreadSafeCloserMapDatastore(),
-		K: keystore.NewMemKeystore(),
+	err := mn.LinkAll()
+	if err != nil {
+		return nil, nil, err
 	}
-	node, err := core.NewNode(ctx, &core.BuildCfg{Repo: r})
+
+	return nodes, apis, nil
+}
+
+func makeAPIIdent(ctx context.Context, fullIdentity bool) (*core.IpfsNode, coreiface.CoreAPI, error) {
+	nodes, apis, err := makeAPISwarm(ctx, fullIdentity, 1)
 	if err != nil {
 		return nil, nil, err
 	}
-	api := coreapi.NewCoreAPI(node)
-	return node, api, nil
+
+	return nodes[0], apis[0], nil
 }
 
 func makeAPI(ctx context.Context) (*core.IpfsNode, coreiface.CoreAPI, error) {
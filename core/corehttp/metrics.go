@@ -30,6 +30,19 @@ var (
 	peersTotalMetric = prometheus.NewDesc(
 		prometheus.BuildFQName("ipfs", "p2p", "peers_total"),
 		"Number of connected peers", []string{"transport"}, nil)
+
+	reprovidedKeysMetric = prometheus.NewDesc(
+		prometheus.BuildFQName("ipfs", "reprovider", "provided_keys_total"),
+		"Number of keys announced by the reprovider", nil, nil)
+	reprovideFailuresMetric = prometheus.NewDesc(
+		prometheus.BuildFQName("ipfs", "reprovider", "failed_keys_total"),
+		"Number of keys the reprovider failed to announce", nil, nil)
+	reprovideDurationMetric = prometheus.NewDesc(
+		prometheus.BuildFQName("ipfs", "reprovider", "last_run_duration_seconds"),
+		"Duration of the last reprovider run", nil, nil)
+	reprovideRunningMetric = prometheus.NewDesc(
+		prometheus.BuildFQName("ipfs", "reprovider", "running"),
+		"Whether the reprovider is running", nil, nil)
 )
 
 type IpfsNodeCollector struct {
@@ -38,6 +51,10 @@ type IpfsNodeCollector struct {
 
 func (_ IpfsNodeCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- peersTotalMetric
+	ch <- reprovidedKeysMetric
+	ch <- reprovideFailuresMetric
+	ch <- reprovideDurationMetric
+	ch <- reprovideRunningMetric
 }
 
 func (c IpfsNodeCollector) Collect(ch chan<- prometheus.Metric) {
@@ -49,6 +66,19 @@ func (c IpfsNodeCollector) Collect(ch chan<- prometheus.Metric) {
 			tr,
 		)
 	}
+
+	if c.Node.Reprovider == nil {
+		return
+	}
+	st := c.Node.Reprovider.Stat()
+	running := 0.0
+	if st.Running {
+		running = 1
+	}
+	ch <- prometheus.MustNewConstMetric(reprovidedKeysMetric, prometheus.CounterValue, float64(st.TotalProvided))
+	ch <- prometheus.MustNewConstMetric(reprovideFailuresMetric, prometheus.CounterValue, float64(st.TotalFailed))
+	ch <- prometheus.MustNewConstMetric(reprovideDurationMetric, prometheus.GaugeValue, st.LastRun.Duration().Seconds())
+	ch <- prometheus.MustNewConstMetric(reprovideRunningMetric, prometheus.GaugeValue, running)
 }
 
 func (c IpfsNodeCollector) PeersTotalValues() map[string]float64 {
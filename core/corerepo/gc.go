@@ -27,6 +27,11 @@ type GC struct {
 	StorageGC  uint64
 	SlackGB    uint64
 	Storage    uint64
+	Concurrent bool
+
+	// ConfirmDelay is how long a periodic GC waits after reporting what it
+	// would remove before re-checking the watermark and collecting.
+	ConfirmDelay time.Duration
 }
 
 func NewGC(n *core.IpfsNode) (*GC, error) {
@@ -52,6 +57,14 @@ func NewGC(n *core.IpfsNode) (*GC, error) {
 	if err != nil {
 		return nil, err
 	}
+
+	var confirmDelay time.Duration
+	if cfg.Datastore.GCConfirmDelay != "" {
+		confirmDelay, err = time.ParseDuration(cfg.Datastore.GCConfirmDelay)
+		if err != nil {
+			return nil, err
+		}
+	}
 	storageGC := storageMax * uint64(cfg.Datastore.StorageGCWatermark) / 100
 
 	// calculate the slack space between StorageMax and StorageGCWatermark
@@ -67,6 +80,9 @@ func NewGC(n *core.IpfsNode) (*GC, error) {
 		StorageMax: storageMax,
 		StorageGC:  storageGC,
 		SlackGB:    slackGB,
+		Concurrent: cfg.Datastore.ConcurrentGC,
+
+		ConfirmDelay: confirmDelay,
 	}, nil
 }
 
@@ -76,7 +92,17 @@ func BestEffortRoots(filesRoot *mfs.Root) ([]*cid.Cid, error) {
 		return nil, err
 	}
 
-	return []*cid.Cid{rootDag.Cid()}, nil
+	roots := []*cid.Cid{rootDag.Cid()}
+
+	// keep what open transactions staged
+	for _, tx := range filesRoot.Transactions() {
+		nd, err := tx.Root().GetValue().GetNode()
+		if err != nil {
+			return nil, err
+		}
+		roots = append(roots, nd.Cid())
+	}
+	return roots, nil
 }
 
 func GarbageCollect(n *core.IpfsNode, ctx context.Context) error {
@@ -95,6 +121,14 @@ func GarbageCollect(n *core.IpfsNode, ctx context.Context) error {
 // given callback for each object removed.  It also collects all errors into a
 // MultiError which is returned after the gc is completed.
 func CollectResult(ctx context.Context, gcOut <-chan gc.Result, cb func(*cid.Cid)) error {
+	return collectResults(ctx, gcOut, func(res gc.Result) {
+		if cb != nil {
+			cb(res.KeyRemoved)
+		}
+	})
+}
+
+func collectResults(ctx context.Context, gcOut <-chan gc.Result, cb func(gc.Result)) error {
 	var errors []error
 loop:
 	for {
@@ -105,8 +139,8 @@ loop:
 			}
 			if res.Error != nil {
 				errors = append(errors, res.Error)
-			} else if res.KeyRemoved != nil && cb != nil {
-				cb(res.KeyRemoved)
+			} else if res.KeyRemoved != nil {
+				cb(res)
 			}
 		case <-ctx.Done():
 			errors = append(errors, ctx.Err())
@@ -157,6 +191,57 @@ func GarbageCollectAsync(n *core.IpfsNode, ctx context.Context) <-chan gc.Result
 	return gc.GC(ctx, n.Blockstore, n.DAG, n.Pinning, roots)
 }
 
+// ConcurrentGarbageCollectAsync runs a garbage collection that lets adds
+// and pins proceed while it runs, see gc.ConcurrentGC.
+func ConcurrentGarbageCollectAsync(n *core.IpfsNode, ctx context.Context) <-chan gc.Result {
+	roots, err := BestEffortRoots(n.FilesRoot)
+	if err != nil {
+		out := make(chan gc.Result, 1)
+		out <- gc.Result{Error: err}
+		close(out)
+		return out
+	}
+
+	return gc.ConcurrentGC(ctx, n.Blockstore, n.DAG, n.Pinning, roots, gc.DefaultSweepBatchSize)
+}
+
+// DryRunGarbageCollectAsync reports the blocks a garbage collection would
+// remove, without removing them, see gc.DryRun.
+func DryRunGarbageCollectAsync(n *core.IpfsNode, ctx context.Context) <-chan gc.Result {
+	roots, err := BestEffortRoots(n.FilesRoot)
+	if err != nil {
+		out := make(chan gc.Result, 1)
+		out <- gc.Result{Error: err}
+		close(out)
+		return out
+	}
+
+	return gc.DryRun(ctx, n.Blockstore, n.DAG, n.Pinning, roots)
+}
+
+// ReclaimReport summarizes the output of a garbage collection dry run.
+type ReclaimReport struct {
+	Blocks uint64
+	Bytes  uint64
+}
+
+// CollectReclaimReport sums up the blocks and bytes reported by a dry run,
+// calling the given callback for each block.
+func CollectReclaimReport(ctx context.Context, gcOut <-chan gc.Result, cb func(k *cid.Cid, size uint64)) (*ReclaimReport, error) {
+	report := new(ReclaimReport)
+	err := collectResults(ctx, gcOut, func(res gc.Result) {
+		report.Blocks++
+		report.Bytes += res.Size
+		if cb != nil {
+			cb(res.KeyRemoved, res.Size)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
 func PeriodicGC(ctx context.Context, node *core.IpfsNode) error {
 	cfg, err := node.Repo.Config()
 	if err != nil {
@@ -213,14 +298,58 @@ func (gc *GC) maybeGC(ctx context.Context, offset uint64) error {
 			log.Warningf("pre-GC: %s", ErrMaxStorageExceeded)
 		}
 
+		if gc.ConfirmDelay > 0 {
+			confirmed, err := gc.confirm(ctx, offset)
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				return nil
+			}
+		}
+
 		// Do GC here
 		log.Info("Watermark exceeded. Starting repo GC...")
 		defer log.EventBegin(ctx, "repoGC").Done()
 
-		if err := GarbageCollect(gc.Node, ctx); err != nil {
+		if gc.Concurrent {
+			rmed := ConcurrentGarbageCollectAsync(gc.Node, ctx)
+			if err := CollectResult(ctx, rmed, nil); err != nil {
+				return err
+			}
+		} else if err := GarbageCollect(gc.Node, ctx); err != nil {
 			return err
 		}
 		log.Infof("Repo GC done. See `ipfs repo stat` to see how much space got freed.\n")
 	}
 	return nil
 }
+
+// confirm logs what a GC would reclaim, waits for ConfirmDelay and then
+// checks again that the watermark is still exceeded, giving operators time
+// to react before anything is deleted.
+func (gc *GC) confirm(ctx context.Context, offset uint64) (bool, error) {
+	report, err := CollectReclaimReport(ctx, DryRunGarbageCollectAsync(gc.Node, ctx), nil)
+	if err != nil {
+		return false, err
+	}
+
+	log.Infof("Watermark exceeded. Repo GC would remove %d blocks (%s), starting in %s...",
+		report.Blocks, humanize.Bytes(report.Bytes), gc.ConfirmDelay)
+
+	select {
+	case <-ctx.Done():
+		return false, nil
+	case <-time.After(gc.ConfirmDelay):
+	}
+
+	storage, err := gc.Repo.GetStorageUsage()
+	if err != nil {
+		return false, err
+	}
+	if storage+offset <= gc.StorageGC {
+		log.Info("Watermark no longer exceeded, skipping repo GC")
+		return false, nil
+	}
+	return true, nil
+}
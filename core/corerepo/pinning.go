@@ -16,9 +16,11 @@ package corerepo
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/ipfs/go-ipfs/core"
 	path "github.com/ipfs/go-ipfs/path"
+	pin "github.com/ipfs/go-ipfs/pin"
 	uio "github.com/ipfs/go-ipfs/unixfs/io"
 
 	cid "gx/ipfs/QmeSrf6pzut73u6zLQkRFQ3ygt3k6XFT2kjdYP8Tnkwwyg/go-cid"
@@ -89,3 +91,83 @@ func Unpin(n *core.IpfsNode, ctx context.Context, paths []string, recursive bool
 	}
 	return unpinned, nil
 }
+
+// UnpinExpired removes all pins whose expiry has passed and returns the
+// cids that were unpinned. Pins that cannot be removed are logged and
+// skipped, and removed again by the next call.
+func UnpinExpired(n *core.IpfsNode, ctx context.Context) ([]*cid.Cid, error) {
+	defer n.Blockstore.PinLock().Unlock()
+
+	expired := n.Pinning.ExpiredPins(time.Now())
+	if len(expired) == 0 {
+		return nil, nil
+	}
+
+	var unpinned []*cid.Cid
+	for _, k := range expired {
+		// recursive also removes direct pins
+		err := n.Pinning.Unpin(ctx, k, true)
+		switch err {
+		case nil:
+			unpinned = append(unpinned, k)
+		case pin.ErrNotPinned:
+			// already unpinned, only its metadata is left
+			if err := n.Pinning.SetMetadata(k, nil); err != nil {
+				log.Errorf("removing the metadata of expired pin %s: %s", k, err)
+			}
+		default:
+			log.Errorf("removing expired pin %s: %s", k, err)
+		}
+	}
+
+	if err := n.Pinning.Flush(); err != nil {
+		return nil, err
+	}
+	return unpinned, nil
+}
+
+// PeriodicUnpinExpired removes expired pins every Pinning.ExpiryInterval
+// until the context is cancelled, running a garbage collection afterwards
+// if Pinning.GCOnExpiry is set.
+func PeriodicUnpinExpired(ctx context.Context, node *core.IpfsNode) error {
+	cfg, err := node.Repo.Config()
+	if err != nil {
+		return err
+	}
+
+	if cfg.Pinning.ExpiryInterval == "" {
+		cfg.Pinning.ExpiryInterval = "10m"
+	}
+
+	period, err := time.ParseDuration(cfg.Pinning.ExpiryInterval)
+	if err != nil {
+		return err
+	}
+	if int64(period) == 0 {
+		// if duration is 0, it means expiry is disabled.
+		return nil
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(period):
+			unpinned, err := UnpinExpired(node, ctx)
+			if err != nil {
+				log.Error(err)
+				continue
+			}
+			if len(unpinned) == 0 {
+				continue
+			}
+			log.Infof("removed %d expired pins", len(unpinned))
+
+			if cfg.Pinning.GCOnExpiry {
+				if err := GarbageCollect(node, ctx); err != nil {
+					log.Error(err)
+				}
+			}
+		}
+	}
+}
@@ -8,12 +8,14 @@ import (
 	"os"
 	gopath "path"
 	"strconv"
+	"time"
 
 	bs "github.com/ipfs/go-ipfs/blocks/blockstore"
 	bstore "github.com/ipfs/go-ipfs/blocks/blockstore"
 	bserv "github.com/ipfs/go-ipfs/blockservice"
 	core "github.com/ipfs/go-ipfs/core"
 	"github.com/ipfs/go-ipfs/exchange/offline"
+	reprovide "github.com/ipfs/go-ipfs/exchange/reprovide"
 	balanced "github.com/ipfs/go-ipfs/importer/balanced"
 	"github.com/ipfs/go-ipfs/importer/chunk"
 	ihelper "github.com/ipfs/go-ipfs/importer/helpers"
@@ -110,6 +112,17 @@ type Adder struct {
 	tempRoot   *cid.Cid
 	Prefix     *cid.Prefix
 	liveNodes  uint64
+
+	// ProvideQueue, if set, is where the added content is queued to be
+	// announced, every block of it, so that the announcements survive a
+	// restart.
+	ProvideQueue *reprovide.Queue
+
+	// PreserveMode and PreserveMtime store the permission bits and the
+	// modification time of added files and directories, when they are
+	// known.
+	PreserveMode  bool
+	PreserveMtime bool
 }
 
 func (adder *Adder) mfsRoot() (*mfs.Root, error) {
@@ -258,7 +271,40 @@ func (adder *Adder) Finalize() (node.Node, error) {
 		return nil, err
 	}
 
-	return root.GetNode()
+	nd, err := root.GetNode()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := adder.provide(nd.Cid()); err != nil {
+		return nil, err
+	}
+
+	return nd, nil
+}
+
+// provide queues root and all the blocks below it to be announced, if the
+// adder has a ProvideQueue.
+func (adder *Adder) provide(root *cid.Cid) error {
+	if adder.ProvideQueue == nil {
+		return nil
+	}
+
+	keys := []*cid.Cid{root}
+	seen := cid.NewSet()
+	getLinks := adder.dagService.GetOfflineLinkService().GetLinks
+	err := dag.EnumerateChildren(adder.ctx, getLinks, root, func(c *cid.Cid) bool {
+		if !seen.Visit(c) {
+			return false
+		}
+		keys = append(keys, c)
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	return adder.ProvideQueue.Enqueue(keys...)
 }
 
 func (adder *Adder) outputDirs(path string, fsn mfs.FSNode) error {
@@ -317,6 +363,11 @@ func AddWithContext(ctx context.Context, n *core.IpfsNode, r io.Reader) (string,
 		return "", err
 	}
 
+	fileAdder.ProvideQueue = n.ProvideQueue
+	if err := fileAdder.provide(node.Cid()); err != nil {
+		return "", err
+	}
+
 	return node.Cid().String(), nil
 }
 
@@ -339,6 +390,7 @@ func AddR(n *core.IpfsNode, root string) (key string, err error) {
 	if err != nil {
 		return "", err
 	}
+	fileAdder.ProvideQueue = n.ProvideQueue
 
 	err = fileAdder.addFile(f)
 	if err != nil {
@@ -364,6 +416,7 @@ func AddWrapped(n *core.IpfsNode, r io.Reader, filename string) (string, node.No
 		return "", nil, err
 	}
 	fileAdder.Wrap = true
+	fileAdder.ProvideQueue = n.ProvideQueue
 
 	defer n.Blockstore.PinLock().Unlock()
 
@@ -490,10 +543,55 @@ func (adder *Adder) addFile(file files.File) error {
 		return err
 	}
 
+	mode, mtime, err := adder.fileAttrs(file)
+	if err != nil {
+		return err
+	}
+	if mode != nil || !mtime.IsZero() {
+		if pi, ok := dagnode.(*posinfo.FilestoreNode); ok {
+			dagnode = pi.Node
+		}
+
+		dagnode, err = unixfs.WithAttrs(dagnode, mode, mtime)
+		if err != nil {
+			return err
+		}
+
+		_, err = adder.dagService.Add(dagnode)
+		if err != nil {
+			return err
+		}
+	}
+
 	// patch it into the root
 	return adder.addNode(dagnode, file.FileName())
 }
 
+// fileAttrs returns the attributes of file to store, as selected by
+// PreserveMode and PreserveMtime. They are only known for files read from
+// the local filesystem, and asking for them for other files is an error.
+func (adder *Adder) fileAttrs(file files.File) (*os.FileMode, time.Time, error) {
+	if !adder.PreserveMode && !adder.PreserveMtime {
+		return nil, time.Time{}, nil
+	}
+
+	fi, ok := file.(files.FileInfo)
+	if !ok || fi.Stat() == nil {
+		return nil, time.Time{}, fmt.Errorf("the mode and modification time of %s are not known, they can only be preserved when adding local files without a running daemon", file.FileName())
+	}
+
+	var mode *os.FileMode
+	var mtime time.Time
+	if adder.PreserveMode {
+		perm := fi.Stat().Mode() & os.ModePerm
+		mode = &perm
+	}
+	if adder.PreserveMtime {
+		mtime = fi.Stat().ModTime()
+	}
+	return mode, mtime, nil
+}
+
 func (adder *Adder) addDir(dir files.File) error {
 	log.Infof("adding directory: %s", dir.FileName())
 
@@ -530,6 +628,23 @@ func (adder *Adder) addDir(dir files.File) error {
 		}
 	}
 
+	mode, mtime, err := adder.fileAttrs(dir)
+	if err != nil {
+		return err
+	}
+	if mode != nil || !mtime.IsZero() {
+		fsn, err := mfs.Lookup(mr, dir.FileName())
+		if err != nil {
+			return err
+		}
+
+		mdir, ok := fsn.(*mfs.Directory)
+		if !ok {
+			return fmt.Errorf("%s is not a directory", dir.FileName())
+		}
+		return mdir.SetAttrs(mode, mtime)
+	}
+
 	return nil
 }
 
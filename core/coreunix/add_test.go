@@ -13,15 +13,21 @@ import (
 	"github.com/ipfs/go-ipfs/blocks/blockstore"
 	"github.com/ipfs/go-ipfs/blockservice"
 	"github.com/ipfs/go-ipfs/core"
+	reprovide "github.com/ipfs/go-ipfs/exchange/reprovide"
 	dag "github.com/ipfs/go-ipfs/merkledag"
 	"github.com/ipfs/go-ipfs/pin/gc"
 	"github.com/ipfs/go-ipfs/repo"
 	"github.com/ipfs/go-ipfs/repo/config"
+	mockrouting "github.com/ipfs/go-ipfs/routing/mock"
 	ds2 "github.com/ipfs/go-ipfs/thirdparty/datastore2"
 	pi "github.com/ipfs/go-ipfs/thirdparty/posinfo"
 	"gx/ipfs/QmYsEQydGrsxNZfAiskvQ76N2xE9hDQtSAkRSynwMiUK3c/go-block-format"
 
 	"gx/ipfs/QmQp2a2Hhb7F6eK2A5hN8f9aJy4mtkEikL9Zj4cgB7d1dD/go-ipfs-cmdkit/files"
+	pstore "gx/ipfs/QmYijbtjCxFEjSXaudaQAUz3LN5VKLssm8WCUsRoqzXmQR/go-libp2p-peerstore"
+	ds "gx/ipfs/QmdHG8MAuARdGHxx4rPQASLcvhz24fzjSQq7AJRAQEorq5/go-datastore"
+	dssync "gx/ipfs/QmdHG8MAuARdGHxx4rPQASLcvhz24fzjSQq7AJRAQEorq5/go-datastore/sync"
+	testutil "gx/ipfs/QmeDA8gNhvRTsbrjEieay5wezupJDiky8xvCzDABbsGzmp/go-testutil"
 	cid "gx/ipfs/QmeSrf6pzut73u6zLQkRFQ3ygt3k6XFT2kjdYP8Tnkwwyg/go-cid"
 )
 
@@ -45,6 +51,69 @@ func TestAddRecursive(t *testing.T) {
 	}
 }
 
+func TestAddProvidesAllBlocks(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r := &repo.Mock{
+		C: config.Config{
+			Identity: config.Identity{
+				PeerID: "Qmfoo", // required by offline node
+			},
+		},
+		D: ds2.ThreadSafeCloserMapDatastore(),
+	}
+	node, err := core.NewNode(ctx, &core.BuildCfg{Repo: r})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mrserv := mockrouting.NewServer()
+	id := testutil.RandIdentityOrFatal(t)
+	q, err := reprovide.NewQueue(ctx, mrserv.Client(id), dssync.MutexWrap(ds.NewMapDatastore()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	adder, err := NewAdder(ctx, node.Pinning, node.Blockstore, node.DAG)
+	if err != nil {
+		t.Fatal(err)
+	}
+	adder.ProvideQueue = q
+
+	rfa := files.NewReaderFile("dir/a", "dir/a", ioutil.NopCloser(bytes.NewBufferString("testfileA")), nil)
+	dir := files.NewSliceFile("dir", "dir", []files.File{rfa})
+	if err := adder.AddFile(dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := adder.Finalize(); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := adder.add(bytes.NewBufferString("testfileA"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go q.Run(1)
+	deadline := time.Now().Add(5 * time.Second)
+	for len(q.Entries()) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the provide queue")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	other := mrserv.Client(testutil.RandIdentityOrFatal(t))
+	var providers []pstore.PeerInfo
+	for p := range other.FindProvidersAsync(ctx, file.Cid(), 1) {
+		providers = append(providers, p)
+	}
+	if len(providers) != 1 || providers[0].ID != id.ID() {
+		t.Fatalf("expected the file of the added directory to be provided, got %v", providers)
+	}
+}
+
 func TestAddGCLive(t *testing.T) {
 	r := &repo.Mock{
 		C: config.Config{
@@ -0,0 +1,317 @@
+package coreunix
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	gopath "path"
+	"path/filepath"
+
+	balanced "github.com/ipfs/go-ipfs/importer/balanced"
+	"github.com/ipfs/go-ipfs/importer/chunk"
+	ihelper "github.com/ipfs/go-ipfs/importer/helpers"
+	trickle "github.com/ipfs/go-ipfs/importer/trickle"
+	dag "github.com/ipfs/go-ipfs/merkledag"
+	dagutils "github.com/ipfs/go-ipfs/merkledag/utils"
+	mfs "github.com/ipfs/go-ipfs/mfs"
+	unixfs "github.com/ipfs/go-ipfs/unixfs"
+
+	node "gx/ipfs/QmNwUEK7QbwSqyKBu3mMtToo8SUc6wQJ7gdZq4gGGJqfnf/go-ipld-format"
+	cid "gx/ipfs/QmeSrf6pzut73u6zLQkRFQ3ygt3k6XFT2kjdYP8Tnkwwyg/go-cid"
+)
+
+// Syncer makes a directory of an mfs root mirror a directory of the local
+// filesystem, only adding the files that changed since the last sync.
+//
+// Files are added with their mode and modification time, which tell later
+// syncs whether they changed.
+type Syncer struct {
+	ctx        context.Context
+	dagService dag.DAGService
+	root       *mfs.Root
+
+	Chunker   string
+	RawLeaves bool
+	Trickle   bool
+	Prefix    *cid.Prefix
+
+	// Checksum finds the files that changed by comparing their content
+	// rather than their size and modification time. All the files are
+	// read, but only the changed ones are updated.
+	Checksum bool
+}
+
+// NewSyncer returns a Syncer updating directories of root.
+func NewSyncer(ctx context.Context, ds dag.DAGService, root *mfs.Root) *Syncer {
+	return &Syncer{
+		ctx:        ctx,
+		dagService: ds,
+		root:       root,
+	}
+}
+
+// Sync makes the directory at mpath mirror the local directory, creating it
+// if needed, and returns the changes made. The paths of the changes are
+// relative to mpath. Changes are not flushed.
+func (s *Syncer) Sync(local, mpath string) ([]*dagutils.Change, error) {
+	fi, err := os.Stat(local)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", local)
+	}
+
+	fsn, err := mfs.Lookup(s.root, mpath)
+	if err == os.ErrNotExist {
+		err = mfs.Mkdir(s.root, mpath, mfs.MkdirOpts{Mkparents: true, Prefix: s.Prefix})
+		if err != nil {
+			return nil, err
+		}
+		fsn, err = mfs.Lookup(s.root, mpath)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	dir, ok := fsn.(*mfs.Directory)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a directory", mpath)
+	}
+
+	return s.syncDir(dir, local, "")
+}
+
+func (s *Syncer) syncDir(dir *mfs.Directory, local, rel string) ([]*dagutils.Change, error) {
+	infos, err := ioutil.ReadDir(local)
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := dir.ListNames(s.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []*dagutils.Change
+	seen := make(map[string]bool, len(infos))
+	for _, fi := range infos {
+		seen[fi.Name()] = true
+
+		c, err := s.syncEntry(dir, filepath.Join(local, fi.Name()), gopath.Join(rel, fi.Name()), fi)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, c...)
+	}
+
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+
+		child, err := dir.Child(name)
+		if err != nil {
+			return nil, err
+		}
+		c, err := s.remove(dir, name, gopath.Join(rel, name), child)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+
+	return changes, nil
+}
+
+func (s *Syncer) syncEntry(dir *mfs.Directory, lpath, rel string, fi os.FileInfo) ([]*dagutils.Change, error) {
+	name := fi.Name()
+	existing, err := dir.Child(name)
+	switch {
+	case err == os.ErrNotExist:
+		existing = nil
+	case err != nil:
+		return nil, err
+	}
+
+	switch {
+	case fi.IsDir():
+		var changes []*dagutils.Change
+		sub, ok := existing.(*mfs.Directory)
+		if !ok {
+			if existing != nil {
+				c, err := s.remove(dir, name, rel, existing)
+				if err != nil {
+					return nil, err
+				}
+				changes = append(changes, c)
+			}
+
+			sub, err = dir.Mkdir(name)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		subchanges, err := s.syncDir(sub, lpath, rel)
+		if err != nil {
+			return nil, err
+		}
+		return append(changes, subchanges...), nil
+	case fi.Mode()&os.ModeSymlink != 0:
+		target, err := os.Readlink(lpath)
+		if err != nil {
+			return nil, err
+		}
+		sdata, err := unixfs.SymlinkData(target)
+		if err != nil {
+			return nil, err
+		}
+
+		nd := dag.NodeWithData(sdata)
+		nd.SetPrefix(s.Prefix)
+		return s.put(dir, name, rel, existing, nd)
+	case fi.Mode().IsRegular():
+		if f, ok := existing.(*mfs.File); ok && !s.Checksum {
+			c, same, err := s.updateAttrs(f, rel, fi)
+			if err != nil {
+				return nil, err
+			}
+			if same {
+				return c, nil
+			}
+		}
+
+		nd, err := s.addFile(lpath, fi)
+		if err != nil {
+			return nil, err
+		}
+		return s.put(dir, name, rel, existing, nd)
+	default:
+		log.Infof("skipping %s, which is not a regular file, directory or symlink", lpath)
+		return nil, nil
+	}
+}
+
+// updateAttrs reports whether f has the same size and modification time as
+// the local file described by fi. If it does, but its mode changed, its mode
+// is updated.
+func (s *Syncer) updateAttrs(f *mfs.File, rel string, fi os.FileInfo) ([]*dagutils.Change, bool, error) {
+	nd, err := f.GetNode()
+	if err != nil {
+		return nil, false, err
+	}
+	size, err := f.Size()
+	if err != nil {
+		return nil, false, err
+	}
+	mode, mtime, err := unixfs.Attrs(nd)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if size != fi.Size() || !mtime.Equal(fi.ModTime()) {
+		return nil, false, nil
+	}
+	perm := fi.Mode().Perm()
+	if mode != nil && *mode == perm {
+		return nil, true, nil
+	}
+
+	if err := f.SetAttrs(&perm, mtime); err != nil {
+		return nil, false, err
+	}
+	after, err := f.GetNode()
+	if err != nil {
+		return nil, false, err
+	}
+
+	return []*dagutils.Change{{
+		Type:   dagutils.Mod,
+		Path:   rel,
+		Before: nd.Cid(),
+		After:  after.Cid(),
+	}}, true, nil
+}
+
+// addFile adds the content of the local file at lpath, with its mode and
+// modification time.
+func (s *Syncer) addFile(lpath string, fi os.FileInfo) (node.Node, error) {
+	f, err := os.Open(lpath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	chnk, err := chunk.FromString(f, s.Chunker)
+	if err != nil {
+		return nil, err
+	}
+
+	params := ihelper.DagBuilderParams{
+		Dagserv:   s.dagService,
+		RawLeaves: s.RawLeaves,
+		Maxlinks:  ihelper.DefaultLinksPerBlock,
+		Prefix:    s.Prefix,
+	}
+
+	var nd node.Node
+	if s.Trickle {
+		nd, err = trickle.TrickleLayout(params.New(chnk))
+	} else {
+		nd, err = balanced.BalancedLayout(params.New(chnk))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	perm := fi.Mode().Perm()
+	nd, err = unixfs.WithAttrs(nd, &perm, fi.ModTime())
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.dagService.Add(nd)
+	if err != nil {
+		return nil, err
+	}
+	return nd, nil
+}
+
+// put links nd as name in dir, replacing existing if it is different.
+func (s *Syncer) put(dir *mfs.Directory, name, rel string, existing mfs.FSNode, nd node.Node) ([]*dagutils.Change, error) {
+	if existing == nil {
+		if err := dir.AddChild(name, nd); err != nil {
+			return nil, err
+		}
+		return []*dagutils.Change{{Type: dagutils.Add, Path: rel, After: nd.Cid()}}, nil
+	}
+
+	old, err := existing.GetNode()
+	if err != nil {
+		return nil, err
+	}
+	if old.Cid().Equals(nd.Cid()) {
+		return nil, nil
+	}
+
+	if err := dir.Unlink(name); err != nil {
+		return nil, err
+	}
+	if err := dir.AddChild(name, nd); err != nil {
+		return nil, err
+	}
+	return []*dagutils.Change{{Type: dagutils.Mod, Path: rel, Before: old.Cid(), After: nd.Cid()}}, nil
+}
+
+func (s *Syncer) remove(dir *mfs.Directory, name, rel string, existing mfs.FSNode) (*dagutils.Change, error) {
+	nd, err := existing.GetNode()
+	if err != nil {
+		return nil, err
+	}
+	if err := dir.Unlink(name); err != nil {
+		return nil, err
+	}
+	return &dagutils.Change{Type: dagutils.Remove, Path: rel, Before: nd.Cid()}, nil
+}
@@ -0,0 +1,125 @@
+package coreunix
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	dagutils "github.com/ipfs/go-ipfs/merkledag/utils"
+	mfs "github.com/ipfs/go-ipfs/mfs"
+	unixfs "github.com/ipfs/go-ipfs/unixfs"
+)
+
+func changeSummary(changes []*dagutils.Change) []string {
+	var out []string
+	for _, c := range changes {
+		out = append(out, string("+-~"[c.Type])+" "+c.Path)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func assertChanges(t *testing.T, changes []*dagutils.Change, expected ...string) {
+	t.Helper()
+	got := changeSummary(changes)
+	sort.Strings(expected)
+	if len(got) != len(expected) {
+		t.Fatalf("expected changes %v, got %v", expected, got)
+	}
+	for i := range got {
+		if got[i] != expected[i] {
+			t.Fatalf("expected changes %v, got %v", expected, got)
+		}
+	}
+}
+
+func TestSync(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	local, err := ioutil.TempDir("", "sync-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(local)
+
+	write := func(name, data string) {
+		p := filepath.Join(local, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a/file1", "one")
+	write("file2", "two")
+
+	dserv := NewMemoryDagService()
+	root, err := mfs.NewRoot(ctx, dserv, unixfs.EmptyDirNode(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewSyncer(ctx, dserv, root)
+
+	changes, err := s.Sync(local, "/mirror")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertChanges(t, changes, "+ a/file1", "+ file2")
+
+	// nothing changed
+	changes, err = s.Sync(local, "/mirror")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertChanges(t, changes)
+
+	write("file2", "two, modified")
+	write("file3", "three")
+	if err := os.RemoveAll(filepath.Join(local, "a")); err != nil {
+		t.Fatal(err)
+	}
+	changes, err = s.Sync(local, "/mirror")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertChanges(t, changes, "~ file2", "+ file3", "- a")
+
+	// a new mode is stored without adding the file again
+	if err := os.Chmod(filepath.Join(local, "file3"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	changes, err = s.Sync(local, "/mirror")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertChanges(t, changes, "~ file3")
+
+	fsn, err := mfs.Lookup(root, "/mirror/file3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	nd, err := fsn.GetNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mode, _, err := unixfs.Attrs(nd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode == nil || *mode != 0600 {
+		t.Fatalf("expected mode 0600, got %v", mode)
+	}
+
+	// comparing content finds no changes either
+	s.Checksum = true
+	changes, err = s.Sync(local, "/mirror")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertChanges(t, changes)
+}
@@ -25,6 +25,7 @@ import (
 	logging "gx/ipfs/QmSpJByNKFX1sCsHBEp3R73FL4NF6FnQTEGyNAXHm2GS52/go-log"
 	peer "gx/ipfs/QmWNY7dV54ZDYmTA1ykVdwNCqC11mpU4zSUp6XDpLTH9eG/go-libp2p-peer"
 	blocks "gx/ipfs/QmYsEQydGrsxNZfAiskvQ76N2xE9hDQtSAkRSynwMiUK3c/go-block-format"
+	ds "gx/ipfs/QmdHG8MAuARdGHxx4rPQASLcvhz24fzjSQq7AJRAQEorq5/go-datastore"
 	cid "gx/ipfs/QmeSrf6pzut73u6zLQkRFQ3ygt3k6XFT2kjdYP8Tnkwwyg/go-cid"
 )
 
@@ -68,6 +69,14 @@ var rebroadcastDelay = delay.Fixed(time.Minute)
 // Runs until context is cancelled.
 func New(parent context.Context, p peer.ID, network bsnet.BitSwapNetwork,
 	bstore blockstore.Blockstore, nice bool) exchange.Interface {
+	return NewWithStrategy(parent, p, network, bstore, nil, decision.NewFairShareStrategy())
+}
+
+// NewWithStrategy is like New, but the decision engine serves the blocks
+// wanted by other peers as decided by the given strategy. If ledgers is not
+// nil, the ledgers of partners are kept in it across restarts.
+func NewWithStrategy(parent context.Context, p peer.ID, network bsnet.BitSwapNetwork,
+	bstore blockstore.Blockstore, ledgers ds.Datastore, strategy decision.Strategy) exchange.Interface {
 
 	// important to use provided parent context (since it may include important
 	// loggable data). It's probably not a good idea to allow bitswap to be
@@ -84,15 +93,16 @@ func New(parent context.Context, p peer.ID, network bsnet.BitSwapNetwork,
 		" data blocks recived").Histogram(metricsBuckets)
 
 	notif := notifications.New()
+	engine := decision.NewEngineWithStrategy(ctx, bstore, ledgers, strategy)
 	px := process.WithTeardown(func() error {
 		notif.Shutdown()
-		return nil
+		return engine.Close()
 	})
 
 	bs := &Bitswap{
 		blockstore:    bstore,
 		notifications: notif,
-		engine:        decision.NewEngine(ctx, bstore), // TODO close the engine with Close() method
+		engine:        engine,
 		network:       network,
 		findKeys:      make(chan *blockRequest, sizeBatchRequestChan),
 		process:       px,
@@ -100,6 +110,7 @@ func New(parent context.Context, p peer.ID, network bsnet.BitSwapNetwork,
 		provideKeys:   make(chan *cid.Cid, provideKeysBufferSize),
 		wm:            NewWantManager(ctx, network),
 		counters:      new(counters),
+		splitRequests: true,
 
 		dupMetric: dupHist,
 		allMetric: allHist,
@@ -163,6 +174,9 @@ type Bitswap struct {
 	// Sessions
 	sessions []*Session
 	sessLk   sync.Mutex
+	// splitRequests is whether new sessions split their wants among their
+	// peers, and is protected by sessLk
+	splitRequests bool
 
 	sessID   uint64
 	sessIDLk sync.Mutex
@@ -201,6 +215,26 @@ func (bs *Bitswap) LedgerForPeer(p peer.ID) *decision.Receipt {
 	return bs.engine.LedgerForPeer(p)
 }
 
+// Ledgers returns the ledgers of all past and present partners.
+func (bs *Bitswap) Ledgers() []*decision.Receipt {
+	return bs.engine.Ledgers()
+}
+
+// SetRequestSplitting sets whether sessions created afterwards divide the
+// blocks they want among their peers, adapting to the duplicate blocks they
+// receive, or request every block from all of them.
+func (bs *Bitswap) SetRequestSplitting(enabled bool) {
+	bs.sessLk.Lock()
+	defer bs.sessLk.Unlock()
+	bs.splitRequests = enabled
+}
+
+// SetQuotas limits the rate at which blocks are sent to other peers, and how
+// many blocks a single peer may have in flight.
+func (bs *Bitswap) SetQuotas(q decision.Quotas) {
+	bs.engine.SetQuotas(q)
+}
+
 // GetBlocks returns a channel where the caller may receive blocks that
 // correspond to the provided |keys|. Returns an error if BitSwap is unable to
 // begin this request within the deadline enforced by the context.
@@ -366,6 +400,12 @@ func (bs *Bitswap) ReceiveMessage(ctx context.Context, p peer.ID, incoming bsmsg
 	// TODO: this is bad, and could be easily abused.
 	// Should only track *useful* messages in ledger
 
+	if resp := bs.engine.PresenceResponse(incoming); resp != nil {
+		go bs.wm.SendPresence(ctx, p, resp)
+	}
+
+	bs.receivePresences(p, incoming)
+
 	iblocks := incoming.Blocks()
 
 	if len(iblocks) == 0 {
@@ -391,6 +431,21 @@ func (bs *Bitswap) ReceiveMessage(ctx context.Context, p peer.ID, incoming bsmsg
 	wg.Wait()
 }
 
+// receivePresences hands the HAVE and DONT_HAVE responses of a message to
+// the sessions that want the blocks.
+func (bs *Bitswap) receivePresences(from peer.ID, incoming bsmsg.BitSwapMessage) {
+	for _, c := range incoming.Haves() {
+		for _, s := range bs.SessionsForBlock(c) {
+			s.receivePresenceFrom(from, c, true)
+		}
+	}
+	for _, c := range incoming.DontHaves() {
+		for _, s := range bs.SessionsForBlock(c) {
+			s.receivePresenceFrom(from, c, false)
+		}
+	}
+}
+
 var ErrAlreadyHaveBlock = errors.New("already have block")
 
 func (bs *Bitswap) updateReceiveCounters(b blocks.Block) {
@@ -12,6 +12,7 @@ import (
 	logging "gx/ipfs/QmSpJByNKFX1sCsHBEp3R73FL4NF6FnQTEGyNAXHm2GS52/go-log"
 	peer "gx/ipfs/QmWNY7dV54ZDYmTA1ykVdwNCqC11mpU4zSUp6XDpLTH9eG/go-libp2p-peer"
 	blocks "gx/ipfs/QmYsEQydGrsxNZfAiskvQ76N2xE9hDQtSAkRSynwMiUK3c/go-block-format"
+	ds "gx/ipfs/QmdHG8MAuARdGHxx4rPQASLcvhz24fzjSQq7AJRAQEorq5/go-datastore"
 )
 
 // TODO consider taking responsibility for other types of requests. For
@@ -87,19 +88,57 @@ type Engine struct {
 	lock sync.Mutex // protects the fields immediatly below
 	// ledgerMap lists Ledgers by their Partner key.
 	ledgerMap map[peer.ID]*ledger
+	// history holds the records of partners without a ledger in ledgerMap
+	// as of when they disconnected, or were loaded from ledgerStore.
+	history map[peer.ID]ledgerRecord
+
+	// ledgerStore keeps the ledgers across restarts, if not nil. saved
+	// holds the records last written to it, and is protected by saveLk.
+	ledgerStore ds.Datastore
+	saveLk      sync.Mutex
+	saved       map[peer.ID]ledgerRecord
 
 	ticker *time.Ticker
+
+	quotaLk     sync.Mutex // protects the fields immediately below
+	quotas      Quotas
+	sendLimiter *rateLimiter
+	quotaStat   QuotaStat
 }
 
 func NewEngine(ctx context.Context, bs bstore.Blockstore) *Engine {
+	return NewEngineWithStrategy(ctx, bs, nil, NewFairShareStrategy())
+}
+
+// NewEngineWithStrategy returns an Engine that serves partners as decided by
+// the given Strategy. If ledgers is not nil, the ledgers of partners are
+// loaded from it, and saved to it periodically and when the engine is
+// closed.
+func NewEngineWithStrategy(ctx context.Context, bs bstore.Blockstore, ledgers ds.Datastore, s Strategy) *Engine {
 	e := &Engine{
 		ledgerMap:        make(map[peer.ID]*ledger),
+		history:          make(map[peer.ID]ledgerRecord),
+		ledgerStore:      ledgers,
+		saved:            make(map[peer.ID]ledgerRecord),
 		bs:               bs,
-		peerRequestQueue: newPRQ(),
+		peerRequestQueue: newPRQWithStrategy(s),
 		outbox:           make(chan (<-chan *Envelope), outboxChanBuffer),
 		workSignal:       make(chan struct{}, 1),
 		ticker:           time.NewTicker(time.Millisecond * 100),
 	}
+
+	if ledgers != nil {
+		records, err := loadLedgers(ledgers)
+		if err != nil {
+			log.Errorf("loading bitswap ledgers: %s", err)
+		}
+		for p, r := range records {
+			e.history[p] = r
+			e.saved[p] = r
+		}
+		go e.ledgerSaver(ctx)
+	}
+
 	go e.taskWorker(ctx)
 	return e
 }
@@ -113,6 +152,7 @@ func (e *Engine) WantlistForPeer(p peer.ID) (out []*wl.Entry) {
 
 func (e *Engine) LedgerForPeer(p peer.ID) *Receipt {
 	ledger := e.findOrCreate(p)
+	outstanding := e.peerRequestQueue.peerOutstandingExceeded(p)
 
 	ledger.lk.Lock()
 	defer ledger.lk.Unlock()
@@ -123,7 +163,91 @@ func (e *Engine) LedgerForPeer(p peer.ID) *Receipt {
 		Sent:      ledger.Accounting.BytesSent,
 		Recv:      ledger.Accounting.BytesRecv,
 		Exchanged: ledger.ExchangeCount(),
+
+		LastExchange: ledger.lastExchange,
+
+		PeerRateExceeded:    ledger.peerRateExceeded,
+		OutstandingExceeded: outstanding,
+	}
+}
+
+// SetQuotas sets the limits on what is sent to partners.
+func (e *Engine) SetQuotas(q Quotas) {
+	e.quotaLk.Lock()
+	e.quotas = q
+	e.sendLimiter = newRateLimiter(q.Rate)
+	e.quotaLk.Unlock()
+
+	e.peerRequestQueue.setMaxOutstanding(q.MaxOutstanding)
+}
+
+// QuotaStat returns how often the quotas were exceeded.
+func (e *Engine) QuotaStat() QuotaStat {
+	e.quotaLk.Lock()
+	st := e.quotaStat
+	e.quotaLk.Unlock()
+
+	st.OutstandingExceeded = e.peerRequestQueue.totalOutstandingExceeded()
+	return st
+}
+
+// peerWait returns how long p has to wait before it may be sent more
+// blocks, because it exceeded its send rate.
+func (e *Engine) peerWait(p peer.ID) time.Duration {
+	e.quotaLk.Lock()
+	rate := e.quotas.PeerRate
+	e.quotaLk.Unlock()
+
+	if rate == 0 {
+		return 0
+	}
+
+	l := e.findOrCreate(p)
+	l.lk.Lock()
+	defer l.lk.Unlock()
+	if l.sendLimiter == nil || l.sendLimiter.rate != float64(rate) {
+		return 0
+	}
+	return l.sendLimiter.wait(time.Now())
+}
+
+// chargeQuotas accounts for a block of n bytes about to be sent to p. If p
+// exceeds its send rate it is paused, and if the global send rate is
+// exceeded chargeQuotas returns how long to wait before sending the block.
+func (e *Engine) chargeQuotas(p peer.ID, n int) time.Duration {
+	now := time.Now()
+
+	e.quotaLk.Lock()
+	q := e.quotas
+	wait := e.sendLimiter.take(n, now)
+	if wait > 0 {
+		e.quotaStat.RateExceeded++
+	}
+	e.quotaLk.Unlock()
+
+	if q.PeerRate == 0 {
+		return wait
 	}
+
+	l := e.findOrCreate(p)
+	l.lk.Lock()
+	if l.sendLimiter == nil || l.sendLimiter.rate != float64(q.PeerRate) {
+		l.sendLimiter = newRateLimiter(q.PeerRate)
+	}
+	peerWait := l.sendLimiter.take(n, now)
+	if peerWait > 0 {
+		l.peerRateExceeded++
+	}
+	l.lk.Unlock()
+
+	if peerWait > 0 {
+		e.peerRequestQueue.throttle(p, now.Add(peerWait))
+
+		e.quotaLk.Lock()
+		e.quotaStat.PeerRateExceeded++
+		e.quotaLk.Unlock()
+	}
+	return wait
 }
 
 func (e *Engine) taskWorker(ctx context.Context) {
@@ -164,6 +288,14 @@ func (e *Engine) nextEnvelope(ctx context.Context) (*Envelope, error) {
 			}
 		}
 
+		// a partner still paying back its send rate gets its task back,
+		// rather than more than its quota
+		if wait := e.peerWait(nextTask.Target); wait > 0 {
+			e.peerRequestQueue.requeue(nextTask)
+			e.peerRequestQueue.throttle(nextTask.Target, time.Now().Add(wait))
+			continue
+		}
+
 		// with a task in hand, we're ready to prepare the envelope...
 
 		block, err := e.bs.Get(nextTask.Entry.Cid)
@@ -175,6 +307,15 @@ func (e *Engine) nextEnvelope(ctx context.Context) (*Envelope, error) {
 			continue
 		}
 
+		if wait := e.chargeQuotas(nextTask.Target, len(block.RawData())); wait > 0 {
+			select {
+			case <-ctx.Done():
+				nextTask.Done()
+				return nil, ctx.Err()
+			case <-time.After(wait):
+			}
+		}
+
 		return &Envelope{
 			Peer:  nextTask.Target,
 			Block: block,
@@ -212,7 +353,7 @@ func (e *Engine) Peers() []peer.ID {
 // MessageReceived performs book-keeping. Returns error if passed invalid
 // arguments.
 func (e *Engine) MessageReceived(p peer.ID, m bsmsg.BitSwapMessage) error {
-	if len(m.Wantlist()) == 0 && len(m.Blocks()) == 0 {
+	if m.Empty() {
 		log.Debugf("received empty message from %s", p)
 	}
 
@@ -235,6 +376,9 @@ func (e *Engine) MessageReceived(p peer.ID, m bsmsg.BitSwapMessage) error {
 			log.Debugf("%s cancel %s", p, entry.Cid)
 			l.CancelWant(entry.Cid)
 			e.peerRequestQueue.Remove(entry.Cid, p)
+		} else if entry.WantType == bsmsg.WantHave {
+			// answered by PresenceResponse, the block is not sent
+			log.Debugf("%s wants to know if we have %s", p, entry.Cid)
 		} else {
 			log.Debugf("wants %s - %d", entry.Cid, entry.Priority)
 			l.Wants(entry.Cid, entry.Priority)
@@ -249,9 +393,40 @@ func (e *Engine) MessageReceived(p peer.ID, m bsmsg.BitSwapMessage) error {
 		log.Debugf("got block %s %d bytes", block, len(block.RawData()))
 		l.ReceivedBytes(len(block.RawData()))
 	}
+	e.peerRequestQueue.updateAccounting(p, l.Accounting)
 	return nil
 }
 
+// PresenceResponse returns a message with a HAVE for every want-have entry
+// of m whose block we have, and a DONT_HAVE for every entry asking for one
+// whose block we do not have. It returns nil if there is nothing to answer.
+func (e *Engine) PresenceResponse(m bsmsg.BitSwapMessage) bsmsg.BitSwapMessage {
+	out := bsmsg.New(false)
+	for _, entry := range m.Wantlist() {
+		if entry.Cancel || (entry.WantType != bsmsg.WantHave && !entry.SendDontHave) {
+			continue
+		}
+
+		has, err := e.bs.Has(entry.Cid)
+		if err != nil {
+			log.Errorf("checking if we have %s: %s", entry.Cid, err)
+			continue
+		}
+
+		switch {
+		case has && entry.WantType == bsmsg.WantHave:
+			out.AddHave(entry.Cid)
+		case !has && entry.SendDontHave:
+			out.AddDontHave(entry.Cid)
+		}
+	}
+
+	if out.Empty() {
+		return nil
+	}
+	return out
+}
+
 func (e *Engine) addBlock(block blocks.Block) {
 	work := false
 
@@ -259,6 +434,7 @@ func (e *Engine) addBlock(block blocks.Block) {
 		l.lk.Lock()
 		if entry, ok := l.WantListContains(block.Cid()); ok {
 			e.peerRequestQueue.Push(entry, l.Partner)
+			e.peerRequestQueue.updateAccounting(l.Partner, l.Accounting)
 			work = true
 		}
 		l.lk.Unlock()
@@ -292,6 +468,7 @@ func (e *Engine) MessageSent(p peer.ID, m bsmsg.BitSwapMessage) error {
 		l.wantList.Remove(block.Cid())
 		e.peerRequestQueue.Remove(block.Cid(), p)
 	}
+	e.peerRequestQueue.updateAccounting(p, l.Accounting)
 
 	return nil
 }
@@ -301,7 +478,7 @@ func (e *Engine) PeerConnected(p peer.ID) {
 	defer e.lock.Unlock()
 	l, ok := e.ledgerMap[p]
 	if !ok {
-		l = newLedger(p)
+		l = e.restoreLedger(p)
 		e.ledgerMap[p] = l
 	}
 	l.lk.Lock()
@@ -320,6 +497,7 @@ func (e *Engine) PeerDisconnected(p peer.ID) {
 	defer l.lk.Unlock()
 	l.ref--
 	if l.ref <= 0 {
+		e.history[p] = l.record()
 		delete(e.ledgerMap, p)
 	}
 }
@@ -340,7 +518,7 @@ func (e *Engine) findOrCreate(p peer.ID) *ledger {
 	defer e.lock.Unlock()
 	l, ok := e.ledgerMap[p]
 	if !ok {
-		l = newLedger(p)
+		l = e.restoreLedger(p)
 		e.ledgerMap[p] = l
 	}
 	return l
@@ -212,3 +212,81 @@ func stringsComplement(set, subset []string) []string {
 	}
 	return complement
 }
+
+func TestPresenceResponse(t *testing.T) {
+	bs := blockstore.NewBlockstore(dssync.MutexWrap(ds.NewMapDatastore()))
+	have := blocks.NewBlock([]byte("have"))
+	missing := blocks.NewBlock([]byte("missing"))
+	if err := bs.Put(have); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	e := NewEngine(ctx, bs)
+	partner := testutil.RandPeerIDFatal(t)
+
+	m := message.New(false)
+	m.AddWantEntry(have.Cid(), 1, message.WantHave, true)
+	m.AddWantEntry(missing.Cid(), 1, message.WantBlock, true)
+	if err := e.MessageReceived(partner, m); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := e.PresenceResponse(m)
+	if resp == nil {
+		t.Fatal("expected a presence response")
+	}
+	if h := resp.Haves(); len(h) != 1 || !h[0].Equals(have.Cid()) {
+		t.Fatalf("unexpected haves: %v", h)
+	}
+	if dh := resp.DontHaves(); len(dh) != 1 || !dh[0].Equals(missing.Cid()) {
+		t.Fatalf("unexpected dont haves: %v", dh)
+	}
+
+	// a want-have must not make us send the block
+	if wl := e.WantlistForPeer(partner); len(wl) != 1 || !wl[0].Cid.Equals(missing.Cid()) {
+		t.Fatalf("expected only the want-block entry in the partner's wantlist, got %v", wl)
+	}
+
+	plain := message.New(false)
+	plain.AddEntry(missing.Cid(), 1)
+	if resp := e.PresenceResponse(plain); resp != nil {
+		t.Fatal("expected no response to entries that do not ask for one")
+	}
+}
+
+func TestWantPriorityUpdate(t *testing.T) {
+	bs := blockstore.NewBlockstore(dssync.MutexWrap(ds.NewMapDatastore()))
+	first := blocks.NewBlock([]byte("wanted first"))
+	later := blocks.NewBlock([]byte("wanted later"))
+	if err := bs.PutMany([]blocks.Block{first, later}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	e := NewEngine(ctx, bs)
+	partner := testutil.RandPeerIDFatal(t)
+
+	m := message.New(false)
+	m.AddEntry(later.Cid(), 1)
+	m.AddEntry(first.Cid(), 2)
+	e.MessageReceived(partner, m)
+
+	// the partner now needs the other block sooner
+	m = message.New(false)
+	m.AddEntry(later.Cid(), 3)
+	e.MessageReceived(partner, m)
+
+	wl := e.WantlistForPeer(partner)
+	if len(wl) != 2 || !wl[0].Cid.Equals(later.Cid()) || wl[0].Priority != 3 {
+		t.Fatalf("expected the wantlist to have the new priority, got %v", wl)
+	}
+
+	env := <-<-e.Outbox()
+	if !env.Block.Cid().Equals(later.Cid()) {
+		t.Fatal("expected the block with the highest priority to be sent first")
+	}
+	env.Sent()
+}
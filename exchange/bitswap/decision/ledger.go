@@ -44,6 +44,11 @@ type ledger struct {
 	// don't drop the reference to this ledger in multi-connection scenarios
 	ref int
 
+	// sendLimiter enforces the per-peer send rate quota, and
+	// peerRateExceeded counts how often the partner exceeded it
+	sendLimiter      *rateLimiter
+	peerRateExceeded uint64
+
 	lk sync.Mutex
 }
 
@@ -53,6 +58,15 @@ type Receipt struct {
 	Sent      uint64
 	Recv      uint64
 	Exchanged uint64
+
+	// LastExchange is the time data was last sent to or received from the
+	// peer
+	LastExchange time.Time
+
+	// PeerRateExceeded and OutstandingExceeded count how often the peer
+	// exceeded the per-peer quotas
+	PeerRateExceeded    uint64
+	OutstandingExceeded uint64
 }
 
 type debtRatio struct {
@@ -76,8 +90,14 @@ func (l *ledger) ReceivedBytes(n int) {
 	l.Accounting.BytesRecv += uint64(n)
 }
 
+// Wants adds k to the partner's wantlist, or updates its priority if it is
+// already there.
 func (l *ledger) Wants(k *cid.Cid, priority int) {
 	log.Debugf("peer %s wants %s", l.Partner, k)
+	if e, ok := l.wantList.Contains(k); ok {
+		e.Priority = priority
+		return
+	}
 	l.wantList.Add(k, priority)
 }
 
@@ -0,0 +1,190 @@
+package decision
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	peer "gx/ipfs/QmWNY7dV54ZDYmTA1ykVdwNCqC11mpU4zSUp6XDpLTH9eG/go-libp2p-peer"
+	ds "gx/ipfs/QmdHG8MAuARdGHxx4rPQASLcvhz24fzjSQq7AJRAQEorq5/go-datastore"
+	dsq "gx/ipfs/QmdHG8MAuARdGHxx4rPQASLcvhz24fzjSQq7AJRAQEorq5/go-datastore/query"
+)
+
+// ledgerKeyPrefix is the datastore key under which ledgers are kept, one
+// per partner.
+var ledgerKeyPrefix = ds.NewKey("/local/bitswap/ledgers")
+
+// LedgerSaveInterval is how often ledgers are written to the datastore, on
+// top of when the engine is closed.
+var LedgerSaveInterval = time.Minute
+
+// ledgerRecord is the part of a ledger that is kept once the partner
+// disconnects, and across restarts.
+type ledgerRecord struct {
+	Sent         uint64
+	Recv         uint64
+	Exchanged    uint64
+	LastExchange time.Time
+}
+
+func (l *ledger) record() ledgerRecord {
+	return ledgerRecord{
+		Sent:         l.Accounting.BytesSent,
+		Recv:         l.Accounting.BytesRecv,
+		Exchanged:    l.exchangeCount,
+		LastExchange: l.lastExchange,
+	}
+}
+
+func (r ledgerRecord) receipt(p peer.ID) *Receipt {
+	dr := debtRatio{BytesSent: r.Sent, BytesRecv: r.Recv}
+	return &Receipt{
+		Peer:         p.Pretty(),
+		Value:        dr.Value(),
+		Sent:         r.Sent,
+		Recv:         r.Recv,
+		Exchanged:    r.Exchanged,
+		LastExchange: r.LastExchange,
+	}
+}
+
+func ledgerKey(p peer.ID) ds.Key {
+	return ledgerKeyPrefix.Child(ds.NewKey(p.Pretty()))
+}
+
+// loadLedgers reads the ledgers kept in d.
+func loadLedgers(d ds.Datastore) (map[peer.ID]ledgerRecord, error) {
+	res, err := d.Query(dsq.Query{Prefix: ledgerKeyPrefix.String()})
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+
+	out := make(map[peer.ID]ledgerRecord)
+	for {
+		e, ok := res.NextSync()
+		if !ok {
+			break
+		}
+		if e.Error != nil {
+			return nil, e.Error
+		}
+
+		p, err := peer.IDB58Decode(ds.RawKey(e.Key).Name())
+		if err != nil {
+			log.Warningf("skipping ledger with invalid key %s: %s", e.Key, err)
+			continue
+		}
+		data, ok := e.Value.([]byte)
+		if !ok {
+			return nil, fmt.Errorf("stored ledger for %s was not a []byte", p)
+		}
+
+		var r ledgerRecord
+		if err := json.Unmarshal(data, &r); err != nil {
+			log.Warningf("skipping invalid ledger for %s: %s", p, err)
+			continue
+		}
+		out[p] = r
+	}
+	return out, nil
+}
+
+// restoreLedger returns a new ledger for p, carrying over what was recorded
+// of past exchanges with it. Must be called with e.lock held.
+func (e *Engine) restoreLedger(p peer.ID) *ledger {
+	l := newLedger(p)
+	if r, ok := e.history[p]; ok {
+		l.Accounting = debtRatio{BytesSent: r.Sent, BytesRecv: r.Recv}
+		l.exchangeCount = r.Exchanged
+		l.lastExchange = r.LastExchange
+	}
+	return l
+}
+
+// records returns the records of all past and present partners.
+func (e *Engine) records() map[peer.ID]ledgerRecord {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	out := make(map[peer.ID]ledgerRecord, len(e.history)+len(e.ledgerMap))
+	for p, r := range e.history {
+		out[p] = r
+	}
+	for p, l := range e.ledgerMap {
+		l.lk.Lock()
+		out[p] = l.record()
+		l.lk.Unlock()
+	}
+	return out
+}
+
+// Ledgers returns the receipts of all past and present partners we
+// exchanged data with, most recent exchange first.
+func (e *Engine) Ledgers() []*Receipt {
+	var out []*Receipt
+	for p, r := range e.records() {
+		if r.Exchanged == 0 {
+			continue
+		}
+		rc := r.receipt(p)
+		rc.OutstandingExceeded = e.peerRequestQueue.peerOutstandingExceeded(p)
+		out = append(out, rc)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].LastExchange.After(out[j].LastExchange)
+	})
+	return out
+}
+
+// saveLedgers writes the ledgers that changed since they were last saved
+// to the datastore.
+func (e *Engine) saveLedgers() error {
+	if e.ledgerStore == nil {
+		return nil
+	}
+
+	e.saveLk.Lock()
+	defer e.saveLk.Unlock()
+
+	for p, r := range e.records() {
+		if r.Exchanged == 0 || e.saved[p] == r {
+			continue
+		}
+
+		data, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		if err := e.ledgerStore.Put(ledgerKey(p), data); err != nil {
+			return err
+		}
+		e.saved[p] = r
+	}
+	return nil
+}
+
+// ledgerSaver periodically saves the ledgers until ctx is cancelled.
+func (e *Engine) ledgerSaver(ctx context.Context) {
+	t := time.NewTicker(LedgerSaveInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			if err := e.saveLedgers(); err != nil {
+				log.Errorf("saving bitswap ledgers: %s", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Close saves the ledgers to the datastore, if the engine has one.
+func (e *Engine) Close() error {
+	return e.saveLedgers()
+}
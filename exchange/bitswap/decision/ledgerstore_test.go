@@ -0,0 +1,68 @@
+package decision
+
+import (
+	"context"
+	"testing"
+
+	blockstore "github.com/ipfs/go-ipfs/blocks/blockstore"
+	message "github.com/ipfs/go-ipfs/exchange/bitswap/message"
+
+	blocks "gx/ipfs/QmYsEQydGrsxNZfAiskvQ76N2xE9hDQtSAkRSynwMiUK3c/go-block-format"
+	ds "gx/ipfs/QmdHG8MAuARdGHxx4rPQASLcvhz24fzjSQq7AJRAQEorq5/go-datastore"
+	dssync "gx/ipfs/QmdHG8MAuARdGHxx4rPQASLcvhz24fzjSQq7AJRAQEorq5/go-datastore/sync"
+	testutil "gx/ipfs/QmeDA8gNhvRTsbrjEieay5wezupJDiky8xvCzDABbsGzmp/go-testutil"
+)
+
+func TestLedgersSurviveRestart(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := dssync.MutexWrap(ds.NewMapDatastore())
+	bs := blockstore.NewBlockstore(dssync.MutexWrap(ds.NewMapDatastore()))
+	sender := testutil.RandPeerIDFatal(t)
+	leech := testutil.RandPeerIDFatal(t)
+
+	e := NewEngineWithStrategy(ctx, bs, store, NewFairShareStrategy())
+	m := message.New(false)
+	m.AddBlock(blocks.NewBlock([]byte("a block from a good partner")))
+	e.PeerConnected(sender)
+	e.MessageReceived(sender, m)
+	e.PeerDisconnected(sender)
+	e.MessageSent(leech, m)
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	e = NewEngineWithStrategy(ctx, bs, store, NewFairShareStrategy())
+	if r := e.LedgerForPeer(sender); r.Recv != uint64(len(m.Blocks()[0].RawData())) || r.Exchanged != 1 {
+		t.Fatalf("expected the ledger of the sender to be restored, got %+v", r)
+	}
+
+	ledgers := e.Ledgers()
+	if len(ledgers) != 2 {
+		t.Fatalf("expected 2 ledgers, got %d", len(ledgers))
+	}
+	if ledgers[0].LastExchange.Before(ledgers[1].LastExchange) {
+		t.Fatal("expected ledgers to be sorted by last exchange")
+	}
+}
+
+func TestLedgersWithoutStore(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	e := NewEngine(ctx, blockstore.NewBlockstore(dssync.MutexWrap(ds.NewMapDatastore())))
+	p := testutil.RandPeerIDFatal(t)
+	m := message.New(false)
+	m.AddBlock(blocks.NewBlock([]byte("some data")))
+	e.PeerConnected(p)
+	e.MessageSent(p, m)
+	e.PeerDisconnected(p)
+
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if ledgers := e.Ledgers(); len(ledgers) != 1 || ledgers[0].Sent == 0 {
+		t.Fatalf("expected disconnected partners to be listed, got %v", ledgers)
+	}
+}
@@ -22,27 +22,42 @@ type peerRequestQueue interface {
 }
 
 func newPRQ() *prq {
-	return &prq{
-		taskMap:  make(map[string]*peerRequestTask),
-		partners: make(map[peer.ID]*activePartner),
-		frozen:   make(map[peer.ID]*activePartner),
-		pQueue:   pq.New(partnerCompare),
+	return newPRQWithStrategy(NewFairShareStrategy())
+}
+
+func newPRQWithStrategy(s Strategy) *prq {
+	tl := &prq{
+		taskMap:   make(map[string]*peerRequestTask),
+		partners:  make(map[peer.ID]*activePartner),
+		frozen:    make(map[peer.ID]*activePartner),
+		throttled: make(map[peer.ID]*activePartner),
+		strategy:  s,
 	}
+	tl.pQueue = pq.New(tl.partnerCompare)
+	return tl
 }
 
 // verify interface implementation
 var _ peerRequestQueue = &prq{}
 
-// TODO: at some point, the strategy needs to plug in here
-// to help decide how to sort tasks (on add) and how to select
-// tasks (on getnext). For now, we are assuming a dumb/nice strategy.
+// prq orders partners according to its Strategy, and the tasks of each
+// partner by wantlist priority.
 type prq struct {
 	lock     sync.Mutex
 	pQueue   pq.PQ
 	taskMap  map[string]*peerRequestTask
 	partners map[peer.ID]*activePartner
+	strategy Strategy
 
 	frozen map[peer.ID]*activePartner
+
+	// throttled holds the partners paused for exceeding their send rate
+	throttled map[peer.ID]*activePartner
+
+	// maxOutstanding caps the strategy allowance of every partner, if not
+	// zero
+	maxOutstanding      int
+	outstandingExceeded uint64
 }
 
 // Push currently adds a new peerRequestTask to the end of the list
@@ -51,14 +66,14 @@ func (tl *prq) Push(entry *wantlist.Entry, to peer.ID) {
 	defer tl.lock.Unlock()
 	partner, ok := tl.partners[to]
 	if !ok {
-		partner = newActivePartner()
+		partner = newActivePartner(to)
 		tl.pQueue.Push(partner)
 		tl.partners[to] = partner
 	}
 
-	partner.activelk.Lock()
-	defer partner.activelk.Unlock()
 	if partner.activeBlocks.Has(entry.Cid) {
+		// wanted again after a cancel
+		partner.cancelled.Remove(entry.Cid)
 		return
 	}
 
@@ -73,10 +88,7 @@ func (tl *prq) Push(entry *wantlist.Entry, to peer.ID) {
 		Target:  to,
 		created: time.Now(),
 		Done: func() {
-			tl.lock.Lock()
-			partner.TaskDone(entry.Cid)
-			tl.pQueue.Update(partner.Index())
-			tl.lock.Unlock()
+			tl.taskDone(partner, entry.Cid)
 		},
 	}
 
@@ -96,7 +108,7 @@ func (tl *prq) Pop() *peerRequestTask {
 	partner := tl.pQueue.Pop().(*activePartner)
 
 	var out *peerRequestTask
-	for partner.taskQueue.Len() > 0 && partner.freezeVal == 0 {
+	for partner.taskQueue.Len() > 0 && partner.freezeVal == 0 && !partner.throttled && !tl.atAllowance(partner) {
 		out = partner.taskQueue.Pop().(*peerRequestTask)
 		delete(tl.taskMap, out.Key())
 		if out.trash {
@@ -106,6 +118,10 @@ func (tl *prq) Pop() *peerRequestTask {
 
 		partner.StartTask(out.Entry.Cid)
 		partner.requests--
+		if tl.maxOutstanding > 0 && partner.active >= tl.maxOutstanding && partner.requests > 0 {
+			partner.outstandingExceeded++
+			tl.outstandingExceeded++
+		}
 		break // and return |out|
 	}
 
@@ -113,6 +129,38 @@ func (tl *prq) Pop() *peerRequestTask {
 	return out
 }
 
+// requeue puts back a task that was popped but not started, to be popped
+// again later. Tasks cancelled since they were popped are dropped instead.
+func (tl *prq) requeue(task *peerRequestTask) {
+	tl.lock.Lock()
+	defer tl.lock.Unlock()
+
+	partner, ok := tl.partners[task.Target]
+	if !ok {
+		return
+	}
+	cancelled := partner.cancelled.Has(task.Entry.Cid)
+	partner.TaskDone(task.Entry.Cid)
+	if cancelled {
+		tl.pQueue.Update(partner.index)
+		return
+	}
+	partner.taskQueue.Push(task)
+	tl.taskMap[task.Key()] = task
+	partner.requests++
+	tl.pQueue.Update(partner.index)
+}
+
+// taskDone records that a task of partner was completed, which may let it
+// be served again.
+func (tl *prq) taskDone(partner *activePartner, k *cid.Cid) {
+	tl.lock.Lock()
+	defer tl.lock.Unlock()
+
+	partner.TaskDone(k)
+	tl.pQueue.Update(partner.index)
+}
+
 // Remove removes a task from the queue
 func (tl *prq) Remove(k *cid.Cid, p peer.ID) {
 	tl.lock.Lock()
@@ -135,8 +183,16 @@ func (tl *prq) Remove(k *cid.Cid, p peer.ID) {
 			tl.frozen[p] = partner
 		}
 
-		partner.freezeVal++
+		partner.freezeVal = tl.strategy.Freeze(partner.info())
+		if partner.freezeVal <= 0 {
+			partner.freezeVal = 0
+			delete(tl.frozen, p)
+		}
 		tl.pQueue.Update(partner.index)
+	} else if partner, ok := tl.partners[p]; ok && partner.activeBlocks.Has(k) {
+		// the task was popped already, remember the cancel in case it is
+		// put back
+		partner.cancelled.Add(k)
 	}
 	tl.lock.Unlock()
 }
@@ -157,12 +213,126 @@ func (tl *prq) thawRound() {
 	defer tl.lock.Unlock()
 
 	for id, partner := range tl.frozen {
-		partner.freezeVal -= (partner.freezeVal + 1) / 2
+		partner.freezeVal = tl.strategy.Thaw(partner.info())
 		if partner.freezeVal <= 0 {
+			partner.freezeVal = 0
 			delete(tl.frozen, id)
 		}
 		tl.pQueue.Update(partner.index)
 	}
+
+	now := time.Now()
+	for id, partner := range tl.throttled {
+		if now.Before(partner.throttledUntil) {
+			continue
+		}
+		partner.throttled = false
+		delete(tl.throttled, id)
+		tl.pQueue.Update(partner.index)
+	}
+}
+
+// throttle pauses a partner until the given time. Partners are resumed by
+// the first thaw round after it.
+func (tl *prq) throttle(p peer.ID, until time.Time) {
+	tl.lock.Lock()
+	defer tl.lock.Unlock()
+
+	partner, ok := tl.partners[p]
+	if !ok {
+		return
+	}
+	if until.After(partner.throttledUntil) {
+		partner.throttledUntil = until
+	}
+	if !partner.throttled {
+		partner.throttled = true
+		tl.throttled[p] = partner
+		tl.pQueue.Update(partner.index)
+	}
+}
+
+// setMaxOutstanding sets the number of blocks any partner may have in
+// flight, zero for no limit besides the strategy allowance.
+func (tl *prq) setMaxOutstanding(n int) {
+	tl.lock.Lock()
+	defer tl.lock.Unlock()
+
+	tl.maxOutstanding = n
+	for _, partner := range tl.partners {
+		tl.pQueue.Update(partner.index)
+	}
+}
+
+// peerOutstandingExceeded returns the number of times the given partner
+// reached the outstanding blocks limit.
+func (tl *prq) peerOutstandingExceeded(p peer.ID) uint64 {
+	tl.lock.Lock()
+	defer tl.lock.Unlock()
+
+	if partner, ok := tl.partners[p]; ok {
+		return partner.outstandingExceeded
+	}
+	return 0
+}
+
+// totalOutstandingExceeded returns the number of times any partner reached
+// the outstanding blocks limit.
+func (tl *prq) totalOutstandingExceeded() uint64 {
+	tl.lock.Lock()
+	defer tl.lock.Unlock()
+	return tl.outstandingExceeded
+}
+
+// updateAccounting records the bytes exchanged with a partner, for
+// strategies that take them into account.
+func (tl *prq) updateAccounting(p peer.ID, dr debtRatio) {
+	tl.lock.Lock()
+	defer tl.lock.Unlock()
+
+	partner, ok := tl.partners[p]
+	if !ok || partner.accounting == dr {
+		return
+	}
+	partner.accounting = dr
+	tl.pQueue.Update(partner.index)
+}
+
+// atAllowance returns true if the partner may not have more blocks in
+// flight.
+func (tl *prq) atAllowance(p *activePartner) bool {
+	info := p.info()
+	allowance := tl.strategy.Allowance(info)
+	if tl.maxOutstanding > 0 && (allowance <= 0 || tl.maxOutstanding < allowance) {
+		allowance = tl.maxOutstanding
+	}
+	return allowance > 0 && info.Active >= allowance
+}
+
+// partnerCompare implements pq.ElemComparator
+// returns true if peer 'a' has higher priority than peer 'b'
+func (tl *prq) partnerCompare(a, b pq.Elem) bool {
+	pa := a.(*activePartner)
+	pb := b.(*activePartner)
+
+	// having no blocks in their wantlist, as many blocks in flight as
+	// allowed, or being throttled means lowest priority
+	// having both of these checks ensures stability of the sort
+	if pa.requests == 0 || pa.throttled || tl.atAllowance(pa) {
+		return false
+	}
+	if pb.requests == 0 || pb.throttled || tl.atAllowance(pb) {
+		return true
+	}
+
+	if pa.freezeVal > pb.freezeVal {
+		return false
+	}
+	if pa.freezeVal < pb.freezeVal {
+		return true
+	}
+
+	return tl.strategy.Less(pa.info(), pb.info())
 }
 
 type peerRequestTask struct {
@@ -220,14 +390,18 @@ func wrapCmp(f func(a, b *peerRequestTask) bool) func(a, b pq.Elem) bool {
 }
 
 type activePartner struct {
+	peer peer.ID
 
-	// Active is the number of blocks this peer is currently being sent
-	// active must be locked around as it will be updated externally
-	activelk sync.Mutex
-	active   int
-
+	// active is the number of blocks this peer is currently being sent,
+	// and activeBlocks their keys. They are only accessed under the
+	// peerRequestQueue's lock, as they decide the order of the partners.
+	active       int
 	activeBlocks *cid.Set
 
+	// cancelled holds the active blocks the peer cancelled after they were
+	// popped
+	cancelled *cid.Set
+
 	// requests is the number of blocks this peer is currently requesting
 	// request need not be locked around as it will only be modified under
 	// the peerRequestQueue's locks
@@ -238,65 +412,62 @@ type activePartner struct {
 
 	freezeVal int
 
+	// accounting is a copy of the partner's ledger accounting, kept up to
+	// date by the engine
+	accounting debtRatio
+
+	// throttled is set while the partner exceeds its send rate, until
+	// throttledUntil
+	throttled      bool
+	throttledUntil time.Time
+
+	// outstandingExceeded counts the times the partner reached the
+	// outstanding blocks limit with more requests waiting
+	outstandingExceeded uint64
+
 	// priority queue of tasks belonging to this peer
 	taskQueue pq.PQ
 }
 
-func newActivePartner() *activePartner {
+func newActivePartner(p peer.ID) *activePartner {
 	return &activePartner{
+		peer:         p,
 		taskQueue:    pq.New(wrapCmp(V1)),
 		activeBlocks: cid.NewSet(),
+		cancelled:    cid.NewSet(),
 	}
 }
 
-// partnerCompare implements pq.ElemComparator
-// returns true if peer 'a' has higher priority than peer 'b'
-func partnerCompare(a, b pq.Elem) bool {
-	pa := a.(*activePartner)
-	pb := b.(*activePartner)
-
-	// having no blocks in their wantlist means lowest priority
-	// having both of these checks ensures stability of the sort
-	if pa.requests == 0 {
-		return false
-	}
-	if pb.requests == 0 {
-		return true
-	}
-
-	if pa.freezeVal > pb.freezeVal {
-		return false
-	}
-	if pa.freezeVal < pb.freezeVal {
-		return true
-	}
-
-	if pa.active == pb.active {
-		// sorting by taskQueue.Len() aids in cleaning out trash entries faster
-		// if we sorted instead by requests, one peer could potentially build up
-		// a huge number of cancelled entries in the queue resulting in a memory leak
-		return pa.taskQueue.Len() > pb.taskQueue.Len()
+// info returns the state of the partner handed to the Strategy.
+func (p *activePartner) info() PartnerInfo {
+	return PartnerInfo{
+		Peer:      p.peer,
+		Active:    p.active,
+		Requests:  p.requests,
+		Queued:    p.taskQueue.Len(),
+		Frozen:    p.freezeVal,
+		BytesSent: p.accounting.BytesSent,
+		BytesRecv: p.accounting.BytesRecv,
 	}
-	return pa.active < pb.active
 }
 
-// StartTask signals that a task was started for this partner
+// StartTask signals that a task was started for this partner. It must be
+// called with the peerRequestQueue's lock held.
 func (p *activePartner) StartTask(k *cid.Cid) {
-	p.activelk.Lock()
 	p.activeBlocks.Add(k)
 	p.active++
-	p.activelk.Unlock()
 }
 
-// TaskDone signals that a task was completed for this partner
+// TaskDone signals that a task was completed for this partner. It must be
+// called with the peerRequestQueue's lock held, and the partner's position
+// in the queue updated afterwards.
 func (p *activePartner) TaskDone(k *cid.Cid) {
-	p.activelk.Lock()
 	p.activeBlocks.Remove(k)
+	p.cancelled.Remove(k)
 	p.active--
 	if p.active < 0 {
 		panic("more tasks finished than started!")
 	}
-	p.activelk.Unlock()
 }
 
 // Index implements pq.Elem
@@ -126,3 +126,36 @@ func TestPeerRepeats(t *testing.T) {
 		}
 	}
 }
+
+// This test checks that a partner at its allowance is served again as soon as
+// one of its tasks is done
+func TestAllowanceFreed(t *testing.T) {
+	prq := newPRQ()
+	prq.setMaxOutstanding(1)
+	a := testutil.RandPeerIDFatal(t)
+	b := testutil.RandPeerIDFatal(t)
+
+	for i := 0; i < 3; i++ {
+		elcid := cid.NewCidV0(u.Hash([]byte(fmt.Sprint(i))))
+		prq.Push(&wantlist.Entry{Cid: elcid}, a)
+		prq.Push(&wantlist.Entry{Cid: elcid}, b)
+	}
+
+	first := prq.Pop()
+	second := prq.Pop()
+	if first == nil || second == nil || first.Target == second.Target {
+		t.Fatal("expected one task for each partner")
+	}
+	if task := prq.Pop(); task != nil {
+		t.Fatal("expected no task while both partners are at their allowance")
+	}
+
+	second.Done()
+	task := prq.Pop()
+	if task == nil || task.Target != second.Target {
+		t.Fatal("expected the next task to be for the partner that finished one")
+	}
+	if task := prq.Pop(); task != nil {
+		t.Fatal("expected no task while both partners are at their allowance")
+	}
+}
@@ -0,0 +1,90 @@
+package decision
+
+import (
+	"time"
+)
+
+// Quotas limits what the engine sends to its partners. Zero values mean no
+// limit.
+type Quotas struct {
+	// Rate is the number of bytes per second sent to all partners.
+	Rate uint64
+
+	// PeerRate is the number of bytes per second sent to a single partner.
+	PeerRate uint64
+
+	// MaxOutstanding is the number of blocks a single partner may have in
+	// flight. It applies on top of the allowance of the Strategy.
+	MaxOutstanding int
+}
+
+// QuotaStat counts how often the quotas were exceeded.
+type QuotaStat struct {
+	// RateExceeded is the number of times sending paused because of Rate.
+	RateExceeded uint64
+
+	// PeerRateExceeded is the number of times a partner was paused because
+	// of PeerRate.
+	PeerRateExceeded uint64
+
+	// OutstandingExceeded is the number of times a partner reached
+	// MaxOutstanding while it was waiting for more blocks.
+	OutstandingExceeded uint64
+}
+
+// rateLimiter is a token bucket holding up to one second worth of bytes.
+// Blocks are charged after they are taken, so the bucket can go into debt,
+// and take returns how long it takes to pay it back.
+type rateLimiter struct {
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(rate uint64) *rateLimiter {
+	if rate == 0 {
+		return nil
+	}
+	return &rateLimiter{
+		rate:   float64(rate),
+		tokens: float64(rate),
+		last:   time.Now(),
+	}
+}
+
+// take charges n bytes, and returns how long to wait before sending more.
+func (r *rateLimiter) take(n int, now time.Time) time.Duration {
+	if r == nil {
+		return 0
+	}
+
+	r.refill(now)
+	r.tokens -= float64(n)
+	return r.debt()
+}
+
+// wait returns how long to wait before sending more, without charging
+// anything.
+func (r *rateLimiter) wait(now time.Time) time.Duration {
+	if r == nil {
+		return 0
+	}
+
+	r.refill(now)
+	return r.debt()
+}
+
+func (r *rateLimiter) refill(now time.Time) {
+	r.tokens += now.Sub(r.last).Seconds() * r.rate
+	if r.tokens > r.rate {
+		r.tokens = r.rate
+	}
+	r.last = now
+}
+
+func (r *rateLimiter) debt() time.Duration {
+	if r.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-r.tokens / r.rate * float64(time.Second))
+}
@@ -0,0 +1,176 @@
+package decision
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gx/ipfs/QmeDA8gNhvRTsbrjEieay5wezupJDiky8xvCzDABbsGzmp/go-testutil"
+)
+
+func TestRateLimiter(t *testing.T) {
+	if wait := (*rateLimiter)(nil).take(1<<20, time.Now()); wait != 0 {
+		t.Fatal("expected no limit without a rate")
+	}
+
+	r := newRateLimiter(1000)
+	now := r.last
+
+	// a full second worth of bytes can be sent at once
+	if wait := r.take(1000, now); wait != 0 {
+		t.Fatalf("expected no wait within the burst, got %s", wait)
+	}
+	if wait := r.take(500, now); wait != 500*time.Millisecond {
+		t.Fatalf("expected to wait 500ms, got %s", wait)
+	}
+
+	// the debt is paid back over time
+	if wait := r.take(0, now.Add(500*time.Millisecond)); wait != 0 {
+		t.Fatalf("expected no wait once the debt is paid, got %s", wait)
+	}
+
+	// unused tokens do not pile up beyond one second
+	if wait := r.take(1500, now.Add(10*time.Second)); wait != 500*time.Millisecond {
+		t.Fatalf("expected the burst to be capped, got %s", wait)
+	}
+}
+
+func TestThrottledPartnerSkipped(t *testing.T) {
+	prq := newPRQ()
+	slow := testutil.RandPeerIDFatal(t)
+	other := testutil.RandPeerIDFatal(t)
+
+	pushBlocks(prq, slow, 5)
+	pushBlocks(prq, other, 5)
+	prq.throttle(slow, time.Now().Add(time.Hour))
+
+	for i := 0; i < 5; i++ {
+		task := prq.Pop()
+		if task == nil || task.Target != other {
+			t.Fatalf("expected task %d to be for the peer that is not throttled", i)
+		}
+		task.Done()
+	}
+	if task := prq.Pop(); task != nil {
+		t.Fatal("expected no task while the remaining peer is throttled")
+	}
+
+	prq.partners[slow].throttledUntil = time.Now()
+	prq.thawRound()
+	if task := prq.Pop(); task == nil || task.Target != slow {
+		t.Fatal("expected the peer to be served once its throttle expired")
+	}
+}
+
+func TestMaxOutstanding(t *testing.T) {
+	prq := newPRQ()
+	prq.setMaxOutstanding(2)
+	p := testutil.RandPeerIDFatal(t)
+	pushBlocks(prq, p, 5)
+
+	var tasks []*peerRequestTask
+	for i := 0; i < 2; i++ {
+		task := prq.Pop()
+		if task == nil {
+			t.Fatalf("expected task %d within the limit", i)
+		}
+		tasks = append(tasks, task)
+	}
+	if task := prq.Pop(); task != nil {
+		t.Fatal("expected no task while the peer is at its limit")
+	}
+	if n := prq.peerOutstandingExceeded(p); n != 1 {
+		t.Fatalf("expected the peer to have reached its limit once, got %d", n)
+	}
+	if n := prq.totalOutstandingExceeded(); n != 1 {
+		t.Fatalf("expected the limit to have been reached once, got %d", n)
+	}
+
+	tasks[0].Done()
+	if task := prq.Pop(); task == nil {
+		t.Fatal("expected a task once a block was sent")
+	}
+}
+
+func TestEnginePeerRateQuota(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	e := newEngine(ctx, "seed")
+	e.Engine.SetQuotas(Quotas{PeerRate: 1})
+	p := testutil.RandPeerIDFatal(t)
+
+	if wait := e.Engine.chargeQuotas(p, 1); wait != 0 {
+		t.Fatal("expected no global wait without a global rate")
+	}
+	e.Engine.chargeQuotas(p, 100)
+
+	if st := e.Engine.QuotaStat(); st.PeerRateExceeded != 1 || st.RateExceeded != 0 {
+		t.Fatalf("unexpected quota stats: %+v", st)
+	}
+	if r := e.Engine.LedgerForPeer(p); r.PeerRateExceeded != 1 {
+		t.Fatalf("expected the ledger to count the exceeded rate, got %d", r.PeerRateExceeded)
+	}
+}
+
+func TestEnginePeerRateChecked(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	e := newEngine(ctx, "seed")
+	e.Engine.SetQuotas(Quotas{PeerRate: 100})
+	p := testutil.RandPeerIDFatal(t)
+
+	if wait := e.Engine.peerWait(p); wait != 0 {
+		t.Fatalf("expected no wait before sending anything, got %s", wait)
+	}
+	e.Engine.chargeQuotas(p, 200)
+	if wait := e.Engine.peerWait(p); wait <= 0 {
+		t.Fatal("expected the peer to wait once over its rate")
+	}
+}
+
+func TestRequeue(t *testing.T) {
+	prq := newPRQ()
+	p := testutil.RandPeerIDFatal(t)
+	pushBlocks(prq, p, 1)
+
+	task := prq.Pop()
+	if task == nil {
+		t.Fatal("expected a task")
+	}
+	prq.requeue(task)
+
+	again := prq.Pop()
+	if again == nil || !again.Entry.Cid.Equals(task.Entry.Cid) {
+		t.Fatal("expected the requeued task to be popped again")
+	}
+	if active := prq.partners[p].active; active != 1 {
+		t.Fatalf("expected one active task, got %d", active)
+	}
+}
+
+func TestRequeueCancelled(t *testing.T) {
+	prq := newPRQ()
+	p := testutil.RandPeerIDFatal(t)
+	pushBlocks(prq, p, 1)
+
+	task := prq.Pop()
+	if task == nil {
+		t.Fatal("expected a task")
+	}
+	prq.Remove(task.Entry.Cid, p)
+	prq.requeue(task)
+
+	if again := prq.Pop(); again != nil {
+		t.Fatal("expected the cancelled task to be dropped")
+	}
+	partner := prq.partners[p]
+	if partner.active != 0 || partner.requests != 0 {
+		t.Fatalf("expected no active or requested tasks, got %d and %d", partner.active, partner.requests)
+	}
+
+	// wanting the block again queues it again
+	prq.Push(task.Entry, p)
+	if again := prq.Pop(); again == nil || !again.Entry.Cid.Equals(task.Entry.Cid) {
+		t.Fatal("expected the task to be popped again")
+	}
+}
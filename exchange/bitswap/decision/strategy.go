@@ -0,0 +1,214 @@
+package decision
+
+import (
+	"fmt"
+
+	peer "gx/ipfs/QmWNY7dV54ZDYmTA1ykVdwNCqC11mpU4zSUp6XDpLTH9eG/go-libp2p-peer"
+)
+
+// Names of the built-in strategies, as accepted by NewStrategy.
+const (
+	StrategyFairShare = "fair-share"
+	StrategyTitForTat = "tit-for-tat"
+	StrategyAllowlist = "allowlist"
+)
+
+// PartnerInfo is the state of a partner with pending requests, as seen by
+// a Strategy.
+type PartnerInfo struct {
+	Peer peer.ID
+
+	// Active is the number of blocks currently being sent to the partner.
+	Active int
+
+	// Requests is the number of blocks the partner requested that are
+	// waiting to be sent.
+	Requests int
+
+	// Queued is the length of the partner's task queue, including tasks
+	// that were cancelled but not dropped yet.
+	Queued int
+
+	// Frozen is the partner's freeze value. Partners are not served while
+	// it is above zero.
+	Frozen int
+
+	// BytesSent and BytesRecv are the totals exchanged with the partner.
+	BytesSent uint64
+	BytesRecv uint64
+}
+
+// DebtRatio returns the ratio of bytes sent to the partner to bytes
+// received from it.
+func (p PartnerInfo) DebtRatio() float64 {
+	dr := debtRatio{BytesSent: p.BytesSent, BytesRecv: p.BytesRecv}
+	return dr.Value()
+}
+
+// Strategy decides in which order partners are served, how many blocks
+// each may have in flight, and how long partners are frozen after they
+// cancel a block we were about to send them.
+//
+// Methods are called with the request queue locked and must not block.
+type Strategy interface {
+	// Less returns true if partner a should be served before partner b.
+	// Only partners with pending requests that are neither frozen nor at
+	// their allowance are compared.
+	Less(a, b PartnerInfo) bool
+
+	// Allowance returns the number of blocks that may be in flight to the
+	// partner at once, or zero for no limit.
+	Allowance(p PartnerInfo) int
+
+	// Freeze returns the new freeze value of a partner that cancelled a
+	// queued block.
+	Freeze(p PartnerInfo) int
+
+	// Thaw returns the freeze value of a frozen partner after a thaw
+	// round. Thaw rounds happen every 100ms while the engine is idle.
+	Thaw(p PartnerInfo) int
+}
+
+// NewStrategy returns the built-in strategy with the given name. An empty
+// name selects fair-share. The allowlist is only used by the allowlist
+// strategy, and must not be empty for it.
+func NewStrategy(name string, allowlist []peer.ID) (Strategy, error) {
+	switch name {
+	case "", StrategyFairShare:
+		return NewFairShareStrategy(), nil
+	case StrategyTitForTat:
+		return NewTitForTatStrategy(DefaultTitForTatAllowance), nil
+	case StrategyAllowlist:
+		if len(allowlist) == 0 {
+			return nil, fmt.Errorf("the %s strategy requires at least one peer", StrategyAllowlist)
+		}
+		return NewAllowlistStrategy(allowlist, NewFairShareStrategy()), nil
+	default:
+		return nil, fmt.Errorf("unknown bitswap strategy '%s'", name)
+	}
+}
+
+type fairShare struct{}
+
+// NewFairShareStrategy returns the default strategy, which serves
+// everyone, preferring partners with the fewest blocks in flight.
+func NewFairShareStrategy() Strategy {
+	return fairShare{}
+}
+
+func (fairShare) Less(a, b PartnerInfo) bool {
+	if a.Active == b.Active {
+		// sorting by Queued aids in cleaning out trash entries faster
+		// if we sorted instead by requests, one peer could potentially build up
+		// a huge number of cancelled entries in the queue resulting in a memory leak
+		return a.Queued > b.Queued
+	}
+	return a.Active < b.Active
+}
+
+func (fairShare) Allowance(PartnerInfo) int {
+	return 0
+}
+
+func (fairShare) Freeze(p PartnerInfo) int {
+	return p.Frozen + 1
+}
+
+func (fairShare) Thaw(p PartnerInfo) int {
+	return p.Frozen - (p.Frozen+1)/2
+}
+
+// DefaultTitForTatAllowance is the number of blocks the tit-for-tat
+// strategy lets a partner that owes us nothing have in flight.
+const DefaultTitForTatAllowance = 32
+
+type titForTat struct {
+	fairShare
+	allowance int
+}
+
+// NewTitForTatStrategy returns a strategy that favours partners who sent
+// us at least as much as we sent them. Partners are served by increasing
+// debt ratio, and a partner may have at most allowance / (1 + debt ratio)
+// blocks in flight, but always at least one. Partners in debt stay frozen
+// twice as long after a cancel.
+func NewTitForTatStrategy(allowance int) Strategy {
+	if allowance <= 0 {
+		allowance = DefaultTitForTatAllowance
+	}
+	return titForTat{allowance: allowance}
+}
+
+func (s titForTat) Less(a, b PartnerInfo) bool {
+	da, db := a.DebtRatio(), b.DebtRatio()
+	if da != db {
+		return da < db
+	}
+	return s.fairShare.Less(a, b)
+}
+
+func (s titForTat) Allowance(p PartnerInfo) int {
+	n := int(float64(s.allowance) / (1 + p.DebtRatio()))
+	if n < 1 {
+		return 1
+	}
+	return n
+}
+
+func (s titForTat) Freeze(p PartnerInfo) int {
+	if p.DebtRatio() > 1 {
+		return p.Frozen + 2
+	}
+	return p.Frozen + 1
+}
+
+type allowlist struct {
+	peers    map[peer.ID]struct{}
+	fallback Strategy
+}
+
+// NewAllowlistStrategy returns a strategy that always serves the given
+// peers before anyone else, without limiting how many blocks they have in
+// flight, and thaws them in a single round. Other partners, and the order
+// among allowlisted partners, are handled by the fallback strategy.
+func NewAllowlistStrategy(peers []peer.ID, fallback Strategy) Strategy {
+	s := allowlist{
+		peers:    make(map[peer.ID]struct{}, len(peers)),
+		fallback: fallback,
+	}
+	for _, p := range peers {
+		s.peers[p] = struct{}{}
+	}
+	return s
+}
+
+func (s allowlist) allowed(p peer.ID) bool {
+	_, ok := s.peers[p]
+	return ok
+}
+
+func (s allowlist) Less(a, b PartnerInfo) bool {
+	aa, ba := s.allowed(a.Peer), s.allowed(b.Peer)
+	if aa != ba {
+		return aa
+	}
+	return s.fallback.Less(a, b)
+}
+
+func (s allowlist) Allowance(p PartnerInfo) int {
+	if s.allowed(p.Peer) {
+		return 0
+	}
+	return s.fallback.Allowance(p)
+}
+
+func (s allowlist) Freeze(p PartnerInfo) int {
+	return s.fallback.Freeze(p)
+}
+
+func (s allowlist) Thaw(p PartnerInfo) int {
+	if s.allowed(p.Peer) {
+		return 0
+	}
+	return s.fallback.Thaw(p)
+}
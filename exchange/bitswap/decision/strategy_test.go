@@ -0,0 +1,114 @@
+package decision
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ipfs/go-ipfs/exchange/bitswap/wantlist"
+	u "gx/ipfs/QmPsAfmDBnZN3kZGSuNwvCNDZiHneERSKmRcFyG3UkvcT3/go-ipfs-util"
+	"gx/ipfs/QmWNY7dV54ZDYmTA1ykVdwNCqC11mpU4zSUp6XDpLTH9eG/go-libp2p-peer"
+	"gx/ipfs/QmeDA8gNhvRTsbrjEieay5wezupJDiky8xvCzDABbsGzmp/go-testutil"
+	cid "gx/ipfs/QmeSrf6pzut73u6zLQkRFQ3ygt3k6XFT2kjdYP8Tnkwwyg/go-cid"
+)
+
+func pushBlocks(prq *prq, p peer.ID, n int) {
+	for i := 0; i < n; i++ {
+		c := cid.NewCidV0(u.Hash([]byte(fmt.Sprint(p, i))))
+		prq.Push(&wantlist.Entry{Cid: c}, p)
+	}
+}
+
+func TestTitForTatPrefersLowDebt(t *testing.T) {
+	prq := newPRQWithStrategy(NewTitForTatStrategy(4))
+	leech := testutil.RandPeerIDFatal(t)
+	seed := testutil.RandPeerIDFatal(t)
+
+	pushBlocks(prq, leech, 10)
+	pushBlocks(prq, seed, 10)
+	prq.updateAccounting(leech, debtRatio{BytesSent: 1000, BytesRecv: 0})
+	prq.updateAccounting(seed, debtRatio{BytesSent: 0, BytesRecv: 1000})
+
+	// the seed owes us nothing and may have 4 blocks in flight
+	for i := 0; i < 4; i++ {
+		task := prq.Pop()
+		if task == nil || task.Target != seed {
+			t.Fatalf("expected task %d to be for the peer with the lowest debt", i)
+		}
+	}
+
+	// the leech is deep in debt and only gets a single block at a time
+	task := prq.Pop()
+	if task == nil || task.Target != leech {
+		t.Fatal("expected a task for the peer in debt once the other one is at its allowance")
+	}
+	if task := prq.Pop(); task != nil {
+		t.Fatalf("expected no more tasks while both peers are at their allowance, got one for %s", task.Target)
+	}
+
+	task.Done()
+	if task := prq.Pop(); task == nil || task.Target != leech {
+		t.Fatal("expected a task for the peer in debt once its block was sent")
+	}
+}
+
+func TestAllowlistServedFirst(t *testing.T) {
+	a := testutil.RandPeerIDFatal(t)
+	b := testutil.RandPeerIDFatal(t)
+	friend := testutil.RandPeerIDFatal(t)
+
+	prq := newPRQWithStrategy(NewAllowlistStrategy([]peer.ID{friend}, NewFairShareStrategy()))
+	pushBlocks(prq, a, 5)
+	pushBlocks(prq, b, 5)
+	pushBlocks(prq, friend, 5)
+
+	for i := 0; i < 5; i++ {
+		task := prq.Pop()
+		if task == nil || task.Target != friend {
+			t.Fatalf("expected task %d to be for the allowlisted peer", i)
+		}
+	}
+
+	task := prq.Pop()
+	if task == nil || task.Target == friend {
+		t.Fatal("expected other peers to be served once the allowlisted peer is done")
+	}
+}
+
+func TestAllowlistThawsInOneRound(t *testing.T) {
+	other := testutil.RandPeerIDFatal(t)
+	friend := testutil.RandPeerIDFatal(t)
+
+	prq := newPRQWithStrategy(NewAllowlistStrategy([]peer.ID{friend}, NewFairShareStrategy()))
+	for _, p := range []peer.ID{other, friend} {
+		pushBlocks(prq, p, 5)
+		for i := 0; i < 3; i++ {
+			prq.Remove(cid.NewCidV0(u.Hash([]byte(fmt.Sprint(p, i)))), p)
+		}
+	}
+
+	prq.thawRound()
+	if prq.partners[friend].freezeVal != 0 {
+		t.Fatal("expected the allowlisted peer to be thawed")
+	}
+	if prq.partners[other].freezeVal == 0 {
+		t.Fatal("expected the other peer to still be frozen")
+	}
+}
+
+func TestNewStrategy(t *testing.T) {
+	for _, name := range []string{"", StrategyFairShare, StrategyTitForTat} {
+		if _, err := NewStrategy(name, nil); err != nil {
+			t.Fatalf("strategy %q: %s", name, err)
+		}
+	}
+
+	if _, err := NewStrategy(StrategyAllowlist, nil); err == nil {
+		t.Fatal("expected the allowlist strategy to require peers")
+	}
+	if _, err := NewStrategy(StrategyAllowlist, []peer.ID{testutil.RandPeerIDFatal(t)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewStrategy("generous", nil); err == nil {
+		t.Fatal("expected an error for an unknown strategy")
+	}
+}
@@ -28,6 +28,11 @@ type BitSwapMessage interface {
 	// AddEntry adds an entry to the Wantlist.
 	AddEntry(key *cid.Cid, priority int)
 
+	// AddWantEntry adds an entry of the given type to the Wantlist. If
+	// sendDontHave is true, the receiver answers with a DONT_HAVE when it
+	// does not have the block.
+	AddWantEntry(key *cid.Cid, priority int, wantType WantType, sendDontHave bool)
+
 	Cancel(key *cid.Cid)
 
 	Empty() bool
@@ -36,6 +41,20 @@ type BitSwapMessage interface {
 	Full() bool
 
 	AddBlock(blocks.Block)
+
+	// Haves returns the keys of the blocks the sender told us it has.
+	Haves() []*cid.Cid
+
+	// DontHaves returns the keys of the blocks the sender told us it does
+	// not have.
+	DontHaves() []*cid.Cid
+
+	// AddHave adds a HAVE response for the given key.
+	AddHave(*cid.Cid)
+
+	// AddDontHave adds a DONT_HAVE response for the given key.
+	AddDontHave(*cid.Cid)
+
 	Exportable
 
 	Loggable() map[string]interface{}
@@ -44,14 +63,35 @@ type BitSwapMessage interface {
 type Exportable interface {
 	ToProtoV0() *pb.Message
 	ToProtoV1() *pb.Message
+	ToProtoV2() *pb.Message
 	ToNetV0(w io.Writer) error
 	ToNetV1(w io.Writer) error
+	ToNetV2(w io.Writer) error
 }
 
+// WantType tells whether a wantlist entry asks for a block, or only whether
+// the peer has it.
+type WantType int32
+
+const (
+	// WantBlock asks the peer to send the block.
+	WantBlock = WantType(pb.Message_Wantlist_Block)
+
+	// WantHave asks the peer to answer with a HAVE if it has the block.
+	// Peers that only speak bitswap 1.1.0 or older receive it as WantBlock.
+	WantHave = WantType(pb.Message_Wantlist_Have)
+)
+
 type impl struct {
-	full     bool
-	wantlist map[string]Entry
-	blocks   map[string]blocks.Block
+	full      bool
+	wantlist  map[string]Entry
+	blocks    map[string]blocks.Block
+	presences map[string]presence
+}
+
+type presence struct {
+	c    *cid.Cid
+	have bool
 }
 
 func New(full bool) BitSwapMessage {
@@ -60,15 +100,18 @@ func New(full bool) BitSwapMessage {
 
 func newMsg(full bool) *impl {
 	return &impl{
-		blocks:   make(map[string]blocks.Block),
-		wantlist: make(map[string]Entry),
-		full:     full,
+		blocks:    make(map[string]blocks.Block),
+		wantlist:  make(map[string]Entry),
+		presences: make(map[string]presence),
+		full:      full,
 	}
 }
 
 type Entry struct {
 	*wantlist.Entry
-	Cancel bool
+	Cancel       bool
+	WantType     WantType
+	SendDontHave bool
 }
 
 func newMessageFromProto(pbm pb.Message) (BitSwapMessage, error) {
@@ -78,7 +121,7 @@ func newMessageFromProto(pbm pb.Message) (BitSwapMessage, error) {
 		if err != nil {
 			return nil, fmt.Errorf("incorrectly formatted cid in wantlist: %s", err)
 		}
-		m.addEntry(c, int(e.GetPriority()), e.GetCancel())
+		m.addEntry(c, int(e.GetPriority()), e.GetCancel(), WantType(e.GetWantType()), e.GetSendDontHave())
 	}
 
 	// deprecated
@@ -108,6 +151,22 @@ func newMessageFromProto(pbm pb.Message) (BitSwapMessage, error) {
 		m.AddBlock(blk)
 	}
 
+	for _, bp := range pbm.GetBlockPresences() {
+		c, err := cid.Cast(bp.GetCid())
+		if err != nil {
+			return nil, fmt.Errorf("incorrectly formatted cid in block presence: %s", err)
+		}
+
+		switch bp.GetType() {
+		case pb.Message_Have:
+			m.AddHave(c)
+		case pb.Message_DontHave:
+			m.AddDontHave(c)
+		default:
+			return nil, fmt.Errorf("unknown block presence type: %d", bp.GetType())
+		}
+	}
+
 	return m, nil
 }
 
@@ -116,7 +175,7 @@ func (m *impl) Full() bool {
 }
 
 func (m *impl) Empty() bool {
-	return len(m.blocks) == 0 && len(m.wantlist) == 0
+	return len(m.blocks) == 0 && len(m.wantlist) == 0 && len(m.presences) == 0
 }
 
 func (m *impl) Wantlist() []Entry {
@@ -135,28 +194,55 @@ func (m *impl) Blocks() []blocks.Block {
 	return bs
 }
 
+func (m *impl) Haves() []*cid.Cid {
+	return m.presenceKeys(true)
+}
+
+func (m *impl) DontHaves() []*cid.Cid {
+	return m.presenceKeys(false)
+}
+
+func (m *impl) presenceKeys(have bool) []*cid.Cid {
+	var out []*cid.Cid
+	for _, p := range m.presences {
+		if p.have == have {
+			out = append(out, p.c)
+		}
+	}
+	return out
+}
+
 func (m *impl) Cancel(k *cid.Cid) {
 	delete(m.wantlist, k.KeyString())
-	m.addEntry(k, 0, true)
+	m.addEntry(k, 0, true, WantBlock, false)
 }
 
 func (m *impl) AddEntry(k *cid.Cid, priority int) {
-	m.addEntry(k, priority, false)
+	m.addEntry(k, priority, false, WantBlock, false)
+}
+
+func (m *impl) AddWantEntry(k *cid.Cid, priority int, wantType WantType, sendDontHave bool) {
+	m.addEntry(k, priority, false, wantType, sendDontHave)
 }
 
-func (m *impl) addEntry(c *cid.Cid, priority int, cancel bool) {
+func (m *impl) addEntry(c *cid.Cid, priority int, cancel bool, wantType WantType, sendDontHave bool) {
 	k := c.KeyString()
 	e, exists := m.wantlist[k]
 	if exists {
 		e.Priority = priority
 		e.Cancel = cancel
+		e.WantType = wantType
+		e.SendDontHave = sendDontHave
+		m.wantlist[k] = e
 	} else {
 		m.wantlist[k] = Entry{
 			Entry: &wantlist.Entry{
 				Cid:      c,
 				Priority: priority,
 			},
-			Cancel: cancel,
+			Cancel:       cancel,
+			WantType:     wantType,
+			SendDontHave: sendDontHave,
 		}
 	}
 }
@@ -165,6 +251,14 @@ func (m *impl) AddBlock(b blocks.Block) {
 	m.blocks[b.Cid().KeyString()] = b
 }
 
+func (m *impl) AddHave(c *cid.Cid) {
+	m.presences[c.KeyString()] = presence{c: c, have: true}
+}
+
+func (m *impl) AddDontHave(c *cid.Cid) {
+	m.presences[c.KeyString()] = presence{c: c, have: false}
+}
+
 func FromNet(r io.Reader) (BitSwapMessage, error) {
 	pbr := ggio.NewDelimitedReader(r, inet.MessageSizeMax)
 	return FromPBReader(pbr)
@@ -225,6 +319,38 @@ func (m *impl) ToProtoV1() *pb.Message {
 	return pbm
 }
 
+// ToProtoV2 is like ToProtoV1, but also carries the types of the wantlist
+// entries and the block presences. Older versions drop both.
+func (m *impl) ToProtoV2() *pb.Message {
+	pbm := m.ToProtoV1()
+	pbm.Wantlist.Entries = pbm.Wantlist.Entries[:0]
+	for _, e := range m.wantlist {
+		pbe := &pb.Message_Wantlist_Entry{
+			Block:    proto.String(e.Cid.KeyString()),
+			Priority: proto.Int32(int32(e.Priority)),
+			Cancel:   proto.Bool(e.Cancel),
+		}
+		if !e.Cancel {
+			pbe.WantType = pb.Message_Wantlist_WantType(e.WantType).Enum()
+			pbe.SendDontHave = proto.Bool(e.SendDontHave)
+		}
+		pbm.Wantlist.Entries = append(pbm.Wantlist.Entries, pbe)
+	}
+
+	pbm.BlockPresences = make([]*pb.Message_BlockPresence, 0, len(m.presences))
+	for _, p := range m.presences {
+		typ := pb.Message_DontHave
+		if p.have {
+			typ = pb.Message_Have
+		}
+		pbm.BlockPresences = append(pbm.BlockPresences, &pb.Message_BlockPresence{
+			Cid:  p.c.Bytes(),
+			Type: typ.Enum(),
+		})
+	}
+	return pbm
+}
+
 func (m *impl) ToNetV0(w io.Writer) error {
 	pbw := ggio.NewDelimitedWriter(w)
 
@@ -237,13 +363,21 @@ func (m *impl) ToNetV1(w io.Writer) error {
 	return pbw.WriteMsg(m.ToProtoV1())
 }
 
+func (m *impl) ToNetV2(w io.Writer) error {
+	pbw := ggio.NewDelimitedWriter(w)
+
+	return pbw.WriteMsg(m.ToProtoV2())
+}
+
 func (m *impl) Loggable() map[string]interface{} {
 	blocks := make([]string, 0, len(m.blocks))
 	for _, v := range m.blocks {
 		blocks = append(blocks, v.Cid().String())
 	}
 	return map[string]interface{}{
-		"blocks": blocks,
-		"wants":  m.Wantlist(),
+		"blocks":    blocks,
+		"wants":     m.Wantlist(),
+		"haves":     m.Haves(),
+		"dontHaves": m.DontHaves(),
 	}
 }
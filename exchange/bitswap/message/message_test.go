@@ -198,3 +198,67 @@ func TestDuplicates(t *testing.T) {
 		t.Fatal("Duplicate in BitSwapMessage")
 	}
 }
+
+func TestToAndFromNetV2PreservesPresence(t *testing.T) {
+	original := New(false)
+	original.AddWantEntry(mkFakeCid("want-have"), 2, WantHave, true)
+	original.AddWantEntry(mkFakeCid("want-block"), 1, WantBlock, true)
+	original.AddHave(mkFakeCid("have"))
+	original.AddDontHave(mkFakeCid("dont-have"))
+
+	buf := new(bytes.Buffer)
+	if err := original.ToNetV2(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	copied, err := FromNet(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, e := range copied.Wantlist() {
+		switch {
+		case e.Cid.Equals(mkFakeCid("want-have")):
+			if e.WantType != WantHave || !e.SendDontHave {
+				t.Fatalf("want-have entry not preserved: %+v", e)
+			}
+		case e.Cid.Equals(mkFakeCid("want-block")):
+			if e.WantType != WantBlock || !e.SendDontHave {
+				t.Fatalf("want-block entry not preserved: %+v", e)
+			}
+		default:
+			t.Fatalf("unexpected entry %s", e.Cid)
+		}
+	}
+
+	if h := copied.Haves(); len(h) != 1 || !h[0].Equals(mkFakeCid("have")) {
+		t.Fatalf("unexpected haves: %v", h)
+	}
+	if dh := copied.DontHaves(); len(dh) != 1 || !dh[0].Equals(mkFakeCid("dont-have")) {
+		t.Fatalf("unexpected dont haves: %v", dh)
+	}
+}
+
+func TestToNetV1DropsPresence(t *testing.T) {
+	original := New(false)
+	original.AddWantEntry(mkFakeCid("want-have"), 1, WantHave, true)
+	original.AddHave(mkFakeCid("have"))
+
+	buf := new(bytes.Buffer)
+	if err := original.ToNetV1(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	copied, err := FromNet(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wl := copied.Wantlist()
+	if len(wl) != 1 || wl[0].WantType != WantBlock || wl[0].SendDontHave {
+		t.Fatalf("expected older peers to receive a plain want, got %+v", wl)
+	}
+	if len(copied.Haves()) != 0 {
+		t.Fatal("expected block presences to be dropped")
+	}
+}
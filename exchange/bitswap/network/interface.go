@@ -17,6 +17,10 @@ var (
 	ProtocolBitswapNoVers protocol.ID = "/ipfs/bitswap"
 
 	ProtocolBitswap protocol.ID = "/ipfs/bitswap/1.1.0"
+
+	// ProtocolBitswapPresence adds want-have entries and HAVE / DONT_HAVE
+	// responses to ProtocolBitswap
+	ProtocolBitswapPresence protocol.ID = "/ipfs/bitswap/1.2.0"
 )
 
 // BitSwapNetwork provides network connectivity for BitSwap sessions
@@ -30,6 +30,7 @@ func NewFromIpfsHost(host host.Host, r routing.ContentRouting) BitSwapNetwork {
 		host:    host,
 		routing: r,
 	}
+	host.SetStreamHandler(ProtocolBitswapPresence, bitswapNetwork.handleNewStream)
 	host.SetStreamHandler(ProtocolBitswap, bitswapNetwork.handleNewStream)
 	host.SetStreamHandler(ProtocolBitswapOne, bitswapNetwork.handleNewStream)
 	host.SetStreamHandler(ProtocolBitswapNoVers, bitswapNetwork.handleNewStream)
@@ -76,6 +77,11 @@ func msgToStream(ctx context.Context, s inet.Stream, msg bsmsg.BitSwapMessage) e
 	}
 
 	switch s.Protocol() {
+	case ProtocolBitswapPresence:
+		if err := msg.ToNetV2(s); err != nil {
+			log.Debugf("error: %s", err)
+			return err
+		}
 	case ProtocolBitswap:
 		if err := msg.ToNetV1(s); err != nil {
 			log.Debugf("error: %s", err)
@@ -106,7 +112,7 @@ func (bsnet *impl) NewMessageSender(ctx context.Context, p peer.ID) (MessageSend
 }
 
 func (bsnet *impl) newStreamToPeer(ctx context.Context, p peer.ID) (inet.Stream, error) {
-	return bsnet.host.NewStream(ctx, p, ProtocolBitswap, ProtocolBitswapOne, ProtocolBitswapNoVers)
+	return bsnet.host.NewStream(ctx, p, ProtocolBitswapPresence, ProtocolBitswap, ProtocolBitswapOne, ProtocolBitswapNoVers)
 }
 
 func (bsnet *impl) SendMessage(
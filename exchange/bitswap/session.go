@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	exchange "github.com/ipfs/go-ipfs/exchange"
 	notifications "github.com/ipfs/go-ipfs/exchange/bitswap/notifications"
 
 	logging "gx/ipfs/QmSpJByNKFX1sCsHBEp3R73FL4NF6FnQTEGyNAXHm2GS52/go-log"
@@ -28,18 +29,32 @@ type Session struct {
 
 	bs           *Bitswap
 	incoming     chan blkRecv
-	newReqs      chan []*cid.Cid
+	presences    chan presenceRecv
+	newReqs      chan wantReq
 	cancelKeys   chan []*cid.Cid
 	interestReqs chan interestReq
+	statReqs     chan chan *SessionStat
 
 	interest  *lru.Cache
 	liveWants map[string]time.Time
 
+	// priorities holds the priorities requested for wanted blocks, if any
+	priorities exchange.Priorities
+
+	// presence holds the HAVE (true) and DONT_HAVE (false) responses
+	// received for live wants, and blockReqs the peers each live want was
+	// requested from, if it was not broadcast
+	presence  map[string]map[peer.ID]bool
+	blockReqs map[string]map[peer.ID]struct{}
+	nextGroup int
+	split     *splitter
+
 	tick          *time.Timer
 	baseTickDelay time.Duration
 
 	latTotal time.Duration
 	fetchcnt int
+	dupcnt   int
 
 	notif notifications.PubSub
 
@@ -55,13 +70,18 @@ func (bs *Bitswap) NewSession(ctx context.Context) *Session {
 	s := &Session{
 		activePeers:   make(map[peer.ID]struct{}),
 		liveWants:     make(map[string]time.Time),
-		newReqs:       make(chan []*cid.Cid),
+		presence:      make(map[string]map[peer.ID]bool),
+		blockReqs:     make(map[string]map[peer.ID]struct{}),
+		newReqs:       make(chan wantReq),
+		priorities:    make(exchange.Priorities),
 		cancelKeys:    make(chan []*cid.Cid),
 		tofetch:       newCidQueue(),
 		interestReqs:  make(chan interestReq),
+		statReqs:      make(chan chan *SessionStat),
 		ctx:           ctx,
 		bs:            bs,
 		incoming:      make(chan blkRecv),
+		presences:     make(chan presenceRecv),
 		notif:         notifications.New(),
 		uuid:          loggables.Uuid("GetBlockRequest"),
 		baseTickDelay: time.Millisecond * 500,
@@ -74,6 +94,7 @@ func (bs *Bitswap) NewSession(ctx context.Context) *Session {
 	s.interest = cache
 
 	bs.sessLk.Lock()
+	s.split = newSplitter(bs.splitRequests)
 	bs.sessions = append(bs.sessions, s)
 	bs.sessLk.Unlock()
 
@@ -106,6 +127,19 @@ func (s *Session) receiveBlockFrom(from peer.ID, blk blocks.Block) {
 	}
 }
 
+type presenceRecv struct {
+	from peer.ID
+	c    *cid.Cid
+	have bool
+}
+
+func (s *Session) receivePresenceFrom(from peer.ID, c *cid.Cid, have bool) {
+	select {
+	case s.presences <- presenceRecv{from: from, c: c, have: have}:
+	case <-s.ctx.Done():
+	}
+}
+
 type interestReq struct {
 	c    *cid.Cid
 	resp chan bool
@@ -177,9 +211,15 @@ func (s *Session) run(ctx context.Context) {
 			s.receiveBlock(ctx, blk.blk)
 
 			s.resetTick()
-		case keys := <-s.newReqs:
+		case pr := <-s.presences:
+			s.receivePresence(ctx, pr)
+		case req := <-s.newReqs:
+			keys := req.keys
 			for _, k := range keys {
 				s.interest.Add(k.KeyString(), nil)
+				if p, ok := req.priorities.Get(k); ok {
+					s.priorities.Set(k, p)
+				}
 			}
 			if len(s.liveWants) < activeWantsLimit {
 				toadd := activeWantsLimit - len(s.liveWants)
@@ -208,7 +248,8 @@ func (s *Session) run(ctx context.Context) {
 			}
 
 			// Broadcast these keys to everyone we're connected to
-			s.bs.wm.WantBlocks(ctx, live, nil, s.id)
+			s.bs.wm.WantBlocks(exchange.WithPriorities(ctx, s.priorities), live, nil, s.id)
+			s.split.timeout(len(s.activePeersArr))
 
 			if len(live) > 0 {
 				go func(k *cid.Cid) {
@@ -227,6 +268,8 @@ func (s *Session) run(ctx context.Context) {
 			s.addActivePeer(p)
 		case lwchk := <-s.interestReqs:
 			lwchk.resp <- s.cidIsWanted(lwchk.c)
+		case resp := <-s.statReqs:
+			resp <- s.stat()
 		case <-ctx.Done():
 			s.tick.Stop()
 			s.bs.removeSession(s)
@@ -256,29 +299,209 @@ func (s *Session) receiveBlock(ctx context.Context, blk blocks.Block) {
 		tval, ok := s.liveWants[ks]
 		if ok {
 			s.latTotal += time.Since(tval)
+			s.split.receivedBlock(len(s.activePeersArr), false, time.Since(tval))
 			delete(s.liveWants, ks)
+			delete(s.presence, ks)
+			delete(s.blockReqs, ks)
 		} else {
 			s.tofetch.Remove(c)
 		}
+		delete(s.priorities, ks)
 		s.fetchcnt++
 		s.notif.Publish(blk)
 
 		if next := s.tofetch.Pop(); next != nil {
 			s.wantBlocks(ctx, []*cid.Cid{next})
 		}
+	} else {
+		// the block is in the interest cache, so it was received before
+		s.dupcnt++
+		s.split.receivedBlock(len(s.activePeersArr), true, 0)
 	}
 }
 
+// wantBlocks makes the given cids live wants. When requests are not split,
+// the blocks are requested from all the session's peers, or everyone if it
+// has none yet.
+//
+// Otherwise, until the session knows some peers, everyone is only asked
+// whether they have the blocks, and each block is requested from the first
+// peer that answers HAVE. Afterwards the session's peers are divided into
+// groups by the splitter, each block is requested from one group, and the
+// other peers are asked whether they have it, in case that group does not.
 func (s *Session) wantBlocks(ctx context.Context, ks []*cid.Cid) {
+	ctx = exchange.WithPriorities(ctx, s.priorities)
+	now := time.Now()
+	for _, c := range ks {
+		s.liveWants[c.KeyString()] = now
+	}
+
+	if !s.split.adaptive {
+		s.bs.wm.WantBlocks(ctx, ks, s.activePeersArr, s.id)
+		return
+	}
+
+	if len(s.activePeersArr) == 0 {
+		s.bs.wm.WantHaves(ctx, ks, nil, s.id)
+		return
+	}
+
+	groups := s.split.groups(s.activePeersArr)
+	wants := make([][]*cid.Cid, len(groups))
 	for _, c := range ks {
-		s.liveWants[c.KeyString()] = time.Now()
+		g := s.nextGroup % len(groups)
+		s.nextGroup++
+		wants[g] = append(wants[g], c)
+
+		reqs := make(map[peer.ID]struct{}, len(groups[g]))
+		for _, p := range groups[g] {
+			reqs[p] = struct{}{}
+		}
+		s.blockReqs[c.KeyString()] = reqs
+	}
+
+	for g, cs := range wants {
+		if len(cs) == 0 {
+			continue
+		}
+		s.bs.wm.WantBlocks(ctx, cs, groups[g], s.id)
+
+		if len(groups) > 1 {
+			others := make([]peer.ID, 0, len(s.activePeersArr)-len(groups[g]))
+			for i, group := range groups {
+				if i != g {
+					others = append(others, group...)
+				}
+			}
+			s.bs.wm.WantHaves(ctx, cs, others, s.id)
+		}
+	}
+}
+
+// receivePresence records a HAVE or DONT_HAVE for a live want, and requests
+// the block from a peer that has it if no suitable peer was asked yet.
+func (s *Session) receivePresence(ctx context.Context, pr presenceRecv) {
+	ctx = exchange.WithPriorities(ctx, s.priorities)
+	ks := pr.c.KeyString()
+	if _, ok := s.liveWants[ks]; !ok {
+		return
+	}
+
+	peers, ok := s.presence[ks]
+	if !ok {
+		peers = make(map[peer.ID]bool)
+		s.presence[ks] = peers
+	}
+	peers[pr.from] = pr.have
+
+	if pr.have {
+		s.addActivePeer(pr.from)
+	} else if reqs, ok := s.blockReqs[ks]; ok {
+		// a peer we asked for the block does not have it
+		delete(reqs, pr.from)
+		if len(reqs) == 0 {
+			delete(s.blockReqs, ks)
+		}
+	}
+
+	if _, ok := s.blockReqs[ks]; ok {
+		return
+	}
+	for p, have := range peers {
+		if have {
+			s.blockReqs[ks] = map[peer.ID]struct{}{p: {}}
+			s.bs.wm.WantBlocks(ctx, []*cid.Cid{pr.c}, []peer.ID{p}, s.id)
+			return
+		}
+	}
+
+	for _, p := range s.activePeersArr {
+		if _, ok := peers[p]; !ok {
+			return
+		}
+	}
+	// none of the session's peers has the block, ask everyone else
+	if s.split.adaptive {
+		s.bs.wm.WantHaves(ctx, []*cid.Cid{pr.c}, nil, s.id)
+	} else {
+		s.bs.wm.WantBlocks(ctx, []*cid.Cid{pr.c}, nil, s.id)
+	}
+}
+
+// SessionStat is a snapshot of the state of a session.
+type SessionStat struct {
+	ID  uint64
+	Tag string
+
+	// ActivePeers are the peers the session fetches blocks from
+	ActivePeers []string
+
+	// Wanted holds the live wants of the session, followed by the blocks
+	// waiting to be wanted
+	Wanted []*cid.Cid
+
+	BlocksReceived  int
+	DupBlksReceived int
+	AverageLatency  time.Duration
+
+	// Split is the number of groups the active peers are divided into to
+	// request blocks, one meaning every block is requested from all of
+	// them, and zero that the session has no active peers yet
+	Split int
+}
+
+// Stat returns the state of the session. It fails if the session is over.
+func (s *Session) Stat() (*SessionStat, error) {
+	resp := make(chan *SessionStat, 1)
+	select {
+	case s.statReqs <- resp:
+	case <-s.ctx.Done():
+		return nil, s.ctx.Err()
+	}
+
+	select {
+	case st := <-resp:
+		return st, nil
+	case <-s.ctx.Done():
+		return nil, s.ctx.Err()
+	}
+}
+
+// stat runs in the session's run loop.
+func (s *Session) stat() *SessionStat {
+	st := &SessionStat{
+		ID:              s.id,
+		Tag:             s.tag,
+		ActivePeers:     make([]string, 0, len(s.activePeersArr)),
+		Wanted:          make([]*cid.Cid, 0, len(s.liveWants)+s.tofetch.Len()),
+		BlocksReceived:  s.fetchcnt,
+		DupBlksReceived: s.dupcnt,
+		Split:           len(s.split.groups(s.activePeersArr)),
+	}
+	if s.fetchcnt > 0 {
+		st.AverageLatency = s.latTotal / time.Duration(s.fetchcnt)
+	}
+
+	for _, p := range s.activePeersArr {
+		st.ActivePeers = append(st.ActivePeers, p.Pretty())
+	}
+	for ks := range s.liveWants {
+		c, err := cid.Cast([]byte(ks))
+		if err != nil {
+			continue
+		}
+		st.Wanted = append(st.Wanted, c)
 	}
-	s.bs.wm.WantBlocks(ctx, ks, s.activePeersArr, s.id)
+	st.Wanted = append(st.Wanted, s.tofetch.Cids()...)
+	return st
 }
 
 func (s *Session) cancel(keys []*cid.Cid) {
 	for _, c := range keys {
 		s.tofetch.Remove(c)
+		if _, ok := s.liveWants[c.KeyString()]; !ok {
+			delete(s.priorities, c.KeyString())
+		}
 	}
 }
 
@@ -289,9 +512,21 @@ func (s *Session) cancelWants(keys []*cid.Cid) {
 	}
 }
 
+// wantReq is a request for blocks, with the priorities set in the context
+// of the request.
+type wantReq struct {
+	keys       []*cid.Cid
+	priorities exchange.Priorities
+}
+
 func (s *Session) fetch(ctx context.Context, keys []*cid.Cid) {
+	req := wantReq{
+		keys:       keys,
+		priorities: exchange.PrioritiesFromContext(ctx),
+	}
+
 	select {
-	case s.newReqs <- keys:
+	case s.newReqs <- req:
 	case <-ctx.Done():
 	case <-s.ctx.Done():
 	}
@@ -349,6 +584,17 @@ func (cq *cidQueue) Has(c *cid.Cid) bool {
 	return cq.eset.Has(c)
 }
 
+// Cids returns the cids in the queue, in order.
+func (cq *cidQueue) Cids() []*cid.Cid {
+	out := make([]*cid.Cid, 0, cq.Len())
+	for _, c := range cq.elems {
+		if cq.eset.Has(c) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
 func (cq *cidQueue) Len() int {
 	return cq.eset.Len()
 }
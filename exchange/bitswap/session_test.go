@@ -285,3 +285,105 @@ func TestMultipleSessions(t *testing.T) {
 	}
 	_ = blkch
 }
+
+func TestSessionPresenceAvoidsDuplicates(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	vnet := getVirtualNetwork()
+	sesgen := NewTestSessionGenerator(vnet)
+	defer sesgen.Close()
+	bgen := blocksutil.NewBlockGenerator()
+
+	inst := sesgen.Instances(4)
+
+	blks := bgen.Blocks(10)
+	var cids []*cid.Cid
+	for _, blk := range blks {
+		cids = append(cids, blk.Cid())
+	}
+	for _, is := range inst[1:] {
+		if err := is.Blockstore().PutMany(blks); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ses := inst[0].Exchange.NewSession(ctx)
+	ch, err := ses.GetBlocks(ctx, cids)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []blocks.Block
+	for b := range ch {
+		got = append(got, b)
+	}
+	if err := assertBlockLists(got, blks); err != nil {
+		t.Fatal(err)
+	}
+
+	// every seed answered HAVE, but each block was only requested once
+	if dups := inst[0].Exchange.counters.dupBlocksRecvd; dups != 0 {
+		t.Fatalf("expected no duplicate blocks, got %d", dups)
+	}
+}
+
+func TestSessionStat(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	vnet := getVirtualNetwork()
+	sesgen := NewTestSessionGenerator(vnet)
+	defer sesgen.Close()
+	bgen := blocksutil.NewBlockGenerator()
+
+	inst := sesgen.Instances(2)
+	a, b := inst[0], inst[1]
+
+	blks := bgen.Blocks(5)
+	if err := b.Blockstore().PutMany(blks); err != nil {
+		t.Fatal(err)
+	}
+
+	ses := a.Exchange.NewSession(ctx)
+	for _, blk := range blks {
+		if _, err := ses.GetBlock(ctx, blk.Cid()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	missing := bgen.Next()
+	wctx, wcancel := context.WithCancel(ctx)
+	defer wcancel()
+	if _, err := ses.GetBlocks(wctx, []*cid.Cid{missing.Cid()}); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond * 50)
+
+	st, err := ses.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.BlocksReceived != len(blks) {
+		t.Fatalf("expected %d blocks received, got %d", len(blks), st.BlocksReceived)
+	}
+	if len(st.ActivePeers) != 1 || st.ActivePeers[0] != b.Peer.Pretty() {
+		t.Fatalf("expected the seed to be the only active peer, got %v", st.ActivePeers)
+	}
+	if len(st.Wanted) != 1 || !st.Wanted[0].Equals(missing.Cid()) {
+		t.Fatalf("expected the missing block to be wanted, got %v", st.Wanted)
+	}
+	if st.Tag == "" || st.AverageLatency <= 0 {
+		t.Fatalf("expected a tag and a latency, got %+v", st)
+	}
+
+	stats := a.Exchange.SessionStats()
+	if len(stats) != 1 || stats[0].ID != st.ID {
+		t.Fatalf("expected the session to be listed, got %v", stats)
+	}
+
+	cancel()
+	if _, err := ses.Stat(); err == nil {
+		t.Fatal("expected an error once the session is over")
+	}
+}
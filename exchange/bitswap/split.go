@@ -0,0 +1,131 @@
+package bitswap
+
+import (
+	"time"
+
+	peer "gx/ipfs/QmWNY7dV54ZDYmTA1ykVdwNCqC11mpU4zSUp6XDpLTH9eG/go-libp2p-peer"
+)
+
+const (
+	// maxSplit is the largest number of groups the peers of a session are
+	// split into. Each want is sent to the peers of a single group.
+	maxSplit = 16
+
+	// minReceivedToAdjustSplit is the number of blocks a session receives
+	// between two adjustments of its split factor.
+	minReceivedToAdjustSplit = 8
+
+	// maxAcceptableDupes is the ratio of duplicate blocks above which
+	// wants are sent to fewer peers.
+	maxAcceptableDupes = 0.4
+
+	// minDupesToTryLessSplits is the ratio of duplicate blocks below which
+	// wants are sent to more peers, if blocks are getting slower to arrive.
+	minDupesToTryLessSplits = 0.2
+)
+
+// splitter decides which of the peers of a session each want is sent to.
+// The peers are divided into split groups, and wants are assigned to the
+// groups in turn. The split factor goes up when many duplicate blocks are
+// received, and down when blocks get slower to arrive or a want times out.
+//
+// splitter is owned by the session's run loop.
+type splitter struct {
+	split    int
+	adaptive bool
+
+	received int
+	dups     int
+	latTotal time.Duration
+	lastLat  time.Duration
+}
+
+// newSplitter returns a splitter that starts by sending every want to a
+// single peer. If adaptive is false, it always broadcasts wants to all the
+// peers instead.
+func newSplitter(adaptive bool) *splitter {
+	if !adaptive {
+		return &splitter{split: 1}
+	}
+	return &splitter{split: maxSplit, adaptive: true}
+}
+
+// groups divides peers into at most split groups, the first group holding
+// the first peer.
+func (sp *splitter) groups(peers []peer.ID) [][]peer.ID {
+	n := sp.split
+	if n > len(peers) {
+		n = len(peers)
+	}
+	if n == 0 {
+		return nil
+	}
+
+	out := make([][]peer.ID, n)
+	for i, p := range peers {
+		out[i%n] = append(out[i%n], p)
+	}
+	return out
+}
+
+// receivedBlock records a block received by the session of npeers peers,
+// whether it was a duplicate, and otherwise how long it took to arrive.
+func (sp *splitter) receivedBlock(npeers int, dup bool, lat time.Duration) {
+	if !sp.adaptive {
+		return
+	}
+
+	sp.received++
+	if dup {
+		sp.dups++
+	} else {
+		sp.latTotal += lat
+	}
+	if sp.received < minReceivedToAdjustSplit {
+		return
+	}
+
+	var avgLat time.Duration
+	if fetched := sp.received - sp.dups; fetched > 0 {
+		avgLat = sp.latTotal / time.Duration(fetched)
+	}
+
+	dupRatio := float64(sp.dups) / float64(sp.received)
+	switch {
+	case dupRatio > maxAcceptableDupes:
+		if sp.split < maxSplit {
+			sp.split++
+		}
+	case dupRatio < minDupesToTryLessSplits && sp.lastLat > 0 && avgLat > sp.lastLat:
+		if split := sp.effective(npeers); split > 1 {
+			sp.split = split - 1
+		}
+	}
+
+	sp.lastLat = avgLat
+	sp.received = 0
+	sp.dups = 0
+	sp.latTotal = 0
+}
+
+// timeout records that the live wants of the session of npeers peers timed
+// out, and were broadcast to everyone.
+func (sp *splitter) timeout(npeers int) {
+	if !sp.adaptive {
+		return
+	}
+	if split := sp.effective(npeers); split > 1 {
+		sp.split = (split + 1) / 2
+	}
+}
+
+// effective returns the split factor in use with npeers peers, above which
+// it makes no difference. Lowering the split factor starts from it, so that
+// it takes effect right away, while the split factor itself is kept for the
+// peers joining later.
+func (sp *splitter) effective(npeers int) int {
+	if npeers > 0 && sp.split > npeers {
+		return npeers
+	}
+	return sp.split
+}
@@ -0,0 +1,166 @@
+package bitswap
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	blocksutil "github.com/ipfs/go-ipfs/blocks/blocksutil"
+	tn "github.com/ipfs/go-ipfs/exchange/bitswap/testnet"
+	mockrouting "github.com/ipfs/go-ipfs/routing/mock"
+	delay "github.com/ipfs/go-ipfs/thirdparty/delay"
+
+	peer "gx/ipfs/QmWNY7dV54ZDYmTA1ykVdwNCqC11mpU4zSUp6XDpLTH9eG/go-libp2p-peer"
+	cid "gx/ipfs/QmeSrf6pzut73u6zLQkRFQ3ygt3k6XFT2kjdYP8Tnkwwyg/go-cid"
+)
+
+func TestSplitterGroups(t *testing.T) {
+	peers := []peer.ID{"a", "b", "c", "d", "e"}
+
+	sp := newSplitter(false)
+	if g := sp.groups(peers); len(g) != 1 || len(g[0]) != len(peers) {
+		t.Fatalf("expected a single group of all peers without splitting, got %v", g)
+	}
+
+	sp = newSplitter(true)
+	if g := sp.groups(peers); len(g) != len(peers) {
+		t.Fatalf("expected one group per peer at first, got %v", g)
+	}
+	if g := sp.groups(nil); len(g) != 0 {
+		t.Fatal("expected no groups without peers")
+	}
+
+	sp.split = 2
+	g := sp.groups(peers)
+	if len(g) != 2 || len(g[0]) != 3 || len(g[1]) != 2 {
+		t.Fatalf("expected groups of 3 and 2 peers, got %v", g)
+	}
+}
+
+func TestSplitterAdjusts(t *testing.T) {
+	sp := newSplitter(true)
+
+	// latency getting worse without duplicates asks more peers
+	for i := 0; i < minReceivedToAdjustSplit; i++ {
+		sp.receivedBlock(4, false, time.Millisecond)
+	}
+	for i := 0; i < minReceivedToAdjustSplit; i++ {
+		sp.receivedBlock(4, false, 10*time.Millisecond)
+	}
+	if sp.split != 3 {
+		t.Fatalf("expected the split factor to go down to 3, got %d", sp.split)
+	}
+
+	// too many duplicates asks fewer peers
+	for i := 0; i < minReceivedToAdjustSplit; i++ {
+		sp.receivedBlock(4, i%2 == 0, 10*time.Millisecond)
+	}
+	if sp.split != 4 {
+		t.Fatalf("expected the split factor to go up to 4, got %d", sp.split)
+	}
+
+	sp.timeout(4)
+	if sp.split != 2 {
+		t.Fatalf("expected a timeout to halve the split factor, got %d", sp.split)
+	}
+
+	fixed := newSplitter(false)
+	for i := 0; i < minReceivedToAdjustSplit; i++ {
+		fixed.receivedBlock(4, true, 0)
+	}
+	fixed.timeout(4)
+	if fixed.split != 1 {
+		t.Fatal("expected the split factor to stay fixed without splitting")
+	}
+}
+
+func TestSplitterPeersJoining(t *testing.T) {
+	peers := []peer.ID{"a", "b", "c", "d", "e"}
+	sp := newSplitter(true)
+
+	// the first block comes from the only peer known yet
+	sp.receivedBlock(1, false, time.Millisecond)
+	if g := sp.groups(peers); len(g) != len(peers) {
+		t.Fatalf("expected one group per peer after more peers joined, got %d groups", len(g))
+	}
+
+	sp.timeout(1)
+	if g := sp.groups(peers); len(g) != len(peers) {
+		t.Fatalf("expected a timeout with a single peer to keep the split factor, got %d groups", len(g))
+	}
+
+	// lowering the split factor starts from the number of peers
+	sp.timeout(len(peers))
+	if sp.split != 3 {
+		t.Fatalf("expected the split factor to go down to 3, got %d", sp.split)
+	}
+}
+
+func BenchmarkDupsSplit(b *testing.B) {
+	benchmarkDups(b, true)
+}
+
+func BenchmarkDupsBroadcast(b *testing.B) {
+	benchmarkDups(b, false)
+}
+
+// benchmarkDups measures how long it takes a node to fetch blocks held by
+// several seeds over the virtual network, one session per round, and logs
+// the number of duplicate blocks it received.
+func benchmarkDups(b *testing.B, split bool) {
+	const numSeeds = 5
+	const numBlocks = 100
+
+	net := tn.VirtualNetwork(mockrouting.NewServer(), delay.Fixed(10*time.Millisecond))
+	sg := NewTestSessionGenerator(net)
+	defer sg.Close()
+	bg := blocksutil.NewBlockGenerator()
+
+	inst := sg.Instances(numSeeds + 1)
+	fetcher := inst[0]
+	fetcher.Exchange.SetRequestSplitting(split)
+
+	var dups uint64
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		blks := bg.Blocks(numBlocks)
+		var keys []*cid.Cid
+		for _, blk := range blks {
+			keys = append(keys, blk.Cid())
+		}
+		for _, seed := range inst[1:] {
+			if err := seed.Blockstore().PutMany(blks); err != nil {
+				b.Fatal(err)
+			}
+		}
+		before := dupBlocksRecvd(fetcher.Exchange)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		b.StartTimer()
+
+		ses := fetcher.Exchange.NewSession(ctx)
+		out, err := ses.GetBlocks(ctx, keys)
+		if err != nil {
+			b.Fatal(err)
+		}
+		n := 0
+		for range out {
+			n++
+		}
+
+		b.StopTimer()
+		cancel()
+		if n != numBlocks {
+			b.Fatalf("got %d of %d blocks", n, numBlocks)
+		}
+		dups += dupBlocksRecvd(fetcher.Exchange) - before
+	}
+
+	b.Logf("%d duplicate blocks per round", dups/uint64(b.N))
+}
+
+func dupBlocksRecvd(bs *Bitswap) uint64 {
+	bs.counterLk.Lock()
+	defer bs.counterLk.Unlock()
+	return bs.counters.dupBlocksRecvd
+}
@@ -16,6 +16,10 @@ type Stat struct {
 	DataSent        uint64
 	DupBlksReceived uint64
 	DupDataReceived uint64
+
+	RateExceeded        uint64
+	PeerRateExceeded    uint64
+	OutstandingExceeded uint64
 }
 
 func (bs *Bitswap) Stat() (*Stat, error) {
@@ -32,6 +36,11 @@ func (bs *Bitswap) Stat() (*Stat, error) {
 	st.DataReceived = c.dataRecvd
 	bs.counterLk.Unlock()
 
+	qs := bs.engine.QuotaStat()
+	st.RateExceeded = qs.RateExceeded
+	st.PeerRateExceeded = qs.PeerRateExceeded
+	st.OutstandingExceeded = qs.OutstandingExceeded
+
 	peers := bs.engine.Peers()
 	st.Peers = make([]string, 0, len(peers))
 
@@ -42,3 +51,24 @@ func (bs *Bitswap) Stat() (*Stat, error) {
 
 	return st, nil
 }
+
+// SessionStats returns the state of the live sessions, ordered by ID.
+func (bs *Bitswap) SessionStats() []*SessionStat {
+	bs.sessLk.Lock()
+	sessions := make([]*Session, len(bs.sessions))
+	copy(sessions, bs.sessions)
+	bs.sessLk.Unlock()
+
+	out := make([]*SessionStat, 0, len(sessions))
+	for _, s := range sessions {
+		st, err := s.Stat()
+		if err != nil {
+			// the session ended in the meantime
+			continue
+		}
+		out = append(out, st)
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
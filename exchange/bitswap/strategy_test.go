@@ -0,0 +1,124 @@
+package bitswap
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	blocksutil "github.com/ipfs/go-ipfs/blocks/blocksutil"
+	decision "github.com/ipfs/go-ipfs/exchange/bitswap/decision"
+	tn "github.com/ipfs/go-ipfs/exchange/bitswap/testnet"
+	mockrouting "github.com/ipfs/go-ipfs/routing/mock"
+	delay "github.com/ipfs/go-ipfs/thirdparty/delay"
+
+	peer "gx/ipfs/QmWNY7dV54ZDYmTA1ykVdwNCqC11mpU4zSUp6XDpLTH9eG/go-libp2p-peer"
+	p2ptestutil "gx/ipfs/QmZTcPxK6VqrwY94JpKZPvEqAZ6tEr1rLrpcqJbbRZbg2V/go-libp2p-netutil"
+	cid "gx/ipfs/QmeSrf6pzut73u6zLQkRFQ3ygt3k6XFT2kjdYP8Tnkwwyg/go-cid"
+)
+
+func BenchmarkStrategyFairShare(b *testing.B) {
+	benchmarkStrategy(b, func(peer.ID) decision.Strategy {
+		return decision.NewFairShareStrategy()
+	})
+}
+
+func BenchmarkStrategyTitForTat(b *testing.B) {
+	benchmarkStrategy(b, func(peer.ID) decision.Strategy {
+		return decision.NewTitForTatStrategy(decision.DefaultTitForTatAllowance)
+	})
+}
+
+func BenchmarkStrategyAllowlist(b *testing.B) {
+	benchmarkStrategy(b, func(favoured peer.ID) decision.Strategy {
+		return decision.NewAllowlistStrategy([]peer.ID{favoured}, decision.NewFairShareStrategy())
+	})
+}
+
+// benchmarkStrategy measures how long it takes a seed using the strategy to
+// serve the same blocks to several leechers over the virtual network. The
+// first leecher is favoured: it sent blocks to the seed beforehand, and is
+// the one passed to mkStrategy.
+func benchmarkStrategy(b *testing.B, mkStrategy func(favoured peer.ID) decision.Strategy) {
+	const numLeechers = 4
+	const numBlocks = 100
+
+	net := tn.VirtualNetwork(mockrouting.NewServer(), delay.Fixed(time.Millisecond))
+	sg := NewTestSessionGenerator(net)
+	defer sg.Close()
+	bg := blocksutil.NewBlockGenerator()
+
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+
+		// leechers are only connected to the seed, so that every block
+		// they get was served by its strategy
+		var leechers []Instance
+		for j := 0; j < numLeechers; j++ {
+			leechers = append(leechers, sg.Next())
+		}
+
+		p, err := p2ptestutil.RandTestBogusIdentity()
+		if err != nil {
+			b.Fatal(err)
+		}
+		seed := MkSessionWithStrategy(sg.ctx, net, p, mkStrategy(leechers[0].Peer))
+		for _, l := range leechers {
+			seed.Exchange.network.ConnectTo(context.Background(), l.Peer)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+
+		var gifts []*cid.Cid
+		for _, blk := range bg.Blocks(10) {
+			leechers[0].Exchange.HasBlock(blk)
+			gifts = append(gifts, blk.Cid())
+		}
+		if err := fetchAll(ctx, seed, gifts); err != nil {
+			b.Fatal(err)
+		}
+
+		var keys []*cid.Cid
+		for _, blk := range bg.Blocks(numBlocks) {
+			seed.Exchange.HasBlock(blk)
+			keys = append(keys, blk.Cid())
+		}
+
+		b.StartTimer()
+		var wg sync.WaitGroup
+		for _, l := range leechers {
+			wg.Add(1)
+			go func(l Instance) {
+				defer wg.Done()
+				if err := fetchAll(ctx, l, keys); err != nil {
+					b.Error(err)
+				}
+			}(l)
+		}
+		wg.Wait()
+		b.StopTimer()
+
+		cancel()
+		seed.Exchange.Close()
+		for _, l := range leechers {
+			l.Exchange.Close()
+		}
+	}
+}
+
+func fetchAll(ctx context.Context, inst Instance, keys []*cid.Cid) error {
+	out, err := inst.Exchange.GetBlocks(ctx, keys)
+	if err != nil {
+		return err
+	}
+
+	n := 0
+	for range out {
+		n++
+	}
+	if n != len(keys) {
+		return fmt.Errorf("got %d of %d blocks: %v", n, len(keys), ctx.Err())
+	}
+	return nil
+}
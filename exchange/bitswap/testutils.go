@@ -5,6 +5,7 @@ import (
 	"time"
 
 	blockstore "github.com/ipfs/go-ipfs/blocks/blockstore"
+	decision "github.com/ipfs/go-ipfs/exchange/bitswap/decision"
 	tn "github.com/ipfs/go-ipfs/exchange/bitswap/testnet"
 	datastore2 "github.com/ipfs/go-ipfs/thirdparty/datastore2"
 	delay "github.com/ipfs/go-ipfs/thirdparty/delay"
@@ -19,21 +20,30 @@ import (
 // WARNING: this uses RandTestBogusIdentity DO NOT USE for NON TESTS!
 func NewTestSessionGenerator(
 	net tn.Network) SessionGenerator {
+	return NewTestSessionGeneratorWithStrategy(net, decision.NewFairShareStrategy())
+}
+
+// NewTestSessionGeneratorWithStrategy returns a SessionGenerator whose
+// instances use the given decision strategy.
+func NewTestSessionGeneratorWithStrategy(
+	net tn.Network, strategy decision.Strategy) SessionGenerator {
 	ctx, cancel := context.WithCancel(context.Background())
 	return SessionGenerator{
-		net:    net,
-		seq:    0,
-		ctx:    ctx, // TODO take ctx as param to Next, Instances
-		cancel: cancel,
+		net:      net,
+		seq:      0,
+		ctx:      ctx, // TODO take ctx as param to Next, Instances
+		cancel:   cancel,
+		strategy: strategy,
 	}
 }
 
 // TODO move this SessionGenerator to the core package and export it as the core generator
 type SessionGenerator struct {
-	seq    int
-	net    tn.Network
-	ctx    context.Context
-	cancel context.CancelFunc
+	seq      int
+	net      tn.Network
+	ctx      context.Context
+	cancel   context.CancelFunc
+	strategy decision.Strategy
 }
 
 func (g *SessionGenerator) Close() error {
@@ -47,7 +57,7 @@ func (g *SessionGenerator) Next() Instance {
 	if err != nil {
 		panic("FIXME") // TODO change signature
 	}
-	return MkSession(g.ctx, g.net, p)
+	return MkSessionWithStrategy(g.ctx, g.net, p, g.strategy)
 }
 
 func (g *SessionGenerator) Instances(n int) []Instance {
@@ -87,6 +97,12 @@ func (i *Instance) SetBlockstoreLatency(t time.Duration) time.Duration {
 // sessions. To safeguard, use the SessionGenerator to generate sessions. It's
 // just a much better idea.
 func MkSession(ctx context.Context, net tn.Network, p testutil.Identity) Instance {
+	return MkSessionWithStrategy(ctx, net, p, decision.NewFairShareStrategy())
+}
+
+// MkSessionWithStrategy is like MkSession, but the session serves other
+// peers as decided by the given strategy.
+func MkSessionWithStrategy(ctx context.Context, net tn.Network, p testutil.Identity, strategy decision.Strategy) Instance {
 	bsdelay := delay.Fixed(0)
 
 	adapter := net.Adapter(p)
@@ -99,9 +115,7 @@ func MkSession(ctx context.Context, net tn.Network, p testutil.Identity) Instanc
 		panic(err.Error()) // FIXME perhaps change signature and return error.
 	}
 
-	const alwaysSendToPeer = true
-
-	bs := New(ctx, p.ID(), adapter, bstore, alwaysSendToPeer).(*Bitswap)
+	bs := NewWithStrategy(ctx, p.ID(), adapter, bstore, nil, strategy).(*Bitswap)
 
 	return Instance{
 		Peer:            p.ID(),
@@ -5,6 +5,7 @@ import (
 	"sync"
 	"time"
 
+	exchange "github.com/ipfs/go-ipfs/exchange"
 	engine "github.com/ipfs/go-ipfs/exchange/bitswap/decision"
 	bsmsg "github.com/ipfs/go-ipfs/exchange/bitswap/message"
 	bsnet "github.com/ipfs/go-ipfs/exchange/bitswap/network"
@@ -68,6 +69,9 @@ type msgQueue struct {
 	network bsnet.BitSwapNetwork
 	wl      *wantlist.ThreadSafe
 
+	// haves holds the cids of wl we only sent want-have entries for
+	haves *cid.Set
+
 	sender bsnet.MessageSender
 
 	refcnt int
@@ -76,15 +80,24 @@ type msgQueue struct {
 	done chan struct{}
 }
 
-// WantBlocks adds the given cids to the wantlist, tracked by the given session
+// WantBlocks adds the given cids to the wantlist, tracked by the given session.
+// Peers that are asked directly, rather than through a broadcast, answer with
+// a DONT_HAVE if they do not have a block.
 func (pm *WantManager) WantBlocks(ctx context.Context, ks []*cid.Cid, peers []peer.ID, ses uint64) {
 	log.Infof("want blocks: %s", ks)
-	pm.addEntries(ctx, ks, peers, false, ses)
+	pm.addEntries(ctx, ks, peers, false, bsmsg.WantBlock, len(peers) > 0, ses)
+}
+
+// WantHaves adds the given cids to the wantlist, tracked by the given session,
+// but only asks peers to tell us whether they have them.
+func (pm *WantManager) WantHaves(ctx context.Context, ks []*cid.Cid, peers []peer.ID, ses uint64) {
+	log.Infof("want haves: %s", ks)
+	pm.addEntries(ctx, ks, peers, false, bsmsg.WantHave, true, ses)
 }
 
 // CancelWants removes the given cids from the wantlist, tracked by the given session
 func (pm *WantManager) CancelWants(ctx context.Context, ks []*cid.Cid, peers []peer.ID, ses uint64) {
-	pm.addEntries(context.Background(), ks, peers, true, ses)
+	pm.addEntries(context.Background(), ks, peers, true, bsmsg.WantBlock, false, ses)
 }
 
 type wantSet struct {
@@ -93,12 +106,21 @@ type wantSet struct {
 	from    uint64
 }
 
-func (pm *WantManager) addEntries(ctx context.Context, ks []*cid.Cid, targets []peer.ID, cancel bool, ses uint64) {
+// addEntries sends entries for the given cids to the targets. Unless ctx
+// holds priorities for them, the cids are wanted in the given order.
+func (pm *WantManager) addEntries(ctx context.Context, ks []*cid.Cid, targets []peer.ID, cancel bool, wantType bsmsg.WantType, sendDontHave bool, ses uint64) {
+	prios := exchange.PrioritiesFromContext(ctx)
 	entries := make([]*bsmsg.Entry, 0, len(ks))
 	for i, k := range ks {
+		priority, ok := prios.Get(k)
+		if !ok {
+			priority = kMaxPriority - i
+		}
 		entries = append(entries, &bsmsg.Entry{
-			Cancel: cancel,
-			Entry:  wantlist.NewRefEntry(k, kMaxPriority-i),
+			Cancel:       cancel,
+			Entry:        wantlist.NewRefEntry(k, priority),
+			WantType:     wantType,
+			SendDontHave: sendDontHave,
 		})
 	}
 	select {
@@ -130,6 +152,15 @@ func (pm *WantManager) SendBlock(ctx context.Context, env *engine.Envelope) {
 	}
 }
 
+// SendPresence sends HAVE and DONT_HAVE responses to a peer.
+func (pm *WantManager) SendPresence(ctx context.Context, p peer.ID, msg bsmsg.BitSwapMessage) {
+	log.Infof("Sending block presence to %s", p)
+	err := pm.network.SendMessage(ctx, p, msg)
+	if err != nil {
+		log.Infof("sendpresence error: %s", err)
+	}
+}
+
 func (pm *WantManager) startPeerHandler(p peer.ID) *msgQueue {
 	mq, ok := pm.peers[p]
 	if ok {
@@ -356,6 +387,7 @@ func (wm *WantManager) newMsgQueue(p peer.ID) *msgQueue {
 		done:    make(chan struct{}),
 		work:    make(chan struct{}, 1),
 		wl:      wantlist.NewThreadSafe(),
+		haves:   cid.NewSet(),
 		network: wm.network,
 		p:       p,
 		refcnt:  1,
@@ -388,12 +420,21 @@ func (mq *msgQueue) addMessage(entries []*bsmsg.Entry, ses uint64) {
 		if e.Cancel {
 			if mq.wl.Remove(e.Cid, ses) {
 				work = true
+				mq.haves.Remove(e.Cid)
 				mq.out.Cancel(e.Cid)
 			}
 		} else {
-			if mq.wl.Add(e.Cid, e.Priority, ses) {
+			added := mq.wl.Add(e.Cid, e.Priority, ses)
+			// asking for a block we only asked the peer to have before
+			upgrade := !added && e.WantType == bsmsg.WantBlock && mq.haves.Has(e.Cid)
+			if added || upgrade {
 				work = true
-				mq.out.AddEntry(e.Cid, e.Priority)
+				if e.WantType == bsmsg.WantHave {
+					mq.haves.Add(e.Cid)
+				} else {
+					mq.haves.Remove(e.Cid)
+				}
+				mq.out.AddWantEntry(e.Cid, e.Priority, e.WantType, e.SendDontHave)
 			}
 		}
 	}
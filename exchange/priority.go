@@ -0,0 +1,57 @@
+package exchange
+
+import (
+	"context"
+	"math"
+
+	cid "gx/ipfs/QmeSrf6pzut73u6zLQkRFQ3ygt3k6XFT2kjdYP8Tnkwwyg/go-cid"
+)
+
+// MaxPriority is the highest priority a block can be fetched with.
+const MaxPriority = math.MaxInt32
+
+// Priorities holds the priorities blocks should be fetched with, by cid.
+// Blocks with a higher priority are fetched first.
+type Priorities map[string]int
+
+// Set sets the priority of c.
+func (p Priorities) Set(c *cid.Cid, priority int) {
+	p[c.KeyString()] = priority
+}
+
+// Get returns the priority of c, if it has one.
+func (p Priorities) Get(c *cid.Cid) (int, bool) {
+	priority, ok := p[c.KeyString()]
+	return priority, ok
+}
+
+type prioritiesKey struct{}
+
+// WithPriorities returns a context asking exchanges to fetch blocks with
+// the given priorities. Blocks without one are fetched with the default
+// priority of the exchange.
+func WithPriorities(ctx context.Context, p Priorities) context.Context {
+	if len(p) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, prioritiesKey{}, p)
+}
+
+// PrioritiesFromContext returns the priorities set with WithPriorities, if
+// any.
+func PrioritiesFromContext(ctx context.Context) Priorities {
+	p, _ := ctx.Value(prioritiesKey{}).(Priorities)
+	return p
+}
+
+// ReadPriority returns the priority of a block holding the data at the
+// given offset of a file read sequentially: the closer the data is to the
+// start, the higher the priority.
+func ReadPriority(offset uint64) int {
+	// one step per KiB covers files of up to 2 TiB
+	p := int64(MaxPriority) - int64(offset>>10)
+	if p < 1 {
+		return 1
+	}
+	return int(p)
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 
 	blocks "github.com/ipfs/go-ipfs/blocks/blockstore"
+	filestore "github.com/ipfs/go-ipfs/filestore"
 	merkledag "github.com/ipfs/go-ipfs/merkledag"
+	mfs "github.com/ipfs/go-ipfs/mfs"
 	pin "github.com/ipfs/go-ipfs/pin"
 
 	cid "gx/ipfs/QmeSrf6pzut73u6zLQkRFQ3ygt3k6XFT2kjdYP8Tnkwwyg/go-cid"
@@ -42,6 +44,98 @@ func NewPinnedProvider(pinning pin.Pinner, dag merkledag.DAGService, onlyRoots b
 	}
 }
 
+// NewMFSProvider returns provider supplying the keys reachable from the
+// files root. If onlyRoots is set, only the files root and the entries
+// directly under it are supplied.
+func NewMFSProvider(root *mfs.Root, dag merkledag.DAGService, onlyRoots bool) KeyChanFunc {
+	return func(ctx context.Context) (<-chan *cid.Cid, error) {
+		nd, err := root.GetValue().GetNode()
+		if err != nil {
+			return nil, err
+		}
+
+		outCh := make(chan *cid.Cid)
+		set := cid.NewSet()
+		add := func(c *cid.Cid) bool {
+			if !set.Visit(c) {
+				return false
+			}
+			select {
+			case <-ctx.Done():
+			case outCh <- c:
+			}
+			return true
+		}
+
+		go func() {
+			defer close(outCh)
+
+			add(nd.Cid())
+			if onlyRoots {
+				for _, l := range nd.Links() {
+					add(l.Cid)
+				}
+				return
+			}
+
+			err := merkledag.EnumerateChildren(ctx, dag.GetLinks, nd.Cid(), add)
+			if err != nil && ctx.Err() == nil {
+				log.Errorf("reprovide files root: %s", err)
+			}
+		}()
+
+		return outCh, nil
+	}
+}
+
+// NewFilestoreProvider returns provider supplying the keys of the blocks
+// referenced by the filestore
+func NewFilestoreProvider(fs *filestore.Filestore) KeyChanFunc {
+	return func(ctx context.Context) (<-chan *cid.Cid, error) {
+		return fs.FileManager().AllKeysChan(ctx)
+	}
+}
+
+// NewCombinedProvider returns provider supplying the keys of all the given
+// providers, in order, without duplicates
+func NewCombinedProvider(providers ...KeyChanFunc) KeyChanFunc {
+	return func(ctx context.Context) (<-chan *cid.Cid, error) {
+		// cancelling stops the providers we are not reading from anymore
+		ctx, cancel := context.WithCancel(ctx)
+
+		chans := make([]<-chan *cid.Cid, 0, len(providers))
+		for _, p := range providers {
+			ch, err := p(ctx)
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+			chans = append(chans, ch)
+		}
+
+		outCh := make(chan *cid.Cid)
+		go func() {
+			defer close(outCh)
+			defer cancel()
+			seen := cid.NewSet()
+			for _, ch := range chans {
+				for c := range ch {
+					if !seen.Visit(c) {
+						continue
+					}
+					select {
+					case <-ctx.Done():
+						return
+					case outCh <- c:
+					}
+				}
+			}
+		}()
+
+		return outCh, nil
+	}
+}
+
 func pinSet(ctx context.Context, pinning pin.Pinner, dag merkledag.DAGService, onlyRoots bool) (*streamingSet, error) {
 	set := newStreamingSet()
 
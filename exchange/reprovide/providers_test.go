@@ -0,0 +1,114 @@
+package reprovide_test
+
+import (
+	"context"
+	"testing"
+
+	dag "github.com/ipfs/go-ipfs/merkledag"
+	mdtest "github.com/ipfs/go-ipfs/merkledag/test"
+	mfs "github.com/ipfs/go-ipfs/mfs"
+	ft "github.com/ipfs/go-ipfs/unixfs"
+
+	cid "gx/ipfs/QmeSrf6pzut73u6zLQkRFQ3ygt3k6XFT2kjdYP8Tnkwwyg/go-cid"
+
+	. "github.com/ipfs/go-ipfs/exchange/reprovide"
+)
+
+func collectKeys(t *testing.T, kcf KeyChanFunc) []*cid.Cid {
+	ch, err := kcf(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out []*cid.Cid
+	for c := range ch {
+		out = append(out, c)
+	}
+	return out
+}
+
+func hasKey(keys []*cid.Cid, c *cid.Cid) bool {
+	for _, k := range keys {
+		if k.Equals(c) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestMFSProvider(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ds := mdtest.Mock()
+	rt, err := mfs.NewRoot(ctx, ds, ft.EmptyDirNode(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mfs.Mkdir(rt, "/a", mfs.MkdirOpts{}); err != nil {
+		t.Fatal(err)
+	}
+	leaf := dag.NodeWithData(ft.FilePBData([]byte("foo"), 3))
+	if _, err := ds.Add(leaf); err != nil {
+		t.Fatal(err)
+	}
+	if err := mfs.PutNode(rt, "/a/foo", leaf); err != nil {
+		t.Fatal(err)
+	}
+
+	root, err := rt.GetValue().GetNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := mfs.Lookup(rt, "/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dirNode, err := dir.GetNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := collectKeys(t, NewMFSProvider(rt, ds, false))
+	if len(keys) != 3 {
+		t.Fatalf("expected 3 keys, got %d", len(keys))
+	}
+	for _, c := range []*cid.Cid{root.Cid(), dirNode.Cid(), leaf.Cid()} {
+		if !hasKey(keys, c) {
+			t.Fatalf("expected %s to be provided", c)
+		}
+	}
+
+	keys = collectKeys(t, NewMFSProvider(rt, ds, true))
+	if len(keys) != 2 || !hasKey(keys, root.Cid()) || !hasKey(keys, dirNode.Cid()) {
+		t.Fatalf("expected only the files root and its entries, got %v", keys)
+	}
+}
+
+func TestCombinedProvider(t *testing.T) {
+	a := dag.NodeWithData([]byte("a")).Cid()
+	b := dag.NodeWithData([]byte("b")).Cid()
+	c := dag.NodeWithData([]byte("c")).Cid()
+
+	provider := func(keys ...*cid.Cid) KeyChanFunc {
+		return func(context.Context) (<-chan *cid.Cid, error) {
+			ch := make(chan *cid.Cid, len(keys))
+			for _, k := range keys {
+				ch <- k
+			}
+			close(ch)
+			return ch, nil
+		}
+	}
+
+	keys := collectKeys(t, NewCombinedProvider(provider(a, b), provider(b, c, a)))
+	if len(keys) != 3 {
+		t.Fatalf("expected 3 keys, got %d", len(keys))
+	}
+	for i, k := range []*cid.Cid{a, b, c} {
+		if !keys[i].Equals(k) {
+			t.Fatalf("expected key %d to be %s, got %s", i, k, keys[i])
+		}
+	}
+}
@@ -0,0 +1,323 @@
+package reprovide
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	routing "gx/ipfs/QmPCGUjMRuBcPybZFpjhzpifwPP9wPRoiy5geTQKU4vqWA/go-libp2p-routing"
+	ds "gx/ipfs/QmdHG8MAuARdGHxx4rPQASLcvhz24fzjSQq7AJRAQEorq5/go-datastore"
+	dsq "gx/ipfs/QmdHG8MAuARdGHxx4rPQASLcvhz24fzjSQq7AJRAQEorq5/go-datastore/query"
+	cid "gx/ipfs/QmeSrf6pzut73u6zLQkRFQ3ygt3k6XFT2kjdYP8Tnkwwyg/go-cid"
+)
+
+// queueKeyPrefix is the datastore key under which the keys waiting to be
+// provided are kept, one per key.
+var queueKeyPrefix = ds.NewKey("/local/providequeue")
+
+const (
+	// DefaultQueueWorkers is the number of keys the queue provides in
+	// parallel.
+	DefaultQueueWorkers = 4
+
+	// queueProvideTimeout bounds a single attempt at providing a key.
+	queueProvideTimeout = time.Minute
+
+	// queueMinRetryDelay and queueMaxRetryDelay bound the time between two
+	// attempts at providing a key. The delay doubles after every failure.
+	queueMinRetryDelay = 10 * time.Second
+	queueMaxRetryDelay = time.Hour
+)
+
+// QueueEntry is a key waiting in the provide queue.
+type QueueEntry struct {
+	Cid *cid.Cid
+
+	// Added is when the key was first queued.
+	Added time.Time
+
+	// Attempts is the number of times providing the key failed, and
+	// NextAttempt when it will be tried again.
+	Attempts    int
+	NextAttempt time.Time
+
+	// LastError is the error the last attempt failed with.
+	LastError string `json:",omitempty"`
+}
+
+// queueRecord is what is kept in the datastore for a queued key.
+type queueRecord struct {
+	Added       time.Time
+	Attempts    int
+	NextAttempt time.Time
+	LastError   string `json:",omitempty"`
+}
+
+type queueItem struct {
+	c        *cid.Cid
+	rec      queueRecord
+	inflight bool
+}
+
+// Queue announces newly added keys to the routing system in the
+// background. Queued keys are kept in the datastore until they were
+// provided, so that they survive restarts. Failed attempts are retried
+// with an increasing delay.
+type Queue struct {
+	ctx    context.Context
+	rsys   routing.ContentRouting
+	dstore ds.Datastore
+
+	lk    sync.Mutex
+	items map[string]*queueItem
+	wake  chan struct{}
+}
+
+// NewQueue creates a provide queue, loading the keys left in dstore by a
+// previous run.
+func NewQueue(ctx context.Context, rsys routing.ContentRouting, dstore ds.Datastore) (*Queue, error) {
+	q := &Queue{
+		ctx:    ctx,
+		rsys:   rsys,
+		dstore: dstore,
+		items:  make(map[string]*queueItem),
+		wake:   make(chan struct{}, 1),
+	}
+
+	res, err := dstore.Query(dsq.Query{Prefix: queueKeyPrefix.String()})
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+
+	for {
+		e, ok := res.NextSync()
+		if !ok {
+			break
+		}
+		if e.Error != nil {
+			return nil, e.Error
+		}
+
+		c, err := cid.Decode(ds.RawKey(e.Key).BaseNamespace())
+		if err != nil {
+			log.Errorf("invalid key in the provide queue %s: %s", e.Key, err)
+			continue
+		}
+		b, ok := e.Value.([]byte)
+		if !ok {
+			return nil, fmt.Errorf("provide queue entry %s is not stored as bytes", e.Key)
+		}
+
+		var rec queueRecord
+		if err := json.Unmarshal(b, &rec); err != nil {
+			log.Errorf("invalid provide queue entry %s: %s", e.Key, err)
+			continue
+		}
+		q.items[c.KeyString()] = &queueItem{c: c, rec: rec}
+	}
+
+	return q, nil
+}
+
+func queueKey(c *cid.Cid) ds.Key {
+	return queueKeyPrefix.ChildString(c.String())
+}
+
+func (q *Queue) put(it *queueItem) error {
+	b, err := json.Marshal(it.rec)
+	if err != nil {
+		return err
+	}
+	return q.dstore.Put(queueKey(it.c), b)
+}
+
+// Enqueue adds keys to the queue. Keys already in the queue are left as
+// they are.
+func (q *Queue) Enqueue(cids ...*cid.Cid) error {
+	q.lk.Lock()
+	defer q.lk.Unlock()
+
+	now := time.Now()
+	for _, c := range cids {
+		if _, ok := q.items[c.KeyString()]; ok {
+			continue
+		}
+
+		it := &queueItem{
+			c:   c,
+			rec: queueRecord{Added: now, NextAttempt: now},
+		}
+		if err := q.put(it); err != nil {
+			return err
+		}
+		q.items[c.KeyString()] = it
+	}
+
+	q.signal()
+	return nil
+}
+
+// Entries returns the keys in the queue, oldest first.
+func (q *Queue) Entries() []QueueEntry {
+	q.lk.Lock()
+	defer q.lk.Unlock()
+
+	out := make([]QueueEntry, 0, len(q.items))
+	for _, it := range q.items {
+		out = append(out, QueueEntry{
+			Cid:         it.c,
+			Added:       it.rec.Added,
+			Attempts:    it.rec.Attempts,
+			NextAttempt: it.rec.NextAttempt,
+			LastError:   it.rec.LastError,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Added.Before(out[j].Added)
+	})
+	return out
+}
+
+// Run provides the queued keys with the given number of workers, until the
+// context of the queue is cancelled.
+func (q *Queue) Run(workers int) {
+	if workers <= 0 {
+		workers = DefaultQueueWorkers
+	}
+
+	jobs := make(chan *queueItem)
+	for i := 0; i < workers; i++ {
+		go q.worker(jobs)
+	}
+
+	for {
+		it, wait := q.next(time.Now())
+		if it != nil {
+			select {
+			case <-q.ctx.Done():
+				return
+			case jobs <- it:
+			}
+			continue
+		}
+
+		var timer *time.Timer
+		var after <-chan time.Time
+		if wait > 0 {
+			timer = time.NewTimer(wait)
+			after = timer.C
+		}
+
+		select {
+		case <-q.ctx.Done():
+			return
+		case <-q.wake:
+		case <-after:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// next returns the oldest key due to be provided, and marks it in flight.
+// If no key is due, it returns how long to wait for the next one, or zero
+// if there is none.
+func (q *Queue) next(now time.Time) (*queueItem, time.Duration) {
+	q.lk.Lock()
+	defer q.lk.Unlock()
+
+	var due, waiting *queueItem
+	for _, it := range q.items {
+		if it.inflight {
+			continue
+		}
+		if it.rec.NextAttempt.After(now) {
+			if waiting == nil || it.rec.NextAttempt.Before(waiting.rec.NextAttempt) {
+				waiting = it
+			}
+			continue
+		}
+		if due == nil || it.rec.Added.Before(due.rec.Added) {
+			due = it
+		}
+	}
+
+	if due != nil {
+		due.inflight = true
+		return due, 0
+	}
+	if waiting != nil {
+		return nil, waiting.rec.NextAttempt.Sub(now)
+	}
+	return nil, 0
+}
+
+func (q *Queue) worker(jobs <-chan *queueItem) {
+	for {
+		select {
+		case <-q.ctx.Done():
+			return
+		case it := <-jobs:
+			ctx, cancel := context.WithTimeout(q.ctx, queueProvideTimeout)
+			err := q.rsys.Provide(ctx, it.c, true)
+			cancel()
+			q.done(it, err)
+		}
+	}
+}
+
+// done records the outcome of an attempt at providing a key.
+func (q *Queue) done(it *queueItem, err error) {
+	q.lk.Lock()
+	defer q.lk.Unlock()
+
+	it.inflight = false
+
+	if err == nil {
+		delete(q.items, it.c.KeyString())
+		if err := q.dstore.Delete(queueKey(it.c)); err != nil {
+			log.Errorf("failed to remove %s from the provide queue: %s", it.c, err)
+		}
+		return
+	}
+
+	if q.ctx.Err() != nil {
+		// shutting down, the key will be tried again after a restart
+		return
+	}
+
+	log.Debugf("failed to provide %s: %s", it.c, err)
+	it.rec.Attempts++
+	it.rec.LastError = err.Error()
+	it.rec.NextAttempt = time.Now().Add(retryDelay(it.rec.Attempts))
+	if err := q.put(it); err != nil {
+		log.Errorf("failed to update the provide queue: %s", err)
+	}
+	q.signal()
+}
+
+// signal wakes Run up to look at the queue again.
+func (q *Queue) signal() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// retryDelay returns how long to wait before trying to provide a key again
+// after the given number of failed attempts.
+func retryDelay(attempts int) time.Duration {
+	d := queueMinRetryDelay
+	for i := 1; i < attempts && d < queueMaxRetryDelay; i++ {
+		d *= 2
+	}
+	if d > queueMaxRetryDelay {
+		return queueMaxRetryDelay
+	}
+	return d
+}
@@ -0,0 +1,121 @@
+package reprovide_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	mock "github.com/ipfs/go-ipfs/routing/mock"
+	pstore "gx/ipfs/QmYijbtjCxFEjSXaudaQAUz3LN5VKLssm8WCUsRoqzXmQR/go-libp2p-peerstore"
+	blocks "gx/ipfs/QmYsEQydGrsxNZfAiskvQ76N2xE9hDQtSAkRSynwMiUK3c/go-block-format"
+	ds "gx/ipfs/QmdHG8MAuARdGHxx4rPQASLcvhz24fzjSQq7AJRAQEorq5/go-datastore"
+	dssync "gx/ipfs/QmdHG8MAuARdGHxx4rPQASLcvhz24fzjSQq7AJRAQEorq5/go-datastore/sync"
+	testutil "gx/ipfs/QmeDA8gNhvRTsbrjEieay5wezupJDiky8xvCzDABbsGzmp/go-testutil"
+	cid "gx/ipfs/QmeSrf6pzut73u6zLQkRFQ3ygt3k6XFT2kjdYP8Tnkwwyg/go-cid"
+
+	. "github.com/ipfs/go-ipfs/exchange/reprovide"
+)
+
+// failingRouting fails to provide anything.
+type failingRouting struct{}
+
+func (failingRouting) Provide(context.Context, *cid.Cid, bool) error {
+	return errors.New("no route")
+}
+
+func (failingRouting) FindProvidersAsync(context.Context, *cid.Cid, int) <-chan pstore.PeerInfo {
+	ch := make(chan pstore.PeerInfo)
+	close(ch)
+	return ch
+}
+
+func waitForQueue(t *testing.T, q *Queue, done func([]QueueEntry) bool) []QueueEntry {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		ents := q.Entries()
+		if done(ents) {
+			return ents
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for the provide queue, got %v", ents)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestQueueProvides(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mrserv := mock.NewServer()
+	idA := testutil.RandIdentityOrFatal(t)
+	clA := mrserv.Client(idA)
+	clB := mrserv.Client(testutil.RandIdentityOrFatal(t))
+
+	q, err := NewQueue(ctx, clA, dssync.MutexWrap(ds.NewMapDatastore()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	go q.Run(2)
+
+	blk := blocks.NewBlock([]byte("this is a test"))
+	if err := q.Enqueue(blk.Cid()); err != nil {
+		t.Fatal(err)
+	}
+
+	waitForQueue(t, q, func(ents []QueueEntry) bool {
+		return len(ents) == 0
+	})
+
+	var providers []pstore.PeerInfo
+	for p := range clB.FindProvidersAsync(ctx, blk.Cid(), 1) {
+		providers = append(providers, p)
+	}
+	if len(providers) != 1 || providers[0].ID != idA.ID() {
+		t.Fatalf("expected the key to be provided by A, got %v", providers)
+	}
+}
+
+func TestQueueRetriesAndPersists(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	dstore := dssync.MutexWrap(ds.NewMapDatastore())
+	q, err := NewQueue(ctx, failingRouting{}, dstore)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go q.Run(1)
+
+	a := blocks.NewBlock([]byte("a")).Cid()
+	b := blocks.NewBlock([]byte("b")).Cid()
+	if err := q.Enqueue(a, b, a); err != nil {
+		t.Fatal(err)
+	}
+
+	ents := waitForQueue(t, q, func(ents []QueueEntry) bool {
+		return len(ents) == 2 && ents[0].Attempts > 0 && ents[1].Attempts > 0
+	})
+	for _, ent := range ents {
+		if ent.LastError != "no route" {
+			t.Fatalf("expected the last error to be kept, got %q", ent.LastError)
+		}
+		if !ent.NextAttempt.After(time.Now()) {
+			t.Fatal("expected the next attempt to be delayed")
+		}
+	}
+	cancel()
+
+	// the keys are still queued after a restart
+	q, err = NewQueue(context.Background(), failingRouting{}, dstore)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ents = q.Entries()
+	if len(ents) != 2 || !ents[0].Cid.Equals(a) && !ents[1].Cid.Equals(a) {
+		t.Fatalf("expected both keys to be loaded, got %v", ents)
+	}
+	if ents[0].Attempts != 1 {
+		t.Fatalf("expected the failed attempt to be kept, got %d", ents[0].Attempts)
+	}
+}
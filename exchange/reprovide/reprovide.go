@@ -3,11 +3,13 @@ package reprovide
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	routing "gx/ipfs/QmPCGUjMRuBcPybZFpjhzpifwPP9wPRoiy5geTQKU4vqWA/go-libp2p-routing"
 	backoff "gx/ipfs/QmPJUtEJsm5YLUWhF6imvyCH8KZXRJa9Wup7FDMwTy5Ufz/backoff"
 	logging "gx/ipfs/QmSpJByNKFX1sCsHBEp3R73FL4NF6FnQTEGyNAXHm2GS52/go-log"
+	ds "gx/ipfs/QmdHG8MAuARdGHxx4rPQASLcvhz24fzjSQq7AJRAQEorq5/go-datastore"
 	cid "gx/ipfs/QmeSrf6pzut73u6zLQkRFQ3ygt3k6XFT2kjdYP8Tnkwwyg/go-cid"
 )
 
@@ -17,6 +19,9 @@ var log = logging.Logger("reprovider")
 type KeyChanFunc func(context.Context) (<-chan *cid.Cid, error)
 type doneFunc func(error)
 
+// DefaultWorkers is the number of keys provided in parallel by default.
+const DefaultWorkers = 8
+
 type Reprovider struct {
 	ctx     context.Context
 	trigger chan doneFunc
@@ -25,17 +30,55 @@ type Reprovider struct {
 	rsys routing.ContentRouting
 
 	keyProvider KeyChanFunc
+
+	// workers is the number of keys provided in parallel, and rate the
+	// maximum number of keys provided per second, or zero for no limit.
+	workers int
+	rate    int
+
+	// dstore keeps the outcome of the last run across restarts
+	dstore ds.Datastore
+
+	statLk   sync.Mutex
+	stat     Stat
+	provided uint64
+	failed   uint64
 }
 
-// NewReprovider creates new Reprovider instance.
-func NewReprovider(ctx context.Context, rsys routing.ContentRouting, keyProvider KeyChanFunc) *Reprovider {
-	return &Reprovider{
+// NewReprovider creates new Reprovider instance. The outcome of the last
+// run is kept in dstore, unless it is nil.
+func NewReprovider(ctx context.Context, rsys routing.ContentRouting, keyProvider KeyChanFunc, dstore ds.Datastore) *Reprovider {
+	rp := &Reprovider{
 		ctx:     ctx,
 		trigger: make(chan doneFunc),
 
 		rsys:        rsys,
 		keyProvider: keyProvider,
+
+		workers: DefaultWorkers,
+		dstore:  dstore,
 	}
+
+	if dstore != nil {
+		run, err := loadLastRun(dstore)
+		if err != nil {
+			log.Errorf("failed to load the last reprovide run: %s", err)
+		}
+		rp.stat.LastRun = run
+	}
+
+	return rp
+}
+
+// SetLimits sets the number of keys provided in parallel, and the maximum
+// number of keys provided per second. A rate of zero means no limit. It
+// must be called before Run.
+func (rp *Reprovider) SetLimits(workers, rate int) {
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+	rp.workers = workers
+	rp.rate = rate
 }
 
 // Run re-provides keys with 'tick' interval or when triggered
@@ -78,30 +121,83 @@ func (rp *Reprovider) Run(tick time.Duration) {
 
 // Reprovide registers all keys given by rp.keyProvider to libp2p content routing
 func (rp *Reprovider) Reprovide() error {
+	rp.startRun()
+	err := rp.reprovide()
+	rp.finishRun(err)
+	return err
+}
+
+func (rp *Reprovider) reprovide() error {
 	keychan, err := rp.keyProvider(rp.ctx)
 	if err != nil {
 		return fmt.Errorf("Failed to get key chan: %s", err)
 	}
+
+	var limit <-chan time.Time
+	if rp.rate > 0 {
+		ticker := time.NewTicker(time.Second / time.Duration(rp.rate))
+		defer ticker.Stop()
+		limit = ticker.C
+	}
+
+	keys := make(chan *cid.Cid)
+	var wg sync.WaitGroup
+	for i := 0; i < rp.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range keys {
+				rp.countKey(rp.provide(c))
+			}
+		}()
+	}
+
+feed:
 	for c := range keychan {
-		op := func() error {
-			err := rp.rsys.Provide(rp.ctx, c, true)
-			if err != nil {
-				log.Debugf("Failed to provide key: %s", err)
+		if limit != nil {
+			select {
+			case <-rp.ctx.Done():
+				break feed
+			case <-limit:
 			}
-			return err
 		}
 
-		// TODO: this backoff library does not respect our context, we should
-		// eventually work contexts into it. low priority.
-		err := backoff.Retry(op, backoff.NewExponentialBackOff())
-		if err != nil {
-			log.Debugf("Providing failed after number of retries: %s", err)
-			return err
+		select {
+		case <-rp.ctx.Done():
+			break feed
+		case keys <- c:
 		}
 	}
+	close(keys)
+	wg.Wait()
+
+	if err := rp.ctx.Err(); err != nil {
+		return err
+	}
+	if failed := rp.Stat().Current.Failed; failed > 0 {
+		return fmt.Errorf("failed to provide %d keys", failed)
+	}
 	return nil
 }
 
+func (rp *Reprovider) provide(c *cid.Cid) error {
+	op := func() error {
+		err := rp.rsys.Provide(rp.ctx, c, true)
+		if err != nil {
+			log.Debugf("Failed to provide key: %s", err)
+		}
+		return err
+	}
+
+	// TODO: this backoff library does not respect our context, we should
+	// eventually work contexts into it. low priority.
+	err := backoff.Retry(op, backoff.NewExponentialBackOff())
+	if err != nil {
+		log.Debugf("Providing failed after number of retries: %s", err)
+	}
+	return err
+}
+
 // Trigger starts reprovision process in rp.Run and waits for it
 func (rp *Reprovider) Trigger(ctx context.Context) error {
 	progressCtx, done := context.WithCancel(ctx)
@@ -2,6 +2,7 @@ package reprovide_test
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
 	blockstore "github.com/ipfs/go-ipfs/blocks/blockstore"
@@ -33,7 +34,7 @@ func TestReprovide(t *testing.T) {
 	bstore.Put(blk)
 
 	keyProvider := NewBlockstoreProvider(bstore)
-	reprov := NewReprovider(ctx, clA, keyProvider)
+	reprov := NewReprovider(ctx, clA, keyProvider, nil)
 	err := reprov.Reprovide()
 	if err != nil {
 		t.Fatal(err)
@@ -55,3 +56,48 @@ func TestReprovide(t *testing.T) {
 		t.Fatal("Somehow got the wrong peer back as a provider.")
 	}
 }
+
+func TestReprovideStat(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mrserv := mock.NewServer()
+	clA := mrserv.Client(testutil.RandIdentityOrFatal(t))
+
+	bstore := blockstore.NewBlockstore(dssync.MutexWrap(ds.NewMapDatastore()))
+	for i := 0; i < 20; i++ {
+		bstore.Put(blocks.NewBlock([]byte(fmt.Sprintf("block %d", i))))
+	}
+
+	dstore := dssync.MutexWrap(ds.NewMapDatastore())
+	reprov := NewReprovider(ctx, clA, NewBlockstoreProvider(bstore), dstore)
+	reprov.SetLimits(4, 0)
+	if err := reprov.Reprovide(); err != nil {
+		t.Fatal(err)
+	}
+
+	st := reprov.Stat()
+	if st.Running {
+		t.Fatal("expected the reprovider to be done")
+	}
+	if st.LastRun.Provided != 20 || st.LastRun.Failed != 0 {
+		t.Fatalf("expected 20 keys provided, got %d (%d failed)", st.LastRun.Provided, st.LastRun.Failed)
+	}
+	if st.TotalProvided != 20 {
+		t.Fatalf("expected 20 keys provided in total, got %d", st.TotalProvided)
+	}
+	if st.LastRun.Finished.Before(st.LastRun.Started) {
+		t.Fatal("expected the run to finish after it started")
+	}
+
+	// the last run is kept across restarts
+	last := st.LastRun
+	reprov = NewReprovider(ctx, clA, NewBlockstoreProvider(bstore), dstore)
+	st = reprov.Stat()
+	if st.LastRun.Provided != last.Provided || !st.LastRun.Finished.Equal(last.Finished) {
+		t.Fatalf("expected the last run to be loaded, got %+v", st.LastRun)
+	}
+	if st.TotalProvided != 0 {
+		t.Fatal("expected the totals to start from zero")
+	}
+}
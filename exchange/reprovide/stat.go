@@ -0,0 +1,142 @@
+package reprovide
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	ds "gx/ipfs/QmdHG8MAuARdGHxx4rPQASLcvhz24fzjSQq7AJRAQEorq5/go-datastore"
+)
+
+// lastRunKey is the datastore key under which the outcome of the last
+// reprovide run is kept.
+var lastRunKey = ds.NewKey("/local/reprovider/lastrun")
+
+// Run describes a single reprovide run.
+type Run struct {
+	Started  time.Time
+	Finished time.Time
+
+	// Provided and Failed are the number of keys that were announced, and
+	// that could not be announced after retrying.
+	Provided uint64
+	Failed   uint64
+
+	// Error is the error the run ended with, if any.
+	Error string `json:",omitempty"`
+}
+
+// Duration returns how long the run took, or has been running for.
+func (r Run) Duration() time.Duration {
+	if r.Started.IsZero() {
+		return 0
+	}
+	if r.Finished.IsZero() {
+		return time.Since(r.Started)
+	}
+	return r.Finished.Sub(r.Started)
+}
+
+// Stat is the state of a Reprovider.
+type Stat struct {
+	// Running is set while a run is in progress, in which case Current
+	// holds its progress so far.
+	Running bool
+	Current Run
+
+	// LastRun is the last finished run, which may come from before the
+	// daemon was restarted.
+	LastRun Run
+
+	// TotalProvided and TotalFailed count the keys of all the runs since
+	// the Reprovider was created.
+	TotalProvided uint64
+	TotalFailed   uint64
+}
+
+// Stat returns the progress of the current run and the outcome of the
+// last one.
+func (rp *Reprovider) Stat() Stat {
+	rp.statLk.Lock()
+	defer rp.statLk.Unlock()
+
+	st := rp.stat
+	if st.Running {
+		st.Current.Provided, st.Current.Failed = rp.provided, rp.failed
+	}
+	st.TotalProvided += rp.provided
+	st.TotalFailed += rp.failed
+	return st
+}
+
+func (rp *Reprovider) startRun() {
+	rp.statLk.Lock()
+	defer rp.statLk.Unlock()
+
+	rp.provided, rp.failed = 0, 0
+	rp.stat.Running = true
+	rp.stat.Current = Run{Started: time.Now()}
+}
+
+func (rp *Reprovider) countKey(err error) {
+	rp.statLk.Lock()
+	defer rp.statLk.Unlock()
+
+	if err != nil {
+		rp.failed++
+	} else {
+		rp.provided++
+	}
+}
+
+func (rp *Reprovider) finishRun(err error) {
+	rp.statLk.Lock()
+	defer rp.statLk.Unlock()
+
+	run := rp.stat.Current
+	run.Finished = time.Now()
+	run.Provided, run.Failed = rp.provided, rp.failed
+	if err != nil {
+		run.Error = err.Error()
+	}
+
+	rp.stat.TotalProvided += rp.provided
+	rp.stat.TotalFailed += rp.failed
+	rp.provided, rp.failed = 0, 0
+	rp.stat.Running = false
+	rp.stat.Current = Run{}
+	rp.stat.LastRun = run
+
+	if rp.dstore == nil {
+		return
+	}
+	b, err := json.Marshal(run)
+	if err != nil {
+		log.Errorf("failed to encode the last reprovide run: %s", err)
+		return
+	}
+	if err := rp.dstore.Put(lastRunKey, b); err != nil {
+		log.Errorf("failed to save the last reprovide run: %s", err)
+	}
+}
+
+// loadLastRun reads the outcome of the last run kept in d.
+func loadLastRun(d ds.Datastore) (Run, error) {
+	var run Run
+
+	val, err := d.Get(lastRunKey)
+	switch err {
+	case nil:
+	case ds.ErrNotFound:
+		return run, nil
+	default:
+		return run, err
+	}
+
+	b, ok := val.([]byte)
+	if !ok {
+		return run, fmt.Errorf("last reprovide run is not stored as bytes")
+	}
+	err = json.Unmarshal(b, &run)
+	return run, err
+}
@@ -23,9 +23,23 @@ var ErrNotYetImplemented = errors.New("not yet implemented")
 var ErrInvalidChild = errors.New("invalid child node")
 var ErrDirExists = errors.New("directory already has entry by that name")
 
+// ErrDetached is returned when changes made through a file or directory can
+// not be applied, because the tree it belonged to was replaced.
+var ErrDetached = errors.New("file or directory is no longer part of the filesystem")
+
+// detached is the parent of the files and directories a swap removed from
+// the tree. Their changes are refused, rather than written over the tree
+// that replaced them.
+type detached struct{}
+
+func (detached) closeChild(string, node.Node, bool) error {
+	return ErrDetached
+}
+
 type Directory struct {
-	dserv  dag.DAGService
-	parent childCloser
+	dserv    dag.DAGService
+	parent   childCloser
+	parentlk sync.Mutex
 
 	childDirs map[string]*Directory
 	files     map[string]*File
@@ -77,7 +91,7 @@ func (d *Directory) closeChild(name string, nd node.Node, sync bool) error {
 	}
 
 	if sync {
-		return d.parent.closeChild(d.name, mynd, true)
+		return d.getParent().closeChild(d.name, mynd, true)
 	}
 	return nil
 }
@@ -322,10 +336,21 @@ func (d *Directory) Mkdir(name string) (*Directory, error) {
 	}
 
 	d.childDirs[name] = dirobj
+	notify(d, name, EventCreate, ndir.Cid())
 	return dirobj, nil
 }
 
 func (d *Directory) Unlink(name string) error {
+	err := d.unlink(name)
+	if err != nil {
+		return err
+	}
+
+	notify(d, name, EventRemove, nil)
+	return nil
+}
+
+func (d *Directory) unlink(name string) error {
 	d.lock.Lock()
 	defer d.lock.Unlock()
 
@@ -335,17 +360,130 @@ func (d *Directory) Unlink(name string) error {
 	return d.dirbuilder.RemoveChild(d.ctx, name)
 }
 
+// SetAttrs sets the mode and modification time of the directory. A nil mode
+// or zero time clears them.
+func (d *Directory) SetAttrs(mode *os.FileMode, mtime time.Time) error {
+	err := d.setAttrs(mode, mtime)
+	if err != nil {
+		return err
+	}
+
+	nd, err := d.GetNode()
+	if err != nil {
+		return err
+	}
+
+	parent := d.getParent()
+	err = parent.closeChild(d.name, nd, true)
+	if err != nil {
+		return err
+	}
+
+	notify(parent, d.name, EventWrite, nd.Cid())
+	return nil
+}
+
+func (d *Directory) setAttrs(mode *os.FileMode, mtime time.Time) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	err := d.sync()
+	if err != nil {
+		return err
+	}
+
+	nd, err := d.dirbuilder.GetNode()
+	if err != nil {
+		return err
+	}
+
+	nd, err = ft.WithAttrs(nd, mode, mtime)
+	if err != nil {
+		return err
+	}
+
+	db, err := uio.NewDirectoryFromNode(d.dserv, nd)
+	if err != nil {
+		return err
+	}
+
+	d.dirbuilder = db
+	d.modTime = time.Now()
+	return nil
+}
+
+// swap replaces the content of the directory with nd, if the directory is
+// still at base or base is nil. Its children are detached from it: changes made through
+// the ones callers still hold fail with ErrDetached.
+func (d *Directory) swap(base *cid.Cid, nd *dag.ProtoNode) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	err := d.sync()
+	if err != nil {
+		return err
+	}
+
+	cur, err := d.dirbuilder.GetNode()
+	if err != nil {
+		return err
+	}
+	if base != nil && !cur.Cid().Equals(base) {
+		return ErrTxConflict
+	}
+
+	db, err := uio.NewDirectoryFromNode(d.dserv, nd)
+	if err != nil {
+		return err
+	}
+
+	for _, child := range d.childDirs {
+		child.setParent(detached{})
+	}
+	for _, child := range d.files {
+		child.setParent(detached{})
+	}
+
+	d.dirbuilder = db
+	d.childDirs = make(map[string]*Directory)
+	d.files = make(map[string]*File)
+	d.modTime = time.Now()
+	return nil
+}
+
+func (d *Directory) getParent() childCloser {
+	d.parentlk.Lock()
+	defer d.parentlk.Unlock()
+	return d.parent
+}
+
+func (d *Directory) setParent(parent childCloser) {
+	d.parentlk.Lock()
+	defer d.parentlk.Unlock()
+	d.parent = parent
+}
+
 func (d *Directory) Flush() error {
 	nd, err := d.GetNode()
 	if err != nil {
 		return err
 	}
 
-	return d.parent.closeChild(d.name, nd, true)
+	return d.getParent().closeChild(d.name, nd, true)
 }
 
 // AddChild adds the node 'nd' under this directory giving it the name 'name'
 func (d *Directory) AddChild(name string, nd node.Node) error {
+	err := d.addChild(name, nd)
+	if err != nil {
+		return err
+	}
+
+	notify(d, name, EventCreate, nd.Cid())
+	return nil
+}
+
+func (d *Directory) addChild(name string, nd node.Node) error {
 	d.lock.Lock()
 	defer d.lock.Unlock()
 
@@ -401,7 +539,7 @@ func (d *Directory) Path() string {
 	var out string
 	for cur != nil {
 		out = path.Join(cur.name, out)
-		cur = cur.parent.(*Directory)
+		cur = cur.getParent().(*Directory)
 	}
 	return out
 }
@@ -0,0 +1,145 @@
+package mfs
+
+import (
+	"context"
+	gopath "path"
+	"strings"
+
+	cid "gx/ipfs/QmeSrf6pzut73u6zLQkRFQ3ygt3k6XFT2kjdYP8Tnkwwyg/go-cid"
+)
+
+// watchBuffer is the number of events a watcher may fall behind by before
+// it is closed.
+const watchBuffer = 128
+
+type EventType int
+
+const (
+	// EventCreate is sent when a file or directory is added to a directory.
+	EventCreate EventType = iota
+	// EventWrite is sent when the content of a file changes, or when the
+	// whole tree is replaced by a committed transaction or a restored
+	// snapshot.
+	EventWrite
+	// EventRemove is sent when an entry is removed from a directory.
+	EventRemove
+	// EventMove is sent when an entry is moved, possibly replacing a file
+	// at its destination.
+	EventMove
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventCreate:
+		return "create"
+	case EventWrite:
+		return "write"
+	case EventRemove:
+		return "remove"
+	case EventMove:
+		return "move"
+	default:
+		return "unknown"
+	}
+}
+
+// Event describes a change made to a Root. Paths are absolute within the
+// root. Cid is the new node of the entry, and is nil for removals.
+type Event struct {
+	Type EventType
+	Path string
+	// From is the previous path of a moved entry.
+	From string
+	Cid  *cid.Cid
+}
+
+type watcher struct {
+	path string
+	ch   chan Event
+}
+
+// matches returns true if ev is about an entry at or below the watched path,
+// or about one of its ancestors, such as the root replaced by a transaction.
+func (w *watcher) matches(ev Event) bool {
+	return w.related(ev.Path) || (ev.From != "" && w.related(ev.From))
+}
+
+func (w *watcher) related(p string) bool {
+	return pathUnder(p, w.path) || pathUnder(w.path, p)
+}
+
+func pathUnder(p, dir string) bool {
+	return dir == "/" || p == dir || strings.HasPrefix(p, dir+"/")
+}
+
+// Watch returns a channel receiving the events at or below pth, or on one of
+// its ancestors, until ctx is done. Events are not sent to watchers that fall
+// too far behind, whose channel is closed instead.
+func (kr *Root) Watch(ctx context.Context, pth string) <-chan Event {
+	w := &watcher{
+		path: gopath.Clean("/" + pth),
+		ch:   make(chan Event, watchBuffer),
+	}
+
+	kr.watchlk.Lock()
+	kr.watchers[w] = struct{}{}
+	kr.watchlk.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		kr.unwatch(w)
+	}()
+
+	return w.ch
+}
+
+func (kr *Root) unwatch(w *watcher) {
+	kr.watchlk.Lock()
+	defer kr.watchlk.Unlock()
+
+	if _, ok := kr.watchers[w]; ok {
+		delete(kr.watchers, w)
+		close(w.ch)
+	}
+}
+
+func (kr *Root) notify(ev Event) {
+	kr.watchlk.Lock()
+	defer kr.watchlk.Unlock()
+
+	for w := range kr.watchers {
+		if !w.matches(ev) {
+			continue
+		}
+
+		select {
+		case w.ch <- ev:
+		default:
+			log.Warningf("closing watcher of %s, which fell behind", w.path)
+			delete(kr.watchers, w)
+			close(w.ch)
+		}
+	}
+}
+
+// notify sends an event for the entry name of parent to the watchers of the
+// root parent belongs to.
+func notify(parent childCloser, name string, typ EventType, c *cid.Cid) {
+	var elems []string
+	for {
+		switch p := parent.(type) {
+		case *Root:
+			// name is the one of the root itself
+			for i, j := 0, len(elems)-1; i < j; i, j = i+1, j-1 {
+				elems[i], elems[j] = elems[j], elems[i]
+			}
+			p.notify(Event{Type: typ, Path: "/" + gopath.Join(elems...), Cid: c})
+			return
+		case *Directory:
+			elems = append(elems, name)
+			name, parent = p.name, p.getParent()
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package mfs
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+func expectEvent(t *testing.T, ch <-chan Event, typ EventType, pth, from string) Event {
+	t.Helper()
+	select {
+	case ev, ok := <-ch:
+		if !ok {
+			t.Fatal("watcher closed")
+		}
+		if ev.Type != typ || ev.Path != pth || ev.From != from {
+			t.Fatalf("expected %s %s (from %q), got %s %s (from %q)", typ, pth, from, ev.Type, ev.Path, ev.From)
+		}
+		return ev
+	default:
+		t.Fatalf("expected %s %s, got no event", typ, pth)
+	}
+	return Event{}
+}
+
+func expectNoEvent(t *testing.T, ch <-chan Event) {
+	t.Helper()
+	select {
+	case ev := <-ch:
+		t.Fatalf("expected no event, got %s %s", ev.Type, ev.Path)
+	default:
+	}
+}
+
+func TestWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dserv, rt := setupRoot(ctx, t)
+
+	rootdir := rt.GetValue().(*Directory)
+
+	wctx, wcancel := context.WithCancel(ctx)
+	all := rt.Watch(wctx, "/")
+	sub := rt.Watch(ctx, "/a/b")
+
+	b := mkdirP(t, rootdir, "a/b")
+	expectEvent(t, all, EventCreate, "/a", "")
+	expectEvent(t, all, EventCreate, "/a/b", "")
+	expectEvent(t, sub, EventCreate, "/a", "")
+	expectEvent(t, sub, EventCreate, "/a/b", "")
+
+	fi := getRandFile(t, dserv, 1000)
+	if err := b.AddChild("file", fi); err != nil {
+		t.Fatal(err)
+	}
+	ev := expectEvent(t, all, EventCreate, "/a/b/file", "")
+	if !ev.Cid.Equals(fi.Cid()) {
+		t.Fatalf("expected cid %s, got %s", fi.Cid(), ev.Cid)
+	}
+	expectEvent(t, sub, EventCreate, "/a/b/file", "")
+
+	fsn, err := b.Child("file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fd, err := fsn.(*File).Open(OpenWriteOnly, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fd.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if err := fd.Close(); err != nil {
+		t.Fatal(err)
+	}
+	ev = expectEvent(t, all, EventWrite, "/a/b/file", "")
+	nd, err := fsn.GetNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ev.Cid.Equals(nd.Cid()) {
+		t.Fatalf("expected cid %s, got %s", nd.Cid(), ev.Cid)
+	}
+	expectEvent(t, sub, EventWrite, "/a/b/file", "")
+
+	// flushing without changes is not a write
+	if err := fsn.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	expectNoEvent(t, all)
+
+	// moves out of a watched directory are seen from both sides
+	if err := Mv(rt, "/a/b/file", "/moved"); err != nil {
+		t.Fatal(err)
+	}
+	expectEvent(t, all, EventMove, "/moved", "/a/b/file")
+	expectEvent(t, sub, EventMove, "/moved", "/a/b/file")
+	expectNoEvent(t, all)
+
+	if err := rootdir.Unlink("moved"); err != nil {
+		t.Fatal(err)
+	}
+	expectEvent(t, all, EventRemove, "/moved", "")
+	expectNoEvent(t, sub)
+
+	wcancel()
+	for range all {
+	}
+}
+
+func TestWatchAncestors(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dserv, rt := setupRoot(ctx, t)
+
+	rootdir := rt.GetValue().(*Directory)
+	b := mkdirP(t, rootdir, "a/b")
+	if err := b.AddChild("file", getRandFile(t, dserv, 1000)); err != nil {
+		t.Fatal(err)
+	}
+
+	sub := rt.Watch(ctx, "/a/b/file")
+	other := rt.Watch(ctx, "/c")
+
+	// a committed transaction replaces the whole tree
+	tx, err := rt.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Mkdir(tx.Root(), "/d", MkdirOpts{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	expectEvent(t, sub, EventWrite, "/", "")
+	expectEvent(t, other, EventWrite, "/", "")
+
+	// so does removing a parent directory
+	if err := rootdir.Unlink("a"); err != nil {
+		t.Fatal(err)
+	}
+	expectEvent(t, sub, EventRemove, "/a", "")
+	expectNoEvent(t, other)
+}
+
+func TestWatchSetAttrs(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dserv, rt := setupRoot(ctx, t)
+
+	rootdir := rt.GetValue().(*Directory)
+	b := mkdirP(t, rootdir, "a/b")
+	if err := b.AddChild("file", getRandFile(t, dserv, 1000)); err != nil {
+		t.Fatal(err)
+	}
+
+	all := rt.Watch(ctx, "/")
+
+	fsn, err := b.Child("file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mode := os.FileMode(0600)
+	if err := fsn.(*File).SetAttrs(&mode, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	ev := expectEvent(t, all, EventWrite, "/a/b/file", "")
+	nd, err := fsn.GetNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ev.Cid.Equals(nd.Cid()) {
+		t.Fatalf("expected cid %s, got %s", nd.Cid(), ev.Cid)
+	}
+
+	mode = 0700
+	if err := b.SetAttrs(&mode, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	ev = expectEvent(t, all, EventWrite, "/a/b", "")
+	nd, err = b.GetNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ev.Cid.Equals(nd.Cid()) {
+		t.Fatalf("expected cid %s, got %s", nd.Cid(), ev.Cid)
+	}
+	expectNoEvent(t, all)
+}
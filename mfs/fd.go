@@ -122,18 +122,32 @@ func (fi *fileDescriptor) flushUp(fullsync bool) error {
 		return err
 	}
 
+	nd, err = fi.inode.keepAttrs(nd)
+	if err != nil {
+		return err
+	}
+
 	_, err = fi.inode.dserv.Add(nd)
 	if err != nil {
 		return err
 	}
 
 	fi.inode.nodelk.Lock()
+	changed := !nd.Cid().Equals(fi.inode.node.Cid())
 	fi.inode.node = nd
 	name := fi.inode.name
 	parent := fi.inode.parent
 	fi.inode.nodelk.Unlock()
 
-	return parent.closeChild(name, nd, fullsync)
+	err = parent.closeChild(name, nd, fullsync)
+	if err != nil {
+		return err
+	}
+
+	if changed {
+		notify(parent, name, EventWrite, nd.Cid())
+	}
+	return nil
 }
 
 // Seek implements io.Seeker
@@ -3,7 +3,9 @@ package mfs
 import (
 	"context"
 	"fmt"
+	"os"
 	"sync"
+	"time"
 
 	chunk "github.com/ipfs/go-ipfs/importer/chunk"
 	dag "github.com/ipfs/go-ipfs/merkledag"
@@ -121,6 +123,70 @@ func (fi *File) GetNode() (node.Node, error) {
 	return fi.node, nil
 }
 
+// SetAttrs sets the mode and modification time of the file. A nil mode or
+// zero time clears them.
+func (fi *File) SetAttrs(mode *os.FileMode, mtime time.Time) error {
+	// wait for open descriptors, which would overwrite the node
+	fi.desclock.Lock()
+	defer fi.desclock.Unlock()
+
+	fi.nodelk.Lock()
+	nd, err := ft.WithAttrs(fi.node, mode, mtime)
+	if err != nil {
+		fi.nodelk.Unlock()
+		return err
+	}
+
+	_, err = fi.dserv.Add(nd)
+	if err != nil {
+		fi.nodelk.Unlock()
+		return err
+	}
+
+	fi.node = nd
+	name := fi.name
+	parent := fi.parent
+	fi.nodelk.Unlock()
+
+	err = parent.closeChild(name, nd, true)
+	if err != nil {
+		return err
+	}
+
+	notify(parent, name, EventWrite, nd.Cid())
+	return nil
+}
+
+func (fi *File) setParent(parent childCloser) {
+	fi.nodelk.Lock()
+	defer fi.nodelk.Unlock()
+	fi.parent = parent
+}
+
+// keepAttrs carries the mode of the file over to nd, a modified version of
+// its node. A modification time, if the file has one, is set to now.
+func (fi *File) keepAttrs(nd node.Node) (node.Node, error) {
+	fi.nodelk.Lock()
+	old := fi.node
+	fi.nodelk.Unlock()
+
+	if nd.Cid().Equals(old.Cid()) {
+		return nd, nil
+	}
+
+	mode, mtime, err := ft.Attrs(old)
+	if err != nil {
+		return nil, err
+	}
+	if mode == nil && mtime.IsZero() {
+		return nd, nil
+	}
+	if !mtime.IsZero() {
+		mtime = time.Now()
+	}
+	return ft.WithAttrs(nd, mode, mtime)
+}
+
 func (fi *File) Flush() error {
 	// open the file in fullsync mode
 	fd, err := fi.Open(OpenWriteOnly, true)
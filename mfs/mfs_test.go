@@ -1106,3 +1106,82 @@ func TestFileDescriptors(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestChmodTouch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ds, rt := setupRoot(ctx, t)
+	rootdir := rt.GetValue().(*Directory)
+	mkdirP(t, rootdir, "a/b")
+
+	fi := getRandFile(t, ds, 1000)
+	if err := PutNode(rt, "/a/b/file", fi); err != nil {
+		t.Fatal(err)
+	}
+
+	mtime := time.Unix(1500000000, 0)
+	if err := Chmod(rt, "/a/b/file", 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := Touch(rt, "/a/b/file", mtime); err != nil {
+		t.Fatal(err)
+	}
+	if err := Chmod(rt, "/a", 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	attrsAt := func(pth string) (os.FileMode, time.Time) {
+		fsn, err := Lookup(rt, pth)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nd, err := fsn.GetNode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		mode, mt, err := ft.Attrs(nd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mode == nil {
+			t.Fatalf("no mode at %s", pth)
+		}
+		return *mode, mt
+	}
+
+	mode, mt := attrsAt("/a/b/file")
+	if mode != 0600 || !mt.Equal(mtime) {
+		t.Fatalf("unexpected file attributes: %o %s", mode, mt)
+	}
+	mode, mt = attrsAt("/a")
+	if mode != 0700 || !mt.IsZero() {
+		t.Fatalf("unexpected directory attributes: %o %s", mode, mt)
+	}
+
+	// the directory keeps its mode as its children change
+	mkdirP(t, rootdir, "a/c")
+	if err := rootdir.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if mode, _ = attrsAt("/a"); mode != 0700 {
+		t.Fatalf("directory mode lost, got %o", mode)
+	}
+
+	// writing to the file keeps its mode and updates its modification time
+	if err := writeFile(rt, "/a/b/file", []byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	mode, mt = attrsAt("/a/b/file")
+	if mode != 0600 || !mt.After(mtime) {
+		t.Fatalf("unexpected attributes after a write: %o %s", mode, mt)
+	}
+
+	// a mode without any permission bits is kept
+	if err := Chmod(rt, "/a/b/file", 0); err != nil {
+		t.Fatal(err)
+	}
+	if mode, _ = attrsAt("/a/b/file"); mode != 0 {
+		t.Fatalf("expected mode 0000, got %o", mode)
+	}
+}
@@ -6,8 +6,10 @@ import (
 	"os"
 	gopath "path"
 	"strings"
+	"time"
 
 	path "github.com/ipfs/go-ipfs/path"
+	ft "github.com/ipfs/go-ipfs/unixfs"
 
 	node "gx/ipfs/QmNwUEK7QbwSqyKBu3mMtToo8SUc6wQJ7gdZq4gGGJqfnf/go-ipld-format"
 	cid "gx/ipfs/QmeSrf6pzut73u6zLQkRFQ3ygt3k6XFT2kjdYP8Tnkwwyg/go-cid"
@@ -47,13 +49,15 @@ func Mv(r *Root, src, dst string) error {
 		return err
 	}
 
+	dstPath := gopath.Join(dstDirStr, filename)
 	fsn, err := dstDir.Child(filename)
 	if err == nil {
 		switch n := fsn.(type) {
 		case *File:
-			_ = dstDir.Unlink(filename)
+			_ = dstDir.unlink(filename)
 		case *Directory:
 			dstDir = n
+			dstPath = gopath.Join(dstPath, filename)
 		default:
 			return fmt.Errorf("unexpected type at path: %s", dst)
 		}
@@ -61,12 +65,23 @@ func Mv(r *Root, src, dst string) error {
 		return err
 	}
 
-	err = dstDir.AddChild(filename, nd)
+	err = dstDir.addChild(filename, nd)
 	if err != nil {
 		return err
 	}
 
-	return srcDirObj.Unlink(srcFname)
+	err = srcDirObj.unlink(srcFname)
+	if err != nil {
+		return err
+	}
+
+	r.notify(Event{
+		Type: EventMove,
+		Path: gopath.Clean("/" + dstPath),
+		From: gopath.Clean("/" + src),
+		Cid:  nd.Cid(),
+	})
+	return nil
 }
 
 func lookupDir(r *Root, path string) (*Directory, error) {
@@ -223,3 +238,44 @@ func FlushPath(rt *Root, pth string) error {
 	rt.repub.WaitPub()
 	return nil
 }
+
+// Chmod sets the permission bits of the file or directory at 'pth'
+func Chmod(r *Root, pth string, mode os.FileMode) error {
+	return setAttrs(r, pth, func(_ *os.FileMode, mtime time.Time) (*os.FileMode, time.Time) {
+		return &mode, mtime
+	})
+}
+
+// Touch sets the modification time of the file or directory at 'pth'
+func Touch(r *Root, pth string, mtime time.Time) error {
+	return setAttrs(r, pth, func(mode *os.FileMode, _ time.Time) (*os.FileMode, time.Time) {
+		return mode, mtime
+	})
+}
+
+func setAttrs(r *Root, pth string, update func(*os.FileMode, time.Time) (*os.FileMode, time.Time)) error {
+	fsn, err := Lookup(r, pth)
+	if err != nil {
+		return err
+	}
+
+	nd, err := fsn.GetNode()
+	if err != nil {
+		return err
+	}
+
+	mode, mtime, err := ft.Attrs(nd)
+	if err != nil {
+		return err
+	}
+	mode, mtime = update(mode, mtime)
+
+	switch fsn := fsn.(type) {
+	case *Directory:
+		return fsn.SetAttrs(mode, mtime)
+	case *File:
+		return fsn.SetAttrs(mode, mtime)
+	default:
+		return fmt.Errorf("unexpected type at path: %s", pth)
+	}
+}
@@ -0,0 +1,275 @@
+package mfs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	gopath "path"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	dag "github.com/ipfs/go-ipfs/merkledag"
+	uio "github.com/ipfs/go-ipfs/unixfs/io"
+
+	node "gx/ipfs/QmNwUEK7QbwSqyKBu3mMtToo8SUc6wQJ7gdZq4gGGJqfnf/go-ipld-format"
+	ds "gx/ipfs/QmdHG8MAuARdGHxx4rPQASLcvhz24fzjSQq7AJRAQEorq5/go-datastore"
+	dsq "gx/ipfs/QmdHG8MAuARdGHxx4rPQASLcvhz24fzjSQq7AJRAQEorq5/go-datastore/query"
+	cid "gx/ipfs/QmeSrf6pzut73u6zLQkRFQ3ygt3k6XFT2kjdYP8Tnkwwyg/go-cid"
+)
+
+// ErrNoSnapshot is returned when a snapshot does not exist.
+var ErrNoSnapshot = errors.New("no such snapshot")
+
+// snapshotKeyPrefix is the datastore key under which snapshots are kept,
+// one per snapshot.
+var snapshotKeyPrefix = ds.NewKey("/local/filesroot/snapshots")
+
+// Snapshot is a recorded state of a filesystem root.
+type Snapshot struct {
+	ID      uint64
+	Name    string `json:",omitempty"`
+	Created time.Time
+	Cid     *cid.Cid
+
+	// Pinned is set if a pin was added for the snapshot when it was
+	// created, so that its content is kept by the garbage collector. It is
+	// not set if the root was already pinned by other means.
+	Pinned bool
+}
+
+// SnapshotStore keeps snapshots in a datastore.
+type SnapshotStore struct {
+	lk sync.Mutex
+	ds ds.Datastore
+}
+
+// NewSnapshotStore returns a SnapshotStore keeping snapshots in d.
+func NewSnapshotStore(d ds.Datastore) *SnapshotStore {
+	return &SnapshotStore{ds: d}
+}
+
+// lastSnapshotKey is the datastore key of the last snapshot ID given out,
+// so that the IDs of removed snapshots are not reused.
+var lastSnapshotKey = ds.NewKey("/local/filesroot/lastsnapshot")
+
+func snapshotKey(id uint64) ds.Key {
+	return snapshotKeyPrefix.ChildString(strconv.FormatUint(id, 10))
+}
+
+// lastID returns the last snapshot ID given out. Repos from before the ID
+// was kept fall back to the highest ID of the remaining snapshots.
+func (s *SnapshotStore) lastID() (uint64, error) {
+	val, err := s.ds.Get(lastSnapshotKey)
+	switch err {
+	case nil:
+		b, ok := val.([]byte)
+		if !ok {
+			return 0, fmt.Errorf("last snapshot ID is not stored as bytes")
+		}
+		return strconv.ParseUint(string(b), 10, 64)
+	case ds.ErrNotFound:
+	default:
+		return 0, err
+	}
+
+	snaps, err := s.list()
+	if err != nil {
+		return 0, err
+	}
+	if len(snaps) == 0 {
+		return 0, nil
+	}
+	return snaps[len(snaps)-1].ID, nil
+}
+
+// Create records c as a new snapshot, and returns it.
+func (s *SnapshotStore) Create(c *cid.Cid, name string, pinned bool) (*Snapshot, error) {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	last, err := s.lastID()
+	if err != nil {
+		return nil, err
+	}
+
+	snap := &Snapshot{
+		ID:      last + 1,
+		Name:    name,
+		Created: time.Now(),
+		Cid:     c,
+		Pinned:  pinned,
+	}
+
+	b, err := json.Marshal(snap)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ds.Put(snapshotKey(snap.ID), b); err != nil {
+		return nil, err
+	}
+	id := strconv.FormatUint(snap.ID, 10)
+	if err := s.ds.Put(lastSnapshotKey, []byte(id)); err != nil {
+		return nil, err
+	}
+	return snap, nil
+}
+
+// Get returns the snapshot with the given ID, or ErrNoSnapshot.
+func (s *SnapshotStore) Get(id uint64) (*Snapshot, error) {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	val, err := s.ds.Get(snapshotKey(id))
+	switch err {
+	case nil:
+	case ds.ErrNotFound:
+		return nil, ErrNoSnapshot
+	default:
+		return nil, err
+	}
+
+	return decodeSnapshot(val)
+}
+
+// List returns all the snapshots, oldest first.
+func (s *SnapshotStore) List() ([]*Snapshot, error) {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	return s.list()
+}
+
+func (s *SnapshotStore) list() ([]*Snapshot, error) {
+	res, err := s.ds.Query(dsq.Query{Prefix: snapshotKeyPrefix.String()})
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+
+	var snaps []*Snapshot
+	for {
+		e, ok := res.NextSync()
+		if !ok {
+			break
+		}
+		if e.Error != nil {
+			return nil, e.Error
+		}
+
+		snap, err := decodeSnapshot(e.Value)
+		if err != nil {
+			return nil, fmt.Errorf("snapshot %s: %s", e.Key, err)
+		}
+		snaps = append(snaps, snap)
+	}
+
+	sort.Slice(snaps, func(i, j int) bool {
+		return snaps[i].ID < snaps[j].ID
+	})
+	return snaps, nil
+}
+
+// Remove deletes the snapshot with the given ID, and returns it.
+func (s *SnapshotStore) Remove(id uint64) (*Snapshot, error) {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	val, err := s.ds.Get(snapshotKey(id))
+	switch err {
+	case nil:
+	case ds.ErrNotFound:
+		return nil, ErrNoSnapshot
+	default:
+		return nil, err
+	}
+
+	snap, err := decodeSnapshot(val)
+	if err != nil {
+		return nil, err
+	}
+	return snap, s.ds.Delete(snapshotKey(id))
+}
+
+func decodeSnapshot(val interface{}) (*Snapshot, error) {
+	b, ok := val.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("snapshot is not stored as bytes")
+	}
+
+	snap := new(Snapshot)
+	if err := json.Unmarshal(b, snap); err != nil {
+		return nil, err
+	}
+	return snap, nil
+}
+
+// Restore replaces what is at path in the filesystem with nd, typically
+// taken from the same path in a snapshot. Restoring "/" replaces the root
+// directory with nd, which must be a directory, in a single update.
+func Restore(ctx context.Context, r *Root, pth string, nd node.Node) error {
+	pth = gopath.Clean(pth)
+	if pth != "/" {
+		dir, name := gopath.Split(pth)
+		parent, err := lookupDir(r, dir)
+		if err == os.ErrNotExist {
+			err = Mkdir(r, dir, MkdirOpts{Mkparents: true})
+			if err != nil {
+				return err
+			}
+			parent, err = lookupDir(r, dir)
+		}
+		if err != nil {
+			return err
+		}
+
+		if _, err := parent.Child(name); err == nil {
+			if err := parent.Unlink(name); err != nil {
+				return err
+			}
+		}
+		if err := parent.AddChild(name, nd); err != nil {
+			return err
+		}
+		return parent.Flush()
+	}
+
+	root, ok := r.GetValue().(*Directory)
+	if !ok {
+		return fmt.Errorf("root is not a directory")
+	}
+
+	pbnd, ok := nd.(*dag.ProtoNode)
+	if !ok {
+		return fmt.Errorf("cannot restore the root from a file")
+	}
+	snapdir, err := uio.NewDirectoryFromNode(r.dserv, pbnd)
+	if err != nil {
+		return fmt.Errorf("cannot restore the root from a file: %s", err)
+	}
+
+	// fetch the entries first, so that the root is left as it is if they
+	// are not available
+	links, err := snapdir.Links(ctx)
+	if err != nil {
+		return err
+	}
+	for _, l := range links {
+		if _, err := l.GetNode(ctx, r.dserv); err != nil {
+			return err
+		}
+	}
+
+	if err := root.swap(nil, pbnd); err != nil {
+		return err
+	}
+	if err := root.Flush(); err != nil {
+		return err
+	}
+
+	r.notify(Event{Type: EventWrite, Path: "/", Cid: pbnd.Cid()})
+	return nil
+}
@@ -0,0 +1,125 @@
+package mfs
+
+import (
+	"context"
+	"testing"
+
+	dag "github.com/ipfs/go-ipfs/merkledag"
+	ft "github.com/ipfs/go-ipfs/unixfs"
+
+	ds "gx/ipfs/QmdHG8MAuARdGHxx4rPQASLcvhz24fzjSQq7AJRAQEorq5/go-datastore"
+	dssync "gx/ipfs/QmdHG8MAuARdGHxx4rPQASLcvhz24fzjSQq7AJRAQEorq5/go-datastore/sync"
+)
+
+func TestSnapshotStore(t *testing.T) {
+	store := NewSnapshotStore(dssync.MutexWrap(ds.NewMapDatastore()))
+
+	a := emptyDirNode().Cid()
+	for i := 0; i < 3; i++ {
+		snap, err := store.Create(a, "", i == 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if snap.ID != uint64(i+1) {
+			t.Fatalf("expected snapshot %d, got %d", i+1, snap.ID)
+		}
+	}
+
+	snap, err := store.Get(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !snap.Cid.Equals(a) || !snap.Pinned {
+		t.Fatalf("unexpected snapshot %+v", snap)
+	}
+
+	if _, err := store.Remove(3); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get(3); err != ErrNoSnapshot {
+		t.Fatalf("expected the snapshot to be removed, got %v", err)
+	}
+
+	snaps, err := store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snaps) != 2 || snaps[0].ID != 1 || snaps[1].ID != 2 {
+		t.Fatalf("expected snapshots 1 and 2, got %v", snaps)
+	}
+
+	// IDs of removed snapshots are not reused
+	snap, err = store.Create(a, "later", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap.ID != 4 || snap.Name != "later" {
+		t.Fatalf("unexpected snapshot %+v", snap)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dserv, rt := setupRoot(ctx, t)
+
+	rootdir := rt.GetValue().(*Directory)
+	d := mkdirP(t, rootdir, "a/b")
+	fi := getRandFile(t, dserv, 1000)
+	if err := d.AddChild("afile", fi); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot, err := rootdir.GetNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	anode, err := mkdirP(t, rootdir, "a").GetNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := rootdir.Unlink("a"); err != nil {
+		t.Fatal(err)
+	}
+	mkdirP(t, rootdir, "c")
+
+	// restore a single directory, leaving the rest alone
+	if err := Restore(ctx, rt, "/a", anode); err != nil {
+		t.Fatal(err)
+	}
+	if err := assertFileAtPath(dserv, rootdir, fi, "a/b/afile"); err != nil {
+		t.Fatal(err)
+	}
+	if err := assertDirAtPath(rootdir, "/", []string{"a", "c"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// restore everything
+	if err := Restore(ctx, rt, "/", snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if err := assertDirAtPath(rootdir, "/", []string{"a"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := assertFileAtPath(dserv, rootdir, fi, "a/b/afile"); err != nil {
+		t.Fatal(err)
+	}
+
+	// files cannot replace the root
+	if err := Restore(ctx, rt, "/", fi); err == nil {
+		t.Fatal("expected an error restoring the root from a file")
+	}
+
+	// a snapshot whose content is missing leaves the root as it is
+	missing := ft.EmptyDirNode()
+	if err := missing.AddNodeLinkClean("x", dag.NodeWithData(ft.FilePBData([]byte("x"), 1))); err != nil {
+		t.Fatal(err)
+	}
+	if err := Restore(ctx, rt, "/", missing); err == nil {
+		t.Fatal("expected an error restoring the root from missing content")
+	}
+	if err := assertDirAtPath(rootdir, "/", []string{"a"}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -21,6 +21,7 @@ import (
 
 	node "gx/ipfs/QmNwUEK7QbwSqyKBu3mMtToo8SUc6wQJ7gdZq4gGGJqfnf/go-ipld-format"
 	logging "gx/ipfs/QmSpJByNKFX1sCsHBEp3R73FL4NF6FnQTEGyNAXHm2GS52/go-log"
+	ds "gx/ipfs/QmdHG8MAuARdGHxx4rPQASLcvhz24fzjSQq7AJRAQEorq5/go-datastore"
 	cid "gx/ipfs/QmeSrf6pzut73u6zLQkRFQ3ygt3k6XFT2kjdYP8Tnkwwyg/go-cid"
 )
 
@@ -61,6 +62,18 @@ type Root struct {
 	dserv dag.DAGService
 
 	Type string
+
+	ctx context.Context
+
+	// open transactions, see tx.go
+	txlk   sync.Mutex
+	txs    map[uint64]*Tx
+	txds   ds.Datastore
+	lastTx uint64
+
+	// watchers of changes, see events.go
+	watchlk  sync.Mutex
+	watchers map[*watcher]struct{}
 }
 
 type PubFunc func(context.Context, *cid.Cid) error
@@ -76,9 +89,12 @@ func NewRoot(parent context.Context, ds dag.DAGService, node *dag.ProtoNode, pf
 	}
 
 	root := &Root{
-		node:  node,
-		repub: repub,
-		dserv: ds,
+		node:     node,
+		repub:    repub,
+		dserv:    ds,
+		ctx:      parent,
+		txs:      make(map[uint64]*Tx),
+		watchers: make(map[*watcher]struct{}),
 	}
 
 	pbn, err := ft.FromBytes(node.Data())
@@ -168,6 +184,10 @@ func (kr *Root) closeChild(name string, nd node.Node, sync bool) error {
 }
 
 func (kr *Root) Close() error {
+	if err := kr.closeTransactions(); err != nil {
+		return err
+	}
+
 	nd, err := kr.GetValue().GetNode()
 	if err != nil {
 		return err
@@ -0,0 +1,331 @@
+package mfs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	dag "github.com/ipfs/go-ipfs/merkledag"
+
+	ds "gx/ipfs/QmdHG8MAuARdGHxx4rPQASLcvhz24fzjSQq7AJRAQEorq5/go-datastore"
+	dsq "gx/ipfs/QmdHG8MAuARdGHxx4rPQASLcvhz24fzjSQq7AJRAQEorq5/go-datastore/query"
+	cid "gx/ipfs/QmeSrf6pzut73u6zLQkRFQ3ygt3k6XFT2kjdYP8Tnkwwyg/go-cid"
+)
+
+// ErrTxConflict is returned when committing a transaction whose root changed
+// since the transaction began.
+var ErrTxConflict = errors.New("the root changed since the transaction began")
+
+// ErrNoTx is returned when a transaction does not exist.
+var ErrNoTx = errors.New("no such transaction")
+
+// txKeyPrefix is the datastore key under which transactions are kept, one
+// per transaction.
+var txKeyPrefix = ds.NewKey("/local/filesroot/tx")
+
+// lastTxKey is the datastore key of the last transaction ID given out, so
+// that the IDs of committed and rolled back transactions are not reused.
+var lastTxKey = ds.NewKey("/local/filesroot/lasttx")
+
+// Tx is a set of changes to a Root. The changes are staged against a private
+// working copy of the root, and applied as a single update when the
+// transaction is committed.
+type Tx struct {
+	ID      uint64
+	Created time.Time
+
+	// Base is the root the transaction began from. Committing fails if the
+	// root changed since.
+	Base *cid.Cid
+
+	root *Root
+	work *Root
+}
+
+// txRecord is how a transaction is kept in the datastore.
+type txRecord struct {
+	ID      uint64
+	Created time.Time
+	Base    *cid.Cid
+	Root    *cid.Cid
+}
+
+func txKey(id uint64) ds.Key {
+	return txKeyPrefix.ChildString(strconv.FormatUint(id, 10))
+}
+
+// Root returns the working copy of the root, which the operations of the
+// transaction are staged against.
+func (tx *Tx) Root() *Root {
+	return tx.work
+}
+
+// LoadTransactions loads the open transactions kept in d, and keeps the
+// transactions begun from now on there, so that they outlive the process.
+func (kr *Root) LoadTransactions(d ds.Datastore) error {
+	kr.txlk.Lock()
+	defer kr.txlk.Unlock()
+
+	kr.txds = d
+
+	val, err := d.Get(lastTxKey)
+	switch err {
+	case nil:
+		b, ok := val.([]byte)
+		if !ok {
+			return fmt.Errorf("last transaction ID is not stored as bytes")
+		}
+		last, err := strconv.ParseUint(string(b), 10, 64)
+		if err != nil {
+			return err
+		}
+		if last > kr.lastTx {
+			kr.lastTx = last
+		}
+	case ds.ErrNotFound:
+	default:
+		return err
+	}
+
+	res, err := d.Query(dsq.Query{Prefix: txKeyPrefix.String()})
+	if err != nil {
+		return err
+	}
+	defer res.Close()
+
+	for {
+		e, ok := res.NextSync()
+		if !ok {
+			break
+		}
+		if e.Error != nil {
+			return e.Error
+		}
+
+		b, ok := e.Value.([]byte)
+		if !ok {
+			return fmt.Errorf("transaction %s is not stored as bytes", e.Key)
+		}
+		rec := new(txRecord)
+		if err := json.Unmarshal(b, rec); err != nil {
+			return fmt.Errorf("transaction %s: %s", e.Key, err)
+		}
+		if rec.ID > kr.lastTx {
+			kr.lastTx = rec.ID
+		}
+
+		nd, err := kr.dserv.Get(kr.ctx, rec.Root)
+		if err != nil {
+			log.Errorf("cannot load transaction %d: %s", rec.ID, err)
+			continue
+		}
+		pbnd, ok := nd.(*dag.ProtoNode)
+		if !ok {
+			log.Errorf("cannot load transaction %d: %s", rec.ID, dag.ErrNotProtobuf)
+			continue
+		}
+
+		tx, err := kr.openTx(rec, pbnd)
+		if err != nil {
+			log.Errorf("cannot load transaction %d: %s", rec.ID, err)
+			continue
+		}
+		kr.txs[rec.ID] = tx
+	}
+	return nil
+}
+
+// Begin starts a transaction from the current state of the root.
+func (kr *Root) Begin() (*Tx, error) {
+	dir, ok := kr.GetValue().(*Directory)
+	if !ok {
+		return nil, fmt.Errorf("root is not a directory")
+	}
+
+	nd, err := dir.GetNode()
+	if err != nil {
+		return nil, err
+	}
+	pbnd, ok := nd.(*dag.ProtoNode)
+	if !ok {
+		return nil, dag.ErrNotProtobuf
+	}
+
+	kr.txlk.Lock()
+	defer kr.txlk.Unlock()
+
+	rec := &txRecord{
+		ID:      kr.lastTx + 1,
+		Created: time.Now(),
+		Base:    nd.Cid(),
+		Root:    nd.Cid(),
+	}
+	if err := kr.putTx(rec); err != nil {
+		return nil, err
+	}
+	if kr.txds != nil {
+		id := strconv.FormatUint(rec.ID, 10)
+		if err := kr.txds.Put(lastTxKey, []byte(id)); err != nil {
+			return nil, err
+		}
+	}
+	kr.lastTx = rec.ID
+
+	tx, err := kr.openTx(rec, pbnd)
+	if err != nil {
+		return nil, err
+	}
+	kr.txs[rec.ID] = tx
+	return tx, nil
+}
+
+// openTx returns the transaction described by rec, whose working copy is nd.
+func (kr *Root) openTx(rec *txRecord, nd *dag.ProtoNode) (*Tx, error) {
+	var pf PubFunc
+	if kr.txds != nil {
+		// keep the working copy up to date in the datastore
+		pf = func(ctx context.Context, c *cid.Cid) error {
+			return kr.putTx(&txRecord{
+				ID:      rec.ID,
+				Created: rec.Created,
+				Base:    rec.Base,
+				Root:    c,
+			})
+		}
+	}
+
+	work, err := NewRoot(kr.ctx, kr.dserv, nd, pf)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Tx{
+		ID:      rec.ID,
+		Created: rec.Created,
+		Base:    rec.Base,
+		root:    kr,
+		work:    work,
+	}, nil
+}
+
+func (kr *Root) putTx(rec *txRecord) error {
+	if kr.txds == nil {
+		return nil
+	}
+
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return kr.txds.Put(txKey(rec.ID), b)
+}
+
+// Tx returns the open transaction with the given ID, or ErrNoTx.
+func (kr *Root) Tx(id uint64) (*Tx, error) {
+	kr.txlk.Lock()
+	defer kr.txlk.Unlock()
+
+	tx, ok := kr.txs[id]
+	if !ok {
+		return nil, ErrNoTx
+	}
+	return tx, nil
+}
+
+// Transactions returns the open transactions, oldest first.
+func (kr *Root) Transactions() []*Tx {
+	kr.txlk.Lock()
+	defer kr.txlk.Unlock()
+
+	txs := make([]*Tx, 0, len(kr.txs))
+	for _, tx := range kr.txs {
+		txs = append(txs, tx)
+	}
+	sort.Slice(txs, func(i, j int) bool {
+		return txs[i].ID < txs[j].ID
+	})
+	return txs
+}
+
+// closeTransactions stops the working copies of the open transactions,
+// which are still kept in the datastore.
+func (kr *Root) closeTransactions() error {
+	kr.txlk.Lock()
+	defer kr.txlk.Unlock()
+
+	for _, tx := range kr.txs {
+		if err := tx.work.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Commit applies the changes of the transaction to its root as a single
+// update, and returns the new root. If the root changed since the
+// transaction began, it fails with ErrTxConflict and the transaction is
+// left open.
+func (tx *Tx) Commit() (*cid.Cid, error) {
+	kr := tx.root
+	kr.txlk.Lock()
+	defer kr.txlk.Unlock()
+
+	if kr.txs[tx.ID] != tx {
+		return nil, ErrNoTx
+	}
+
+	dir, ok := kr.GetValue().(*Directory)
+	if !ok {
+		return nil, fmt.Errorf("root is not a directory")
+	}
+
+	nd, err := tx.work.GetValue().GetNode()
+	if err != nil {
+		return nil, err
+	}
+	pbnd, ok := nd.(*dag.ProtoNode)
+	if !ok {
+		return nil, dag.ErrNotProtobuf
+	}
+
+	if err := dir.swap(tx.Base, pbnd); err != nil {
+		return nil, err
+	}
+	if err := kr.closeChild(dir.name, pbnd, true); err != nil {
+		return nil, err
+	}
+	kr.notify(Event{Type: EventWrite, Path: "/", Cid: pbnd.Cid()})
+
+	return pbnd.Cid(), tx.close()
+}
+
+// Rollback discards the transaction.
+func (tx *Tx) Rollback() error {
+	kr := tx.root
+	kr.txlk.Lock()
+	defer kr.txlk.Unlock()
+
+	if kr.txs[tx.ID] != tx {
+		return ErrNoTx
+	}
+	return tx.close()
+}
+
+// close forgets the transaction. It must be called with the root's
+// transaction lock taken.
+func (tx *Tx) close() error {
+	kr := tx.root
+	delete(kr.txs, tx.ID)
+
+	if err := tx.work.Close(); err != nil {
+		return err
+	}
+	if kr.txds == nil {
+		return nil
+	}
+	return kr.txds.Delete(txKey(tx.ID))
+}
@@ -0,0 +1,251 @@
+package mfs
+
+import (
+	"context"
+	"testing"
+
+	ds "gx/ipfs/QmdHG8MAuARdGHxx4rPQASLcvhz24fzjSQq7AJRAQEorq5/go-datastore"
+	dssync "gx/ipfs/QmdHG8MAuARdGHxx4rPQASLcvhz24fzjSQq7AJRAQEorq5/go-datastore/sync"
+)
+
+func TestTxCommit(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dserv, rt := setupRoot(ctx, t)
+
+	rootdir := rt.GetValue().(*Directory)
+	fi := getRandFile(t, dserv, 1000)
+	if err := rootdir.AddChild("a", fi); err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := rt.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Mkdir(tx.Root(), "/b", MkdirOpts{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Mv(tx.Root(), "/a", "/b/a"); err != nil {
+		t.Fatal(err)
+	}
+
+	// nothing is applied before the commit
+	if err := assertDirAtPath(rootdir, "/", []string{"a"}); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := tx.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := assertDirAtPath(rootdir, "/", []string{"b"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := assertFileAtPath(dserv, rootdir, fi, "b/a"); err != nil {
+		t.Fatal(err)
+	}
+
+	nd, err := rootdir.GetNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !nd.Cid().Equals(c) {
+		t.Fatalf("expected the root to be %s, got %s", c, nd.Cid())
+	}
+
+	if _, err := rt.Tx(tx.ID); err != ErrNoTx {
+		t.Fatalf("expected the transaction to be closed, got %v", err)
+	}
+	if _, err := tx.Commit(); err != ErrNoTx {
+		t.Fatalf("expected committing twice to fail, got %v", err)
+	}
+}
+
+func TestTxConflict(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, rt := setupRoot(ctx, t)
+
+	rootdir := rt.GetValue().(*Directory)
+
+	tx, err := rt.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Mkdir(tx.Root(), "/fromtx", MkdirOpts{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// a change made outside of the transaction, not flushed yet
+	mkdirP(t, rootdir, "outside")
+
+	if _, err := tx.Commit(); err != ErrTxConflict {
+		t.Fatalf("expected a conflict, got %v", err)
+	}
+	if err := assertDirAtPath(rootdir, "/", []string{"outside"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the transaction is left open
+	if txs := rt.Transactions(); len(txs) != 1 || txs[0] != tx {
+		t.Fatalf("expected the transaction to be open, got %v", txs)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	if txs := rt.Transactions(); len(txs) != 0 {
+		t.Fatalf("expected no open transactions, got %v", txs)
+	}
+}
+
+func TestTxPersist(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dserv, rt := setupRoot(ctx, t)
+
+	dstore := dssync.MutexWrap(ds.NewMapDatastore())
+	if err := rt.LoadTransactions(dstore); err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := rt.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Mkdir(tx.Root(), "/staged", MkdirOpts{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := rt.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// load the transaction again, as after a restart
+	rt, err = NewRoot(ctx, dserv, emptyDirNode(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rt.LoadTransactions(dstore); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := rt.Tx(tx.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.Base.Equals(tx.Base) {
+		t.Fatalf("expected base %s, got %s", tx.Base, loaded.Base)
+	}
+	workdir := loaded.Root().GetValue().(*Directory)
+	if err := assertDirAtPath(workdir, "/", []string{"staged"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := loaded.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := assertDirAtPath(rt.GetValue().(*Directory), "/", []string{"staged"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// IDs keep increasing, and committed transactions are gone
+	next, err := rt.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.ID != tx.ID+1 {
+		t.Fatalf("expected transaction %d, got %d", tx.ID+1, next.ID)
+	}
+
+	again, err := NewRoot(ctx, dserv, emptyDirNode(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := again.LoadTransactions(dstore); err != nil {
+		t.Fatal(err)
+	}
+	if txs := again.Transactions(); len(txs) != 1 || txs[0].ID != next.ID {
+		t.Fatalf("expected only transaction %d to be kept, got %v", next.ID, txs)
+	}
+
+	// IDs are not reused once no transaction is left open
+	if err := next.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	if err := rt.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	rt, err = NewRoot(ctx, dserv, emptyDirNode(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rt.LoadTransactions(dstore); err != nil {
+		t.Fatal(err)
+	}
+	if txs := rt.Transactions(); len(txs) != 0 {
+		t.Fatalf("expected no open transactions, got %v", txs)
+	}
+	last, err := rt.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last.ID != next.ID+1 {
+		t.Fatalf("expected transaction %d, got %d", next.ID+1, last.ID)
+	}
+}
+
+func TestTxCommitWithOpenFile(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dserv, rt := setupRoot(ctx, t)
+
+	rootdir := rt.GetValue().(*Directory)
+	if err := rootdir.AddChild("a", getRandFile(t, dserv, 1000)); err != nil {
+		t.Fatal(err)
+	}
+	if err := rootdir.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	fsn, err := rootdir.Child("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fd, err := fsn.(*File).Open(OpenWriteOnly, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fd.Write([]byte("stale")); err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := rt.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Root().GetValue().(*Directory).Unlink("a"); err != nil {
+		t.Fatal(err)
+	}
+	c, err := tx.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the descriptor was opened before the commit, and must not write the
+	// file back
+	if err := fd.Close(); err != ErrDetached {
+		t.Fatalf("expected ErrDetached, got %v", err)
+	}
+	if err := assertDirAtPath(rootdir, "/", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	nd, err := rootdir.GetNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !nd.Cid().Equals(c) {
+		t.Fatalf("expected the root to stay at %s, got %s", c, nd.Cid())
+	}
+}
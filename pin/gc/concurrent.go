@@ -0,0 +1,222 @@
+package gc
+
+import (
+	"context"
+	"fmt"
+
+	bstore "github.com/ipfs/go-ipfs/blocks/blockstore"
+	dag "github.com/ipfs/go-ipfs/merkledag"
+	pin "github.com/ipfs/go-ipfs/pin"
+
+	node "gx/ipfs/QmNwUEK7QbwSqyKBu3mMtToo8SUc6wQJ7gdZq4gGGJqfnf/go-ipld-format"
+	logging "gx/ipfs/QmSpJByNKFX1sCsHBEp3R73FL4NF6FnQTEGyNAXHm2GS52/go-log"
+	cid "gx/ipfs/QmeSrf6pzut73u6zLQkRFQ3ygt3k6XFT2kjdYP8Tnkwwyg/go-cid"
+)
+
+// DefaultSweepBatchSize is the number of blocks ConcurrentGC deletes while
+// holding the GCLock, if no batch size is given.
+const DefaultSweepBatchSize = 1024
+
+// ConcurrentGC performs a mark and sweep garbage collection like GC, but
+// without holding the GCLock while marking, so adds and pins can go on
+// while it runs.
+//
+// Blocks written while marking are recorded by a write barrier on the
+// blockstore. The sweep then deletes unmarked blocks in batches of at most
+// batchSize. Before each batch it takes the GCLock and marks anything that
+// became reachable in the meantime: the recorded blocks, and pins created
+// since marking started, plus their descendants.
+func ConcurrentGC(ctx context.Context, bs bstore.GCBlockstore, ls dag.LinkService, pn pin.Pinner, bestEffortRoots []*cid.Cid, batchSize int) <-chan Result {
+	output := make(chan Result, 128)
+
+	cbs, ok := bs.(bstore.ConcurrentGCBlockstore)
+	if !ok {
+		output <- Result{Error: bstore.ErrNoWriteBarrier}
+		close(output)
+		return output
+	}
+
+	wb, err := cbs.StartWriteBarrier()
+	if err != nil {
+		output <- Result{Error: err}
+		close(output)
+		return output
+	}
+
+	if batchSize <= 0 {
+		batchSize = DefaultSweepBatchSize
+	}
+
+	ls = ls.GetOfflineLinkService()
+
+	go func() {
+		defer close(output)
+		defer wb.Stop()
+
+		emark := log.EventBegin(ctx, "GC.mark")
+		gcs, err := ColoredSet(ctx, pn, ls, bestEffortRoots, output)
+		if err != nil {
+			output <- Result{Error: err}
+			return
+		}
+		emark.Append(logging.LoggableMap{
+			"blackSetSize": fmt.Sprintf("%d", gcs.Len()),
+		})
+		emark.Done()
+		esweep := log.EventBegin(ctx, "GC.sweep")
+
+		keychan, err := bs.AllKeysChan(ctx)
+		if err != nil {
+			output <- Result{Error: err}
+			return
+		}
+
+		s := &sweeper{
+			ctx:    ctx,
+			bs:     bs,
+			ls:     ls,
+			pn:     pn,
+			wb:     wb,
+			gcs:    gcs,
+			output: output,
+		}
+
+		batch := make([]*cid.Cid, 0, batchSize)
+	loop:
+		for {
+			select {
+			case k, ok := <-keychan:
+				if !ok {
+					break loop
+				}
+				if gcs.Has(k) {
+					continue
+				}
+				batch = append(batch, k)
+				if len(batch) < batchSize {
+					continue
+				}
+				if err := s.sweep(batch); err != nil {
+					output <- Result{Error: err}
+					return
+				}
+				batch = batch[:0]
+			case <-ctx.Done():
+				break loop
+			}
+		}
+
+		if len(batch) > 0 && ctx.Err() == nil {
+			if err := s.sweep(batch); err != nil {
+				output <- Result{Error: err}
+				return
+			}
+		}
+
+		esweep.Append(logging.LoggableMap{
+			"whiteSetSize": fmt.Sprintf("%d", s.removed),
+		})
+		esweep.Done()
+		if s.errors {
+			output <- Result{Error: ErrCannotDeleteSomeBlocks}
+		}
+	}()
+
+	return output
+}
+
+type sweeper struct {
+	ctx    context.Context
+	bs     bstore.GCBlockstore
+	ls     dag.LinkService
+	pn     pin.Pinner
+	wb     bstore.WriteBarrier
+	gcs    *cid.Set
+	output chan<- Result
+
+	removed uint64
+	errors  bool
+}
+
+// sweep deletes the blocks of a batch that are still unmarked, holding the
+// GCLock.
+func (s *sweeper) sweep(batch []*cid.Cid) error {
+	elock := log.EventBegin(s.ctx, "GC.lockWait")
+	unlocker := s.bs.GCLock()
+	elock.Done()
+	defer unlocker.Unlock()
+
+	if err := s.remark(); err != nil {
+		return err
+	}
+
+	for _, k := range batch {
+		if s.gcs.Has(k) {
+			continue
+		}
+
+		err := s.bs.DeleteBlock(k)
+		if err != nil {
+			s.errors = true
+			s.output <- Result{Error: &CannotDeleteBlockError{k, err}}
+			continue
+		}
+		s.removed++
+
+		select {
+		case s.output <- Result{KeyRemoved: k}:
+		case <-s.ctx.Done():
+			return nil
+		}
+	}
+	return nil
+}
+
+// remark adds everything that became reachable since the last mark to the
+// marked set. It must be called with the GCLock held.
+func (s *sweeper) remark() error {
+	getLinks := func(ctx context.Context, c *cid.Cid) ([]*node.Link, error) {
+		links, err := s.ls.GetLinks(ctx, c)
+		if err != nil {
+			return nil, &CannotFetchLinksError{c, err}
+		}
+		return links, nil
+	}
+
+	// written blocks may since have been removed, or be unreadable raw
+	// data; they are kept but not required to be walkable
+	bestEffortGetLinks := func(ctx context.Context, c *cid.Cid) ([]*node.Link, error) {
+		links, err := s.ls.GetLinks(ctx, c)
+		if err != nil && err != dag.ErrNotFound {
+			return nil, &CannotFetchLinksError{c, err}
+		}
+		return links, nil
+	}
+
+	var roots []*cid.Cid
+	for _, k := range s.pn.RecursiveKeys() {
+		if !s.gcs.Has(k) {
+			roots = append(roots, k)
+		}
+	}
+	for _, k := range s.pn.InternalPins() {
+		if !s.gcs.Has(k) {
+			roots = append(roots, k)
+		}
+	}
+	for _, k := range s.pn.DirectKeys() {
+		s.gcs.Add(k)
+	}
+
+	if err := Descendants(s.ctx, getLinks, s.gcs, roots); err != nil {
+		return err
+	}
+
+	var written []*cid.Cid
+	for _, k := range s.wb.Written() {
+		if !s.gcs.Has(k) {
+			written = append(written, k)
+		}
+	}
+	return Descendants(s.ctx, bestEffortGetLinks, s.gcs, written)
+}
@@ -0,0 +1,222 @@
+package gc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	bstore "github.com/ipfs/go-ipfs/blocks/blockstore"
+	bserv "github.com/ipfs/go-ipfs/blockservice"
+	offline "github.com/ipfs/go-ipfs/exchange/offline"
+	dag "github.com/ipfs/go-ipfs/merkledag"
+	pin "github.com/ipfs/go-ipfs/pin"
+
+	node "gx/ipfs/QmNwUEK7QbwSqyKBu3mMtToo8SUc6wQJ7gdZq4gGGJqfnf/go-ipld-format"
+	ds "gx/ipfs/QmdHG8MAuARdGHxx4rPQASLcvhz24fzjSQq7AJRAQEorq5/go-datastore"
+	dssync "gx/ipfs/QmdHG8MAuARdGHxx4rPQASLcvhz24fzjSQq7AJRAQEorq5/go-datastore/sync"
+	cid "gx/ipfs/QmeSrf6pzut73u6zLQkRFQ3ygt3k6XFT2kjdYP8Tnkwwyg/go-cid"
+)
+
+// hookedLinks runs hook before the first GetLinks call, which happens while
+// the collector marks.
+type hookedLinks struct {
+	dag.LinkService
+	once sync.Once
+	hook func()
+}
+
+func (l *hookedLinks) GetLinks(ctx context.Context, c *cid.Cid) ([]*node.Link, error) {
+	l.once.Do(l.hook)
+	return l.LinkService.GetLinks(ctx, c)
+}
+
+func (l *hookedLinks) GetOfflineLinkService() dag.LinkService {
+	return l
+}
+
+// hookedBlockstore counts the sweep batches, and runs beforeBatch before the
+// GCLock is taken for each of them.
+type hookedBlockstore struct {
+	bstore.ConcurrentGCBlockstore
+	batches     int
+	beforeBatch func(n int)
+}
+
+func (bs *hookedBlockstore) GCLock() bstore.Unlocker {
+	bs.batches++
+	if bs.beforeBatch != nil {
+		bs.beforeBatch(bs.batches)
+	}
+	return bs.ConcurrentGCBlockstore.GCLock()
+}
+
+type gcTest struct {
+	bs    *hookedBlockstore
+	dserv dag.DAGService
+	pn    pin.Pinner
+}
+
+func newGCTest(t *testing.T) *gcTest {
+	bs := bstore.NewGCBlockstore(bstore.NewBlockstore(dssync.MutexWrap(ds.NewMapDatastore())), bstore.NewGCLocker())
+	dserv := dag.NewDAGService(bserv.New(bs, offline.Exchange(bs)))
+	return &gcTest{
+		bs:    &hookedBlockstore{ConcurrentGCBlockstore: bs.(bstore.ConcurrentGCBlockstore)},
+		dserv: dserv,
+		pn:    pin.NewPinner(dssync.MutexWrap(ds.NewMapDatastore()), dserv, dserv),
+	}
+}
+
+func (gt *gcTest) add(t *testing.T, data string, children ...node.Node) *dag.ProtoNode {
+	nd := dag.NodeWithData([]byte(data))
+	for _, c := range children {
+		if err := nd.AddNodeLink("", c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := gt.dserv.Add(nd); err != nil {
+		t.Fatal(err)
+	}
+	return nd
+}
+
+// run collects garbage and returns the number of blocks removed.
+func (gt *gcTest) run(t *testing.T, ls dag.LinkService, roots []*cid.Cid, batchSize int) int {
+	removed := 0
+	for res := range ConcurrentGC(context.Background(), gt.bs, ls, gt.pn, roots, batchSize) {
+		if res.Error != nil {
+			t.Fatal(res.Error)
+		}
+		removed++
+	}
+	return removed
+}
+
+func (gt *gcTest) assertHas(t *testing.T, expected bool, nds ...node.Node) {
+	t.Helper()
+	for _, nd := range nds {
+		has, err := gt.bs.Has(nd.Cid())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if has != expected {
+			t.Fatalf("expected block %s to be present: %t, got %t", nd.Cid(), expected, has)
+		}
+	}
+}
+
+func TestConcurrentGCWriteBarrier(t *testing.T) {
+	ctx := context.Background()
+	gt := newGCTest(t)
+
+	child := gt.add(t, "child")
+	pinned := gt.add(t, "pinned", child)
+	if err := gt.pn.Pin(ctx, pinned, true); err != nil {
+		t.Fatal(err)
+	}
+	garbage := gt.add(t, "garbage")
+
+	written := dag.NodeWithData([]byte("written"))
+	ls := &hookedLinks{LinkService: gt.dserv, hook: func() {
+		if _, err := gt.dserv.Add(written); err != nil {
+			t.Error(err)
+		}
+	}}
+
+	if removed := gt.run(t, ls, nil, 0); removed != 1 {
+		t.Fatalf("expected 1 block to be removed, got %d", removed)
+	}
+	gt.assertHas(t, true, pinned, child, written)
+	gt.assertHas(t, false, garbage)
+}
+
+func TestConcurrentGCPinBetweenBatches(t *testing.T) {
+	ctx := context.Background()
+	gt := newGCTest(t)
+
+	var nds []node.Node
+	for i := 0; i < 10; i++ {
+		nds = append(nds, gt.add(t, fmt.Sprintf("garbage %d", i)))
+	}
+
+	// pin one of the blocks left after the first batch
+	var kept node.Node
+	gt.bs.beforeBatch = func(n int) {
+		if n != 2 {
+			return
+		}
+		for _, nd := range nds {
+			has, err := gt.bs.Has(nd.Cid())
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if has {
+				kept = nd
+				break
+			}
+		}
+		if err := gt.pn.Pin(ctx, kept, true); err != nil {
+			t.Error(err)
+		}
+	}
+
+	if removed := gt.run(t, gt.dserv, nil, 2); removed != len(nds)-1 {
+		t.Fatalf("expected %d blocks to be removed, got %d", len(nds)-1, removed)
+	}
+	for _, nd := range nds {
+		gt.assertHas(t, nd == kept, nd)
+	}
+}
+
+func TestConcurrentGCNewBestEffortRoot(t *testing.T) {
+	gt := newGCTest(t)
+
+	entry := gt.add(t, "entry")
+	oldRoot := gt.add(t, "root", entry)
+	unreferenced := gt.add(t, "unreferenced")
+	garbage := gt.add(t, "garbage")
+
+	// a new files root, referencing content that was not reachable when
+	// marking started
+	newRoot := oldRoot.Copy().(*dag.ProtoNode)
+	if err := newRoot.AddNodeLink("added", unreferenced); err != nil {
+		t.Fatal(err)
+	}
+	ls := &hookedLinks{LinkService: gt.dserv, hook: func() {
+		if _, err := gt.dserv.Add(newRoot); err != nil {
+			t.Error(err)
+		}
+	}}
+
+	gt.run(t, ls, []*cid.Cid{oldRoot.Cid()}, 0)
+	gt.assertHas(t, true, oldRoot, entry, newRoot, unreferenced)
+	gt.assertHas(t, false, garbage)
+}
+
+func TestConcurrentGCBatches(t *testing.T) {
+	ctx := context.Background()
+	gt := newGCTest(t)
+
+	pinned := gt.add(t, "pinned")
+	if err := gt.pn.Pin(ctx, pinned, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := gt.pn.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	var garbage []node.Node
+	for i := 0; i < 10; i++ {
+		garbage = append(garbage, gt.add(t, fmt.Sprintf("garbage %d", i)))
+	}
+
+	if removed := gt.run(t, gt.dserv, nil, 3); removed != len(garbage) {
+		t.Fatalf("expected %d blocks to be removed, got %d", len(garbage), removed)
+	}
+	if gt.bs.batches != 4 {
+		t.Fatalf("expected 4 sweep batches, got %d", gt.bs.batches)
+	}
+	gt.assertHas(t, true, pinned)
+	gt.assertHas(t, false, garbage...)
+}
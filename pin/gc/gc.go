@@ -18,8 +18,10 @@ var log = logging.Logger("gc")
 
 // Result represents an incremental output from a garbage collection
 // run.  It contains either an error, or the cid of a removed object.
+// Size is only set by DryRun.
 type Result struct {
 	KeyRemoved *cid.Cid
+	Size       uint64
 	Error      error
 }
 
@@ -109,6 +111,65 @@ func GC(ctx context.Context, bs bstore.GCBlockstore, ls dag.LinkService, pn pin.
 	return output
 }
 
+// DryRun computes the same marked set as GC, but instead of deleting the
+// unmarked blocks it reports each of them, with its size, as a Result. It
+// does not take the GCLock, so the report may go stale as soon as adds and
+// pins happen.
+func DryRun(ctx context.Context, bs bstore.GCBlockstore, ls dag.LinkService, pn pin.Pinner, bestEffortRoots []*cid.Cid) <-chan Result {
+	ls = ls.GetOfflineLinkService()
+
+	output := make(chan Result, 128)
+
+	go func() {
+		defer close(output)
+
+		gcs, err := ColoredSet(ctx, pn, ls, bestEffortRoots, output)
+		if err != nil {
+			output <- Result{Error: err}
+			return
+		}
+
+		keychan, err := bs.AllKeysChan(ctx)
+		if err != nil {
+			output <- Result{Error: err}
+			return
+		}
+
+	loop:
+		for {
+			select {
+			case k, ok := <-keychan:
+				if !ok {
+					break loop
+				}
+				if gcs.Has(k) {
+					continue
+				}
+
+				b, err := bs.Get(k)
+				if err == bstore.ErrNotFound {
+					// removed since it was listed
+					continue
+				}
+				if err != nil {
+					output <- Result{Error: err}
+					continue
+				}
+
+				select {
+				case output <- Result{KeyRemoved: k, Size: uint64(len(b.RawData()))}:
+				case <-ctx.Done():
+					break loop
+				}
+			case <-ctx.Done():
+				break loop
+			}
+		}
+	}()
+
+	return output
+}
+
 func Descendants(ctx context.Context, getLinks dag.GetLinks, set *cid.Set, roots []*cid.Cid) error {
 	for _, c := range roots {
 		set.Add(c)
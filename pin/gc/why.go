@@ -0,0 +1,124 @@
+package gc
+
+import (
+	"context"
+
+	dag "github.com/ipfs/go-ipfs/merkledag"
+	pin "github.com/ipfs/go-ipfs/pin"
+
+	node "gx/ipfs/QmNwUEK7QbwSqyKBu3mMtToo8SUc6wQJ7gdZq4gGGJqfnf/go-ipld-format"
+	cid "gx/ipfs/QmeSrf6pzut73u6zLQkRFQ3ygt3k6XFT2kjdYP8Tnkwwyg/go-cid"
+)
+
+const (
+	ReasonRecursive  = "recursive"
+	ReasonDirect     = "direct"
+	ReasonInternal   = "internal"
+	ReasonBestEffort = "best-effort"
+)
+
+// Reason is one of the roots that keeps a block from being garbage
+// collected.
+type Reason struct {
+	// Type is one of ReasonRecursive, ReasonDirect, ReasonInternal or
+	// ReasonBestEffort
+	Type string
+	Root *cid.Cid
+
+	// Path holds the links followed from Root down to the block. Each
+	// element is the name of the link, or the cid it points to if the link
+	// has no name. It is empty if the block is the root itself.
+	Path []string
+}
+
+// Why returns every root GC would keep the given block for: the recursive
+// pins and bestEffortRoots that reach it, each with the path of links
+// leading to the block, and the direct or internal pin on the block itself.
+func Why(ctx context.Context, pn pin.Pinner, ls dag.LinkService, bestEffortRoots []*cid.Cid, c *cid.Cid) ([]Reason, error) {
+	ls = ls.GetOfflineLinkService()
+
+	var reasons []Reason
+	for _, k := range pn.DirectKeys() {
+		if k.Equals(c) {
+			reasons = append(reasons, Reason{Type: ReasonDirect, Root: k})
+		}
+	}
+
+	// every object of the pinning structure is an internal pin, and below
+	// them there are only the pinned roots, which are reported on their own
+	for _, k := range pn.InternalPins() {
+		if k.Equals(c) {
+			reasons = append(reasons, Reason{Type: ReasonInternal, Root: k})
+		}
+	}
+
+	roots := []struct {
+		typ        string
+		keys       []*cid.Cid
+		bestEffort bool
+	}{
+		{ReasonRecursive, pn.RecursiveKeys(), false},
+		{ReasonBestEffort, bestEffortRoots, true},
+	}
+
+	for _, r := range roots {
+		for _, k := range r.keys {
+			p, found, err := linkPath(ctx, ls, k, c, r.bestEffort)
+			if err != nil {
+				return nil, err
+			}
+			if found {
+				reasons = append(reasons, Reason{Type: r.typ, Root: k, Path: p})
+			}
+		}
+	}
+
+	return reasons, nil
+}
+
+type parentLink struct {
+	parent *cid.Cid
+	name   string
+}
+
+// linkPath walks the dag below root with Descendants, remembering through
+// which link each node was first reached, and returns the path to target.
+func linkPath(ctx context.Context, ls dag.LinkService, root, target *cid.Cid, bestEffort bool) ([]string, bool, error) {
+	parents := make(map[string]parentLink)
+	getLinks := func(ctx context.Context, c *cid.Cid) ([]*node.Link, error) {
+		links, err := ls.GetLinks(ctx, c)
+		if err == dag.ErrNotFound && bestEffort {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, &CannotFetchLinksError{c, err}
+		}
+
+		for _, l := range links {
+			if _, ok := parents[l.Cid.KeyString()]; !ok {
+				parents[l.Cid.KeyString()] = parentLink{parent: c, name: l.Name}
+			}
+		}
+		return links, nil
+	}
+
+	set := cid.NewSet()
+	if err := Descendants(ctx, getLinks, set, []*cid.Cid{root}); err != nil {
+		return nil, false, err
+	}
+	if !set.Has(target) {
+		return nil, false, nil
+	}
+
+	var path []string
+	for cur := target; !cur.Equals(root); {
+		pl := parents[cur.KeyString()]
+		name := pl.name
+		if name == "" {
+			name = cur.String()
+		}
+		path = append([]string{name}, path...)
+		cur = pl.parent
+	}
+	return path, true, nil
+}
@@ -0,0 +1,83 @@
+package gc
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	dag "github.com/ipfs/go-ipfs/merkledag"
+	dstest "github.com/ipfs/go-ipfs/merkledag/test"
+	pin "github.com/ipfs/go-ipfs/pin"
+
+	ds "gx/ipfs/QmdHG8MAuARdGHxx4rPQASLcvhz24fzjSQq7AJRAQEorq5/go-datastore"
+	dssync "gx/ipfs/QmdHG8MAuARdGHxx4rPQASLcvhz24fzjSQq7AJRAQEorq5/go-datastore/sync"
+	cid "gx/ipfs/QmeSrf6pzut73u6zLQkRFQ3ygt3k6XFT2kjdYP8Tnkwwyg/go-cid"
+)
+
+func TestWhy(t *testing.T) {
+	ctx := context.Background()
+	dserv := dstest.Mock()
+	pn := pin.NewPinner(dssync.MutexWrap(ds.NewMapDatastore()), dserv, dserv)
+
+	leaf := dag.NewRawNode([]byte("leaf"))
+	mid := dag.NodeWithData([]byte("mid"))
+	if err := mid.AddNodeLink("leaf", leaf); err != nil {
+		t.Fatal(err)
+	}
+	root := dag.NodeWithData([]byte("root"))
+	if err := root.AddNodeLink("mid", mid); err != nil {
+		t.Fatal(err)
+	}
+	mfs := dag.NodeWithData([]byte("mfs"))
+	if err := mfs.AddNodeLink("", leaf); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, n := range []*dag.ProtoNode{mid, root, mfs} {
+		if _, err := dserv.Add(n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := dserv.Add(leaf); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := pn.Pin(ctx, root, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := pn.Pin(ctx, leaf, false); err != nil {
+		t.Fatal(err)
+	}
+
+	reasons, err := Why(ctx, pn, dserv, []*cid.Cid{mfs.Cid()}, leaf.Cid())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := make(map[string]string)
+	for _, r := range reasons {
+		found[r.Type] = r.Root.String() + "/" + strings.Join(r.Path, "/")
+	}
+
+	expected := map[string]string{
+		ReasonDirect:     leaf.Cid().String() + "/",
+		ReasonRecursive:  root.Cid().String() + "/mid/leaf",
+		ReasonBestEffort: mfs.Cid().String() + "/" + leaf.Cid().String(),
+	}
+	if len(found) != len(expected) {
+		t.Fatalf("unexpected reasons: %v", found)
+	}
+	for typ, p := range expected {
+		if found[typ] != p {
+			t.Errorf("expected %s reason %s, got %s", typ, p, found[typ])
+		}
+	}
+
+	reasons, err = Why(ctx, pn, dserv, nil, dag.NodeWithData([]byte("nope")).Cid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reasons) != 0 {
+		t.Fatalf("expected no reasons, got %v", reasons)
+	}
+}
@@ -0,0 +1,137 @@
+package pin
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ipfs/go-ipfs/merkledag"
+
+	mh "gx/ipfs/QmYeKnKpubCMRiq3PGZcTREErthbb5Q9cXsCoSkD9bjEBd/go-multihash"
+	cid "gx/ipfs/QmeSrf6pzut73u6zLQkRFQ3ygt3k6XFT2kjdYP8Tnkwwyg/go-cid"
+	cbor "gx/ipfs/QmeZv9VXw2SfVbX55LV6kGTWASKBc9ZxAVqGBeJcDGdoXy/go-ipld-cbor"
+)
+
+// linkMetadata is the name of the link from the pinning root to the
+// metadata object. Pin roots written before pins could carry metadata do
+// not have it, and are upgraded in place on the next Flush. Older versions
+// ignore the link, so the repo version does not change.
+const linkMetadata = "metadata"
+
+func init() {
+	cbor.RegisterCborType(Metadata{})
+}
+
+// Metadata is optional user supplied information attached to a direct or
+// recursive pin.
+type Metadata struct {
+	Name   string            `refmt:"name"`
+	Labels map[string]string `refmt:"labels"`
+
+	// Expires is the unix time in seconds after which the pin is removed,
+	// zero if the pin never expires.
+	Expires int64 `refmt:"expires"`
+}
+
+// Empty returns true if the metadata carries no information.
+func (m *Metadata) Empty() bool {
+	return m == nil || (m.Name == "" && len(m.Labels) == 0 && m.Expires == 0)
+}
+
+// ExpiresAt returns the time the pin expires, or the zero time if it never
+// does.
+func (m *Metadata) ExpiresAt() time.Time {
+	if m == nil || m.Expires == 0 {
+		return time.Time{}
+	}
+	return time.Unix(m.Expires, 0)
+}
+
+// Expired returns true if the pin has an expiry at or before now.
+func (m *Metadata) Expired(now time.Time) bool {
+	return m != nil && m.Expires != 0 && m.Expires <= now.Unix()
+}
+
+// Matches returns true if the metadata has the given name (unless name is
+// empty) and carries every one of the given labels.
+func (m *Metadata) Matches(name string, labels map[string]string) bool {
+	if m == nil {
+		return name == "" && len(labels) == 0
+	}
+	if name != "" && m.Name != name {
+		return false
+	}
+	for k, v := range labels {
+		if mv, ok := m.Labels[k]; !ok || mv != v {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *Metadata) copy() *Metadata {
+	out := &Metadata{Name: m.Name, Expires: m.Expires}
+	if len(m.Labels) > 0 {
+		out.Labels = make(map[string]string, len(m.Labels))
+		for k, v := range m.Labels {
+			out.Labels[k] = v
+		}
+	}
+	return out
+}
+
+// storeMetadata writes the metadata of all pins as a single cbor object,
+// keyed by the string form of the pinned cid.
+func storeMetadata(dag merkledag.DAGService, meta map[string]*Metadata, internalKeys keyObserver) (*cbor.Node, error) {
+	obj := make(map[string]Metadata, len(meta))
+	for k, m := range meta {
+		obj[k] = *m
+	}
+
+	n, err := cbor.WrapObject(obj, mh.SHA2_256, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := dag.Add(n)
+	if err != nil {
+		return nil, err
+	}
+	internalKeys(c)
+	return n, nil
+}
+
+func loadMetadata(ctx context.Context, dag merkledag.DAGService, root *merkledag.ProtoNode, internalKeys keyObserver) (map[string]*Metadata, error) {
+	meta := make(map[string]*Metadata)
+
+	l, err := root.GetNodeLink(linkMetadata)
+	switch err {
+	case nil:
+	case merkledag.ErrLinkNotFound:
+		// pin root predates pin metadata
+		return meta, nil
+	default:
+		return nil, err
+	}
+
+	internalKeys(l.Cid)
+
+	n, err := l.GetNode(ctx, dag)
+	if err != nil {
+		return nil, err
+	}
+
+	var obj map[string]Metadata
+	if err := cbor.DecodeInto(n.RawData(), &obj); err != nil {
+		return nil, err
+	}
+
+	for k, m := range obj {
+		if _, err := cid.Decode(k); err != nil {
+			return nil, fmt.Errorf("invalid key in pin metadata: %s", err)
+		}
+		m := m
+		meta[k] = &m
+	}
+	return meta, nil
+}
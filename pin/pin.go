@@ -106,6 +106,17 @@ type Pinner interface {
 	// be successful.
 	RemovePinWithMode(*cid.Cid, PinMode)
 
+	// SetMetadata attaches metadata to a direct or recursive pin,
+	// replacing whatever it carried before. Passing nil removes it.
+	SetMetadata(*cid.Cid, *Metadata) error
+
+	// Metadata returns the metadata attached to a pin, if any.
+	Metadata(*cid.Cid) (*Metadata, bool)
+
+	// ExpiredPins returns the direct and recursive pins whose expiry is at
+	// or before the given time.
+	ExpiredPins(time.Time) []*cid.Cid
+
 	Flush() error
 	DirectKeys() []*cid.Cid
 	RecursiveKeys() []*cid.Cid
@@ -147,9 +158,13 @@ type pinner struct {
 	// Track the keys used for storing the pinning state, so gc does
 	// not delete them.
 	internalPin *cid.Set
-	dserv       mdag.DAGService
-	internal    mdag.DAGService // dagservice used to store internal objects
-	dstore      ds.Datastore
+
+	// metadata of direct and recursive pins, keyed by cid string
+	meta map[string]*Metadata
+
+	dserv    mdag.DAGService
+	internal mdag.DAGService // dagservice used to store internal objects
+	dstore   ds.Datastore
 }
 
 // NewPinner creates a new pinner using the given datastore as a backend
@@ -165,6 +180,7 @@ func NewPinner(dstore ds.Datastore, serv, internal mdag.DAGService) Pinner {
 		dstore:      dstore,
 		internal:    internal,
 		internalPin: cid.NewSet(),
+		meta:        make(map[string]*Metadata),
 	}
 }
 
@@ -224,12 +240,14 @@ func (p *pinner) Unpin(ctx context.Context, c *cid.Cid, recursive bool) error {
 	case "recursive":
 		if recursive {
 			p.recursePin.Remove(c)
+			delete(p.meta, c.String())
 			return nil
 		} else {
 			return fmt.Errorf("%s is pinned recursively", c)
 		}
 	case "direct":
 		p.directPin.Remove(c)
+		delete(p.meta, c.String())
 		return nil
 	default:
 		return fmt.Errorf("%s is pinned indirectly under %s", c, reason)
@@ -376,6 +394,57 @@ func (p *pinner) RemovePinWithMode(c *cid.Cid, mode PinMode) {
 		// programmer error, panic OK
 		panic("unrecognized pin type")
 	}
+	if !p.recursePin.Has(c) && !p.directPin.Has(c) {
+		delete(p.meta, c.String())
+	}
+}
+
+func (p *pinner) SetMetadata(c *cid.Cid, m *Metadata) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	// metadata left without a pin can always be removed
+	if m.Empty() {
+		delete(p.meta, c.String())
+		return nil
+	}
+
+	if !p.recursePin.Has(c) && !p.directPin.Has(c) {
+		return ErrNotPinned
+	}
+	p.meta[c.String()] = m.copy()
+	return nil
+}
+
+func (p *pinner) ExpiredPins(now time.Time) []*cid.Cid {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	var out []*cid.Cid
+	for k, m := range p.meta {
+		if !m.Expired(now) {
+			continue
+		}
+		c, err := cid.Decode(k)
+		if err != nil {
+			// keys are validated when loaded
+			log.Error(err)
+			continue
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+func (p *pinner) Metadata(c *cid.Cid) (*Metadata, bool) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	m, ok := p.meta[c.String()]
+	if !ok {
+		return nil, false
+	}
+	return m.copy(), true
 }
 
 func cidSetWithValues(cids []*cid.Cid) *cid.Set {
@@ -437,6 +506,14 @@ func LoadPinner(d ds.Datastore, dserv, internal mdag.DAGService) (Pinner, error)
 		p.directPin = cidSetWithValues(directKeys)
 	}
 
+	{ // load metadata
+		meta, err := loadMetadata(ctx, internal, rootpb, recordInternal)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load pin metadata: %v", err)
+		}
+		p.meta = meta
+	}
+
 	p.internalPin = internalset
 
 	// assign services
@@ -471,8 +548,12 @@ func (p *pinner) Update(ctx context.Context, from, to *cid.Cid, unpin bool) erro
 	}
 
 	p.recursePin.Add(to)
+	if m, ok := p.meta[from.String()]; ok {
+		p.meta[to.String()] = m.copy()
+	}
 	if unpin {
 		p.recursePin.Remove(from)
+		delete(p.meta, from.String())
 	}
 	return nil
 }
@@ -508,6 +589,16 @@ func (p *pinner) Flush() error {
 		}
 	}
 
+	{
+		n, err := storeMetadata(p.internal, p.meta, recordInternal)
+		if err != nil {
+			return err
+		}
+		if err := root.AddNodeLink(linkMetadata, n); err != nil {
+			return err
+		}
+	}
+
 	// add the empty node, its referenced by the pin sets but never created
 	_, err := p.internal.Add(new(mdag.ProtoNode))
 	if err != nil {
@@ -400,3 +400,138 @@ func TestPinUpdate(t *testing.T) {
 	assertPinned(t, p, c2, "c2 should be pinned still")
 	assertPinned(t, p, c1, "c1 should be pinned now")
 }
+
+func TestPinMetadata(t *testing.T) {
+	dstore := dssync.MutexWrap(ds.NewMapDatastore())
+	bstore := blockstore.NewBlockstore(dstore)
+	bserv := bs.New(bstore, offline.Exchange(bstore))
+
+	dserv := mdag.NewDAGService(bserv)
+	p := NewPinner(dstore, dserv, dserv)
+	n1, c1 := randNode()
+	n2, c2 := randNode()
+	_, c3 := randNode()
+
+	dserv.Add(n1)
+	dserv.Add(n2)
+
+	ctx := context.Background()
+	if err := p.Pin(ctx, n1, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Pin(ctx, n2, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.SetMetadata(c3, &Metadata{Name: "nope"}); err != ErrNotPinned {
+		t.Fatalf("expected ErrNotPinned, got %v", err)
+	}
+
+	m1 := &Metadata{Name: "site", Labels: map[string]string{"env": "prod"}}
+	if err := p.SetMetadata(c1, m1); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.SetMetadata(c2, &Metadata{Name: "single"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	np, err := LoadPinner(dstore, dserv, dserv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, ok := np.Metadata(c1)
+	if !ok {
+		t.Fatal("expected metadata to survive a reload")
+	}
+	if m.Name != "site" || m.Labels["env"] != "prod" {
+		t.Fatalf("unexpected metadata: %#v", m)
+	}
+	if !m.Matches("", map[string]string{"env": "prod"}) || m.Matches("site", map[string]string{"env": "dev"}) {
+		t.Fatal("label matching is broken")
+	}
+
+	if err := np.Unpin(ctx, c2, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := np.Metadata(c2); ok {
+		t.Fatal("expected metadata to be removed with the pin")
+	}
+}
+
+func TestPinMetadataUpdate(t *testing.T) {
+	dstore := dssync.MutexWrap(ds.NewMapDatastore())
+	bstore := blockstore.NewBlockstore(dstore)
+	bserv := bs.New(bstore, offline.Exchange(bstore))
+
+	dserv := mdag.NewDAGService(bserv)
+	p := NewPinner(dstore, dserv, dserv)
+	n1, c1 := randNode()
+	n2, c2 := randNode()
+
+	dserv.Add(n1)
+	dserv.Add(n2)
+
+	ctx := context.Background()
+	if err := p.Pin(ctx, n1, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.SetMetadata(c1, &Metadata{Name: "site"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.Update(ctx, c1, c2, true); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := p.Metadata(c1); ok {
+		t.Fatal("expected metadata of the old pin to be gone")
+	}
+	if m, ok := p.Metadata(c2); !ok || m.Name != "site" {
+		t.Fatal("expected metadata to move to the new pin")
+	}
+}
+
+func TestExpiredPins(t *testing.T) {
+	dstore := dssync.MutexWrap(ds.NewMapDatastore())
+	bstore := blockstore.NewBlockstore(dstore)
+	bserv := bs.New(bstore, offline.Exchange(bstore))
+
+	dserv := mdag.NewDAGService(bserv)
+	p := NewPinner(dstore, dserv, dserv)
+	n1, c1 := randNode()
+	n2, c2 := randNode()
+
+	dserv.Add(n1)
+	dserv.Add(n2)
+
+	ctx := context.Background()
+	if err := p.Pin(ctx, n1, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Pin(ctx, n2, false); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	if err := p.SetMetadata(c1, &Metadata{Expires: now.Add(-time.Minute).Unix()}); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.SetMetadata(c2, &Metadata{Expires: now.Add(time.Hour).Unix()}); err != nil {
+		t.Fatal(err)
+	}
+
+	expired := p.ExpiredPins(now)
+	if len(expired) != 1 || !expired[0].Equals(c1) {
+		t.Fatalf("expected only %s to be expired, got %v", c1, expired)
+	}
+
+	expired = p.ExpiredPins(now.Add(2 * time.Hour))
+	if len(expired) != 2 {
+		t.Fatalf("expected both pins to be expired, got %v", expired)
+	}
+}
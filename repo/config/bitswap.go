@@ -0,0 +1,12 @@
+package config
+
+type Bitswap struct {
+	Strategy  string   // How blocks wanted by other peers are served
+	Allowlist []string // Peer IDs served first by the "allowlist" strategy
+
+	SendRate           string `json:",omitempty"` // Bytes per second sent to all peers, e.g. "10MB"
+	PeerSendRate       string `json:",omitempty"` // Bytes per second sent to a single peer
+	PeerMaxOutstanding int    `json:",omitempty"` // Blocks a single peer may have in flight
+
+	DisableRequestSplitting bool `json:",omitempty"` // Request every block from all session peers
+}
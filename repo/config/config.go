@@ -26,6 +26,8 @@ type Config struct {
 	Swarm     SwarmConfig
 
 	Reprovider   Reprovider
+	Bitswap      Bitswap
+	Pinning      Pinning
 	Experimental Experiments
 }
 
@@ -12,6 +12,8 @@ type Datastore struct {
 	StorageMax         string // in B, kB, kiB, MB, ...
 	StorageGCWatermark int64  // in percentage to multiply on StorageMax
 	GCPeriod           string // in ns, us, ms, s, m, h
+	ConcurrentGC       bool   // mark without blocking adds, sweep in batches
+	GCConfirmDelay     string `json:",omitempty"` // report and wait before periodic GC deletes
 
 	// deprecated fields, use Spec
 	Type   string           `json:",omitempty"`
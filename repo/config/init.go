@@ -62,6 +62,13 @@ func Init(out io.Writer, nBitsForKeypair int) (*Config, error) {
 			Interval: "12h",
 			Strategy: "all",
 		},
+		Bitswap: Bitswap{
+			Strategy:  "fair-share",
+			Allowlist: []string{},
+		},
+		Pinning: Pinning{
+			ExpiryInterval: "10m",
+		},
 		Swarm: SwarmConfig{
 			ConnMgr: ConnMgr{
 				LowWater:    DefaultConnMgrLowWater,
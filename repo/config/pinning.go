@@ -0,0 +1,6 @@
+package config
+
+type Pinning struct {
+	ExpiryInterval string // Time period between checks for expired pins
+	GCOnExpiry     bool   // Run a garbage collection after expired pins are removed
+}
@@ -3,4 +3,6 @@ package config
 type Reprovider struct {
 	Interval string // Time period to reprovide locally stored objects to the network
 	Strategy string // Which keys to announce
+	Workers  int    // Number of keys announced in parallel
+	Rate     int    // Maximum number of keys announced per second, zero for no limit
 }
@@ -206,6 +206,32 @@ test_dag_cmd() {
     test_cmp resolve_obj_exp resolve_obj &&
     test_cmp resolve_data_exp resolve_data
   '
+
+  test_expect_success "dag export works" '
+    ipfs dag export $HASH > export.car
+  '
+
+  test_expect_success "dag export of unknown path fails" '
+    test_must_fail ipfs dag export /ipfs/not-a-hash > /dev/null
+  '
+
+  test_expect_success "dag import works" '
+    ipfs dag import export.car > import_out
+  '
+
+  test_expect_success "dag import output looks good" '
+    echo "root $HASH" > import_exp &&
+    test_cmp import_exp import_out
+  '
+
+  test_expect_success "dag import pinned the root" '
+    ipfs pin ls --type=recursive $HASH
+  '
+
+  test_expect_success "dag import of a corrupt archive fails" '
+    head -c 20 export.car > bad.car &&
+    test_must_fail ipfs dag import bad.car
+  '
 }
 
 # should work offline
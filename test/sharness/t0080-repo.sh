@@ -48,6 +48,26 @@ test_expect_success "'ipfs pin rm' output looks good" '
   test_cmp expected1 actual1
 '
 
+test_expect_success "'ipfs repo gc --dry-run' succeeds" '
+  ipfs repo gc --dry-run >gc_dry_out
+'
+
+test_expect_success "'ipfs repo gc --dry-run' reports the unpinned file" '
+  grep "would remove $HASH (" gc_dry_out &&
+  grep "^would reclaim [0-9]* bytes in [0-9]* blocks$" gc_dry_out
+'
+
+test_expect_success "'ipfs repo gc --dry-run' does not remove anything" '
+  ipfs cat "$HASH" >out &&
+  test_cmp out afile
+'
+
+test_expect_success "'ipfs repo gc --dry-run -q' lists just hashes" '
+  ipfs repo gc --dry-run -q >gc_dry_quiet &&
+  grep "^$HASH$" gc_dry_quiet &&
+  test_must_fail grep reclaim gc_dry_quiet
+'
+
 test_expect_failure "ipfs repo gc fully reverse ipfs add" '
   ipfs repo gc &&
   random 100000 41 >gcfile &&
@@ -97,6 +97,92 @@ test_pin_progress() {
   '
 }
 
+test_pin_metadata() {
+  test_expect_success "'ipfs pin add --name --label' succeeds" '
+    NAMED=$(echo "named pin" | ipfs add -q --pin=false) &&
+    OTHER=$(echo "other pin" | ipfs add -q --pin=false) &&
+    ipfs pin add --name=site --label=env=prod,team=web $NAMED &&
+    ipfs pin add --label=env=dev $OTHER
+  '
+
+  test_expect_success "'ipfs pin ls --label' filters pins" '
+    ipfs pin ls --label=env=prod > ls_label &&
+    echo "$NAMED recursive site" > ls_label_exp &&
+    test_cmp ls_label_exp ls_label
+  '
+
+  test_expect_success "'ipfs pin ls --name' filters pins" '
+    ipfs pin ls -q --name=site > ls_name &&
+    echo "$NAMED" > ls_name_exp &&
+    test_cmp ls_name_exp ls_name
+  '
+
+  test_expect_success "'ipfs pin ls --label' filters the pins of paths" '
+    ipfs pin ls -q --label=env=dev $NAMED $OTHER > ls_paths &&
+    echo "$OTHER" > ls_paths_exp &&
+    test_cmp ls_paths_exp ls_paths
+  '
+
+  test_expect_success "'ipfs pin add' rejects malformed labels" '
+    test_must_fail ipfs pin add --label=nope $NAMED
+  '
+
+  test_expect_success "'ipfs pin rm' drops the metadata" '
+    ipfs pin rm $NAMED $OTHER &&
+    ipfs pin ls --name=site > ls_name_rm &&
+    test_must_be_empty ls_name_rm
+  '
+}
+
+test_pin_why() {
+  test_expect_success "'ipfs pin why' setup" '
+    mkdir -p why_dir &&
+    echo "why file" > why_dir/file &&
+    WHY_DIR=$(ipfs add -r -q why_dir | tail -n1) &&
+    WHY_FILE=$(ipfs add -q --only-hash why_dir/file)
+  '
+
+  test_expect_success "'ipfs pin why' explains an indirect pin" '
+    ipfs pin why $WHY_FILE > why_out &&
+    echo "recursive $WHY_DIR/file" > why_exp &&
+    test_cmp why_exp why_out
+  '
+
+  test_expect_success "'ipfs pin why' explains a root" '
+    ipfs pin why $WHY_DIR > why_root &&
+    echo "recursive $WHY_DIR" > why_root_exp &&
+    test_cmp why_root_exp why_root
+  '
+
+  test_expect_success "'ipfs pin why' reports unpinned objects" '
+    ipfs pin rm $WHY_DIR &&
+    ipfs pin why $WHY_FILE > why_none &&
+    echo "$WHY_FILE is not kept by any pin" > why_none_exp &&
+    test_cmp why_none_exp why_none
+  '
+}
+
+test_pin_ttl() {
+  test_expect_success "'ipfs pin add --ttl' succeeds" '
+    EXPIRING=$(echo "expiring pin" | ipfs add -q --pin=false) &&
+    ipfs pin add --ttl=72h $EXPIRING
+  '
+
+  test_expect_success "'ipfs pin ls' shows the expiry" '
+    ipfs pin ls $EXPIRING > ls_ttl &&
+    grep "^$EXPIRING recursive (expires " ls_ttl
+  '
+
+  test_expect_success "'ipfs pin add' rejects a bad ttl" '
+    test_must_fail ipfs pin add --ttl=soon $EXPIRING &&
+    test_must_fail ipfs pin add --ttl=-1h $EXPIRING
+  '
+
+  test_expect_success "cleanup expiring pin" '
+    ipfs pin rm $EXPIRING
+  '
+}
+
 test_init_ipfs
 
 test_pins
@@ -110,6 +196,12 @@ test_pin_dag --raw-leaves
 
 test_pin_progress
 
+test_pin_metadata
+
+test_pin_ttl
+
+test_pin_why
+
 test_launch_ipfs_daemon --offline
 
 test_pins
@@ -123,6 +215,12 @@ test_pin_dag --raw-leaves
 
 test_pin_progress
 
+test_pin_metadata
+
+test_pin_ttl
+
+test_pin_why
+
 test_kill_ipfs_daemon
 
 test_done
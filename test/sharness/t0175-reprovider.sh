@@ -125,6 +125,39 @@ test_expect_success 'stop peer 1' '
   iptb stop 1
 '
 
+# Test 'mfs' strategy combined with 'roots'
+init_strategy 'roots+mfs'
+
+test_expect_success 'prepare test files' '
+  echo foo > f1 &&
+  echo bar > f2 &&
+  echo baz > f3
+'
+
+test_expect_success 'add test objects' '
+  HASH_FOO=$(ipfsi 0 add -q --local --pin=false f1) &&
+  HASH_BAR=$(ipfsi 0 add -q --local --pin=false f2) &&
+  HASH_BAZ=$(ipfsi 0 add -q --local f3) &&
+  ipfsi 0 files mkdir /dir &&
+  ipfsi 0 files cp /ipfs/$HASH_BAR /dir/bar &&
+  HASH_MFS_DIR=$(ipfsi 0 files stat --hash /dir)
+'
+
+findprovs_empty '$HASH_FOO'
+findprovs_empty '$HASH_BAR'
+findprovs_empty '$HASH_MFS_DIR'
+
+reprovide
+
+findprovs_empty '$HASH_FOO'
+findprovs_expect '$HASH_BAR' '$PEERID_0'
+findprovs_expect '$HASH_BAZ' '$PEERID_0'
+findprovs_expect '$HASH_MFS_DIR' '$PEERID_0'
+
+test_expect_success 'stop peer 1' '
+  iptb stop 1
+'
+
 # Test reprovider working with ticking disabled
 test_expect_success 'init iptb' '
   iptb init -f -n $NUM_NODES --bootstrap=none --port=0
@@ -149,5 +182,21 @@ findprovs_empty '$HASH_0'
 reprovide
 findprovs_expect '$HASH_0' '$PEERID_0'
 
+test_expect_success 'reprovide --progress shows a summary' '
+  ipfsi 0 bitswap reprovide --progress > reprovideOut &&
+  grep "^Provided [0-9]* keys (0 failed) in " reprovideOut
+'
+
+test_expect_success 'added content is announced from the provide queue' '
+  HASH_QUEUED=$(echo "queued" | ipfsi 0 add -q) &&
+  for i in $(test_seq 1 50); do
+    ipfsi 0 provide queue > queueOut &&
+    test_must_be_empty queueOut && break
+    go-sleep 100ms
+  done &&
+  test_must_be_empty queueOut
+'
+
+findprovs_expect '$HASH_QUEUED' '$PEERID_0'
 
 test_done
@@ -25,6 +25,9 @@ bitswap status
   data sent: 0
   dup blocks received: 0
   dup data received: 0 B
+  rate limited: 0
+  peer rate limited: 0
+  peer outstanding limited: 0
   wantlist [0 keys]
   partners [0]
 EOF
@@ -63,6 +66,9 @@ bitswap status
   data sent: 0
   dup blocks received: 0
   dup data received: 0 B
+  rate limited: 0
+  peer rate limited: 0
+  peer outstanding limited: 0
   wantlist [0 keys]
   partners [0]
 EOF
@@ -77,6 +83,27 @@ test_expect_success "'ipfs bitswap wantlist -p' output looks good" '
   test_cmp wantlist_out wantlist_p_out
 '
 
+test_expect_success "'ipfs bitswap ledger' without a peer fails" '
+  test_must_fail ipfs bitswap ledger 2>ledger_err &&
+  grep "a peer ID is required unless --all is given" ledger_err
+'
+
+test_expect_success "'ipfs bitswap ledger --all' works" '
+  ipfs bitswap ledger --all >ledger_all_out
+'
+
+test_expect_success "'ipfs bitswap ledger --all' output is empty without partners" '
+  test_must_be_empty ledger_all_out
+'
+
+test_expect_success "'ipfs bitswap sessions' works" '
+  ipfs bitswap sessions >sessions_out
+'
+
+test_expect_success "'ipfs bitswap sessions' output is empty without sessions" '
+  test_must_be_empty sessions_out
+'
+
 test_kill_ipfs_daemon
 
 test_done
@@ -0,0 +1,88 @@
+#!/bin/sh
+#
+# MIT Licensed; see the LICENSE file in this repository.
+#
+
+test_description="test snapshots of the unix files api"
+
+. lib/test-lib.sh
+
+test_init_ipfs
+
+test_expect_success "create a snapshot" '
+  ipfs files mkdir /adir &&
+  echo "file1" | ipfs files write --create /adir/file1 &&
+  echo "file2" | ipfs files write --create /file2 &&
+  ROOT_HASH=$(ipfs files stat --hash /) &&
+  ipfs files snapshot create --name=first > snap_out &&
+  echo 1 > snap_expected &&
+  test_cmp snap_expected snap_out
+'
+
+test_expect_success "snapshot is listed" '
+  ipfs files snapshot ls > ls_out &&
+  test_line_count = 1 ls_out &&
+  grep "^1	.*	$ROOT_HASH	first$" ls_out
+'
+
+test_expect_success "diff shows changes since the snapshot" '
+  ipfs files rm -r /adir &&
+  echo "file3" | ipfs files write --create /file3 &&
+  ipfs files snapshot diff 1 > diff_out &&
+  grep "^- .* \"adir\"$" diff_out &&
+  grep "^+ .* \"file3\"$" diff_out &&
+  test_line_count = 2 diff_out
+'
+
+test_expect_success "diff between two snapshots" '
+  ipfs files snapshot create --pin > snap_out &&
+  echo 2 > snap_expected &&
+  test_cmp snap_expected snap_out &&
+  ipfs files snapshot diff 1 2 > diff2_out &&
+  test_cmp diff_out diff2_out
+'
+
+test_expect_success "restore a directory from a snapshot" '
+  ipfs files snapshot restore 1 /adir &&
+  ipfs files read /adir/file1 > file1_out &&
+  echo "file1" > file1_expected &&
+  test_cmp file1_expected file1_out &&
+  ipfs files ls / > ls_root_out &&
+  printf "adir\nfile2\nfile3\n" > ls_root_expected &&
+  test_cmp ls_root_expected ls_root_out
+'
+
+test_expect_success "restore the root from a snapshot" '
+  ipfs files snapshot restore 1 &&
+  ipfs files stat --hash / > root_out &&
+  echo $ROOT_HASH > root_expected &&
+  test_cmp root_expected root_out
+'
+
+test_expect_success "pinned snapshot is pinned" '
+  SNAP2_HASH=$(ipfs files snapshot ls | grep "^2	" | cut -f3) &&
+  ipfs pin ls --type=recursive | grep $SNAP2_HASH
+'
+
+test_expect_success "removing a snapshot unpins it" '
+  ipfs files snapshot rm 2 &&
+  test_must_fail ipfs files snapshot diff 2 &&
+  ipfs pin ls --type=recursive > pins_out &&
+  test_must_fail grep $SNAP2_HASH pins_out
+'
+
+test_expect_success "removing a snapshot keeps pins it does not own" '
+  ipfs pin add $ROOT_HASH &&
+  SNAP=$(ipfs files snapshot create --pin) &&
+  ipfs files snapshot ls | grep "^$SNAP	" > ls_out &&
+  test_must_fail grep "(pinned)" ls_out &&
+  ipfs files snapshot rm $SNAP &&
+  ipfs pin ls --type=recursive | grep $ROOT_HASH
+'
+
+test_expect_success "unknown snapshot fails" '
+  test_must_fail ipfs files snapshot restore 42 2> err_out &&
+  grep "no such snapshot" err_out
+'
+
+test_done
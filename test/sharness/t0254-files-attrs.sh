@@ -0,0 +1,105 @@
+#!/bin/sh
+#
+# MIT Licensed; see the LICENSE file in this repository.
+#
+
+test_description="test file modes and modification times"
+
+. lib/test-lib.sh
+
+test_init_ipfs
+
+test_expect_success "create files with a mode and mtime" '
+  mkdir -p dir &&
+  echo "#!/bin/sh" > dir/script &&
+  chmod 0750 dir/script &&
+  touch -t 201707140240.00 dir/script ref
+'
+
+test_expect_success "add with --preserve-mode and --preserve-mtime" '
+  HASH=$(ipfs add -r -Q --preserve-mode --preserve-mtime dir) &&
+  PLAIN=$(ipfs add -r -Q dir) &&
+  test "$HASH" != "$PLAIN"
+'
+
+test_expect_success "stored attributes are shown by files stat" '
+  ipfs files cp /ipfs/$HASH /dir &&
+  ipfs files stat --format="<mode>" /dir/script > stat_out &&
+  echo 0750 > stat_expected &&
+  test_cmp stat_expected stat_out &&
+  ipfs files cp /ipfs/$PLAIN /plain &&
+  ipfs files stat --format="<mode> <mtime>" /plain/script > stat_out &&
+  echo "- -" > stat_expected &&
+  test_cmp stat_expected stat_out
+'
+
+test_expect_success "ipfs get restores the attributes" '
+  ipfs get -o out $HASH &&
+  ls -l out/script | cut -c1-10 > ls_out &&
+  echo "-rwxr-x---" > ls_expected &&
+  test_cmp ls_expected ls_out &&
+  test ! out/script -nt ref &&
+  test ! out/script -ot ref
+'
+
+test_expect_success "ipfs get applies the umask to stored modes" '
+  mkdir open &&
+  echo "data" > open/file &&
+  chmod 0777 open open/file &&
+  OPEN=$(ipfs add -r -Q --preserve-mode open) &&
+  (umask 022 && ipfs get -o open_out $OPEN) &&
+  ls -ld open_out open_out/file | cut -c1-10 > ls_out &&
+  printf "drwxr-xr-x\n-rwxr-xr-x\n" > ls_expected &&
+  test_cmp ls_expected ls_out
+'
+
+test_expect_success "files chmod sets the mode" '
+  ipfs files chmod 0600 /dir/script &&
+  ipfs files stat --format="<mode>" /dir/script > stat_out &&
+  echo 0600 > stat_expected &&
+  test_cmp stat_expected stat_out
+'
+
+test_expect_success "files chmod keeps a mode of 0000" '
+  ipfs files chmod 0 /dir/script &&
+  ipfs files stat --format="<mode>" /dir/script > stat_out &&
+  echo 0000 > stat_expected &&
+  test_cmp stat_expected stat_out &&
+  ipfs files chmod 0600 /dir/script
+'
+
+test_expect_success "files chmod rejects invalid modes" '
+  test_must_fail ipfs files chmod 9 /dir/script &&
+  test_must_fail ipfs files chmod 01777 /dir/script
+'
+
+test_expect_success "files touch sets the mtime" '
+  ipfs files touch --mtime=1500000000 /plain &&
+  TZ=UTC ipfs files stat --format="<mode> <mtime>" /plain > stat_out &&
+  echo "- 2017-07-14T02:40:00Z" > stat_expected &&
+  test_cmp stat_expected stat_out
+'
+
+test_expect_success "writing to a file updates its mtime" '
+  ipfs files touch --mtime=1500000000 /dir/script &&
+  echo "exit 0" | ipfs files write /dir/script &&
+  TZ=UTC ipfs files stat --format="<mode> <mtime>" /dir/script > stat_out &&
+  grep "^0600 " stat_out &&
+  test_must_fail grep "2017-07-14T02:40:00Z" stat_out
+'
+
+test_expect_success "attributes of stdin can not be preserved" '
+  echo "data" | test_must_fail ipfs add --preserve-mode 2> add_err &&
+  grep "can only be preserved when adding local files" add_err
+'
+
+test_launch_ipfs_daemon
+
+test_expect_success "attributes can not be preserved through the daemon" '
+  test_must_fail ipfs add -r --preserve-mode dir 2> add_err &&
+  grep "can only be preserved when adding local files" add_err
+'
+
+test_kill_ipfs_daemon
+
+test_done
@@ -0,0 +1,76 @@
+#!/bin/sh
+#
+# MIT Licensed; see the LICENSE file in this repository.
+#
+
+test_description="test transactions of the unix files api"
+
+. lib/test-lib.sh
+
+test_init_ipfs
+
+test_expect_success "stage changes in a transaction" '
+  echo "report" | ipfs files write --create /inbox &&
+  TX=$(ipfs files tx begin) &&
+  test "$TX" = 1 &&
+  ipfs files mkdir --tx=$TX /archive &&
+  ipfs files mv --tx=$TX /inbox /archive/inbox
+'
+
+test_expect_success "staged changes are only in the transaction" '
+  ipfs files ls / > ls_out &&
+  echo inbox > ls_expected &&
+  test_cmp ls_expected ls_out &&
+  ipfs files ls --tx=$TX / > ls_out &&
+  echo archive > ls_expected &&
+  test_cmp ls_expected ls_out &&
+  ipfs files tx ls > tx_out &&
+  test_line_count = 1 tx_out &&
+  grep "^$TX	" tx_out
+'
+
+test_expect_success "staged content is kept by gc" '
+  HASH=$(echo "staged" | ipfs add -q --pin=false) &&
+  ipfs files cp --tx=$TX /ipfs/$HASH /staged &&
+  ipfs repo gc &&
+  ipfs cat $HASH > cat_out &&
+  echo "staged" > cat_expected &&
+  test_cmp cat_expected cat_out
+'
+
+test_expect_success "commit applies the changes at once" '
+  ipfs files tx commit $TX > commit_out &&
+  ipfs files stat --hash / > root_out &&
+  test_cmp root_out commit_out &&
+  ipfs files ls / > ls_out &&
+  printf "archive\nstaged\n" > ls_expected &&
+  test_cmp ls_expected ls_out &&
+  ipfs files read /archive/inbox > read_out &&
+  echo "report" > read_expected &&
+  test_cmp read_expected read_out
+'
+
+test_expect_success "committed transactions are closed" '
+  ipfs files tx ls > tx_out &&
+  test_line_count = 0 tx_out &&
+  test_must_fail ipfs files ls --tx=$TX / &&
+  test_must_fail ipfs files tx commit $TX
+'
+
+test_expect_success "commit fails if the root changed" '
+  TX=$(ipfs files tx begin) &&
+  ipfs files mkdir --tx=$TX /fromtx &&
+  ipfs files mkdir /outside &&
+  test_must_fail ipfs files tx commit $TX 2> commit_err &&
+  grep "root changed since the transaction began" commit_err &&
+  test_must_fail ipfs files stat /fromtx
+'
+
+test_expect_success "rollback discards the transaction" '
+  ipfs files tx rollback $TX &&
+  ipfs files tx ls > tx_out &&
+  test_line_count = 0 tx_out &&
+  test_must_fail ipfs files stat /fromtx
+'
+
+test_done
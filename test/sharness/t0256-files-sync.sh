@@ -0,0 +1,78 @@
+#!/bin/sh
+#
+# MIT Licensed; see the LICENSE file in this repository.
+#
+
+test_description="test mirroring local directories with the unix files api"
+
+. lib/test-lib.sh
+
+test_init_ipfs
+
+test_expect_success "create a local directory" '
+  mkdir -p local/sub &&
+  echo "one" > local/file1 &&
+  echo "two" > local/sub/file2
+'
+
+test_expect_success "sync adds all files" '
+  ipfs files sync "$(pwd)/local" /mirror > sync_out &&
+  grep "^+ .* \"file1\"$" sync_out &&
+  grep "^+ .* \"sub/file2\"$" sync_out &&
+  echo "2 added, 0 modified, 0 removed" > summary_expected &&
+  tail -n 1 sync_out > summary_out &&
+  test_cmp summary_expected summary_out &&
+  ipfs files read /mirror/sub/file2 > read_out &&
+  echo "two" > read_expected &&
+  test_cmp read_expected read_out
+'
+
+test_expect_success "sync without local changes does nothing" '
+  ipfs files stat --hash /mirror > before_out &&
+  ipfs files sync "$(pwd)/local" /mirror > sync_out &&
+  echo "0 added, 0 modified, 0 removed" > sync_expected &&
+  test_cmp sync_expected sync_out &&
+  ipfs files stat --hash /mirror > after_out &&
+  test_cmp before_out after_out
+'
+
+test_expect_success "sync applies local changes" '
+  echo "one, modified" > local/file1 &&
+  rm -r local/sub &&
+  echo "three" > local/file3 &&
+  ipfs files sync "$(pwd)/local" /mirror > sync_out &&
+  grep "^~ .* \"file1\"$" sync_out &&
+  grep "^- .* \"sub\"$" sync_out &&
+  grep "^+ .* \"file3\"$" sync_out &&
+  echo "1 added, 1 modified, 1 removed" > summary_expected &&
+  tail -n 1 sync_out > summary_out &&
+  test_cmp summary_expected summary_out &&
+  ipfs files ls /mirror > ls_out &&
+  printf "file1\nfile3\n" > ls_expected &&
+  test_cmp ls_expected ls_out &&
+  ipfs files read /mirror/file1 > read_out &&
+  echo "one, modified" > read_expected &&
+  test_cmp read_expected read_out
+'
+
+test_expect_success "sync with --checksum finds no changes" '
+  ipfs files sync --checksum "$(pwd)/local" /mirror > sync_out &&
+  echo "0 added, 0 modified, 0 removed" > sync_expected &&
+  test_cmp sync_expected sync_out
+'
+
+test_expect_success "sync requires an absolute local path" '
+  test_must_fail ipfs files sync local /mirror
+'
+
+test_launch_ipfs_daemon
+
+test_expect_success "sync through the daemon finds no changes" '
+  ipfs files sync "$(pwd)/local" /mirror > sync_out &&
+  echo "0 added, 0 modified, 0 removed" > sync_expected &&
+  test_cmp sync_expected sync_out
+'
+
+test_kill_ipfs_daemon
+
+test_done
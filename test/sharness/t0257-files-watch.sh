@@ -0,0 +1,55 @@
+#!/bin/sh
+#
+# MIT Licensed; see the LICENSE file in this repository.
+#
+
+test_description="test watching changes with the unix files api"
+
+. lib/test-lib.sh
+
+test_init_ipfs
+
+test_launch_ipfs_daemon
+
+test_expect_success "create a directory" '
+  ipfs files mkdir /watched
+'
+
+test_expect_success "start watching the directory" '
+  ipfs files watch /watched > watch_out &
+  WATCH_PID=$! &&
+  sleep 1
+'
+
+test_expect_success "make changes" '
+  echo "hello" | ipfs files write --create /watched/file &&
+  FILE_HASH=$(ipfs files stat --hash /watched/file) &&
+  echo "world" | ipfs files write /watched/file &&
+  WRITE_HASH=$(ipfs files stat --hash /watched/file) &&
+  ipfs files mkdir /elsewhere &&
+  ipfs files mv /watched/file /elsewhere/file &&
+  ipfs files rm -r /elsewhere &&
+  ipfs files rm -r /watched &&
+  sleep 1
+'
+
+test_expect_success "stop watching" '
+  kill $WATCH_PID
+'
+
+test_expect_success "changes under the watched path were streamed" '
+  test_line_count = 5 watch_out &&
+  head -n 1 watch_out | grep "^create /watched/file Qm" &&
+  cat > watch_expected <<-EOF &&
+	write /watched/file $FILE_HASH
+	write /watched/file $WRITE_HASH
+	move /watched/file /elsewhere/file $WRITE_HASH
+	remove /watched
+	EOF
+  tail -n 4 watch_out > watch_tail &&
+  test_cmp watch_expected watch_tail
+'
+
+test_kill_ipfs_daemon
+
+test_done
@@ -8,11 +8,22 @@ import (
 	gopath "path"
 	fp "path/filepath"
 	"strings"
+	"time"
 )
 
 type Extractor struct {
 	Path     string
 	Progress func(int64) int64
+
+	// dirs are the extracted directories, whose mode and modification
+	// time are set once their content is extracted
+	dirs []extractedDir
+}
+
+type extractedDir struct {
+	path  string
+	mode  os.FileMode
+	mtime time.Time
 }
 
 func (te *Extractor) Extract(reader io.Reader) error {
@@ -58,9 +69,28 @@ func (te *Extractor) Extract(reader io.Reader) error {
 			return fmt.Errorf("unrecognized tar header type: %d", header.Typeflag)
 		}
 	}
+
+	// deepest directories first, so that setting the mode of a directory
+	// doesn't prevent setting the attributes of its children
+	for i := len(te.dirs) - 1; i >= 0; i-- {
+		if err := setAttrs(te.dirs[i].path, te.dirs[i].mode, te.dirs[i].mtime); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// umask is the file mode creation mask of the process, which restricts the
+// modes stored in the archive like it restricts the modes of created files.
+var umask os.FileMode
+
+func setAttrs(path string, mode os.FileMode, mtime time.Time) error {
+	if err := os.Chmod(path, mode&^umask); err != nil {
+		return err
+	}
+	return os.Chtimes(path, mtime, mtime)
+}
+
 // outputPath returns the path at whicht o place tarPath
 func (te *Extractor) outputPath(tarPath string) string {
 	elems := strings.Split(tarPath, "/") // break into elems
@@ -79,7 +109,21 @@ func (te *Extractor) extractDir(h *tar.Header, depth int) error {
 		te.Path = path
 	}
 
-	return os.MkdirAll(path, 0755)
+	// leave the attributes of an existing directory we extract into alone
+	if _, err := os.Stat(path); err == nil && depth == 0 {
+		return nil
+	}
+
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return err
+	}
+
+	te.dirs = append(te.dirs, extractedDir{
+		path:  path,
+		mode:  h.FileInfo().Mode().Perm(),
+		mtime: h.ModTime,
+	})
+	return nil
 }
 
 func (te *Extractor) extractSymlink(h *tar.Header) error {
@@ -105,9 +149,16 @@ func (te *Extractor) extractFile(h *tar.Header, r *tar.Reader, depth int, rootEx
 	if err != nil {
 		return err
 	}
-	defer file.Close()
 
-	return copyWithProgress(file, r, te.Progress)
+	err = copyWithProgress(file, r, te.Progress)
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	return setAttrs(path, h.FileInfo().Mode().Perm(), h.ModTime)
 }
 
 func copyWithProgress(to io.Writer, from io.Reader, cb func(int64) int64) error {
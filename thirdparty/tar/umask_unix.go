@@ -0,0 +1,16 @@
+// +build !windows
+
+package tar
+
+import (
+	"os"
+	"syscall"
+)
+
+func init() {
+	// the umask can only be read by setting it, which is done while the
+	// process starts, before files are created
+	mask := syscall.Umask(0)
+	syscall.Umask(mask)
+	umask = os.FileMode(mask)
+}
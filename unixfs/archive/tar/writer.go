@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"os"
 	"path"
 	"time"
 
@@ -36,8 +37,8 @@ func NewWriter(ctx context.Context, dag mdag.DAGService, archive bool, compressi
 	}, nil
 }
 
-func (w *Writer) writeDir(nd *mdag.ProtoNode, fpath string) error {
-	if err := writeDirHeader(w.TarW, fpath); err != nil {
+func (w *Writer) writeDir(nd *mdag.ProtoNode, pb *upb.Data, fpath string) error {
+	if err := writeDirHeader(w.TarW, fpath, ft.Mode(pb), ft.ModTime(pb)); err != nil {
 		return err
 	}
 
@@ -57,7 +58,7 @@ func (w *Writer) writeDir(nd *mdag.ProtoNode, fpath string) error {
 }
 
 func (w *Writer) writeFile(nd *mdag.ProtoNode, pb *upb.Data, fpath string) error {
-	if err := writeFileHeader(w.TarW, fpath, pb.GetFilesize()); err != nil {
+	if err := writeFileHeader(w.TarW, fpath, pb.GetFilesize(), ft.Mode(pb), ft.ModTime(pb)); err != nil {
 		return err
 	}
 
@@ -81,7 +82,7 @@ func (w *Writer) WriteNode(nd node.Node, fpath string) error {
 		case upb.Data_Metadata:
 			fallthrough
 		case upb.Data_Directory:
-			return w.writeDir(nd, fpath)
+			return w.writeDir(nd, pb, fpath)
 		case upb.Data_Raw:
 			fallthrough
 		case upb.Data_File:
@@ -92,7 +93,7 @@ func (w *Writer) WriteNode(nd node.Node, fpath string) error {
 			return ft.ErrUnrecognizedType
 		}
 	case *mdag.RawNode:
-		if err := writeFileHeader(w.TarW, fpath, uint64(len(nd.RawData()))); err != nil {
+		if err := writeFileHeader(w.TarW, fpath, uint64(len(nd.RawData())), nil, time.Time{}); err != nil {
 			return err
 		}
 
@@ -110,24 +111,40 @@ func (w *Writer) Close() error {
 	return w.TarW.Close()
 }
 
-func writeDirHeader(w *tar.Writer, fpath string) error {
+// writeDirHeader writes the header of a directory. A nil mode or zero mtime,
+// for directories that don't store them, is replaced by a default.
+func writeDirHeader(w *tar.Writer, fpath string, mode *os.FileMode, mtime time.Time) error {
+	perm := os.FileMode(0755)
+	if mode != nil {
+		perm = *mode
+	}
+	if mtime.IsZero() {
+		mtime = time.Now()
+	}
 	return w.WriteHeader(&tar.Header{
 		Name:     fpath,
 		Typeflag: tar.TypeDir,
-		Mode:     0777,
-		ModTime:  time.Now(),
-		// TODO: set mode, dates, etc. when added to unixFS
+		Mode:     int64(perm),
+		ModTime:  mtime,
 	})
 }
 
-func writeFileHeader(w *tar.Writer, fpath string, size uint64) error {
+// writeFileHeader writes the header of a file. A nil mode or zero mtime, for
+// files that don't store them, is replaced by a default.
+func writeFileHeader(w *tar.Writer, fpath string, size uint64, mode *os.FileMode, mtime time.Time) error {
+	perm := os.FileMode(0644)
+	if mode != nil {
+		perm = *mode
+	}
+	if mtime.IsZero() {
+		mtime = time.Now()
+	}
 	return w.WriteHeader(&tar.Header{
 		Name:     fpath,
 		Size:     int64(size),
 		Typeflag: tar.TypeReg,
-		Mode:     0644,
-		ModTime:  time.Now(),
-		// TODO: set mode, dates, etc. when added to unixFS
+		Mode:     int64(perm),
+		ModTime:  mtime,
 	})
 }
 
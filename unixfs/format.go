@@ -5,10 +5,16 @@ package unixfs
 
 import (
 	"errors"
+	"fmt"
+	"os"
+	"time"
 
 	dag "github.com/ipfs/go-ipfs/merkledag"
 	pb "github.com/ipfs/go-ipfs/unixfs/pb"
+
+	node "gx/ipfs/QmNwUEK7QbwSqyKBu3mMtToo8SUc6wQJ7gdZq4gGGJqfnf/go-ipld-format"
 	proto "gx/ipfs/QmZ4Qi3GaRbjcx28Sme5eMH7RQjGkt8wHxt2a65oLaeFEV/gogo-protobuf/proto"
+	cid "gx/ipfs/QmeSrf6pzut73u6zLQkRFQ3ygt3k6XFT2kjdYP8Tnkwwyg/go-cid"
 )
 
 const (
@@ -126,6 +132,117 @@ func DataSize(data []byte) (uint64, error) {
 	}
 }
 
+// Mode returns the permission bits stored in pbdata, or nil if none are set.
+func Mode(pbdata *pb.Data) *os.FileMode {
+	if pbdata.Mode == nil {
+		return nil
+	}
+	mode := os.FileMode(pbdata.GetMode()) & os.ModePerm
+	return &mode
+}
+
+// SetMode stores the permission bits of mode in pbdata. A nil mode clears
+// them.
+func SetMode(pbdata *pb.Data, mode *os.FileMode) {
+	if mode == nil {
+		pbdata.Mode = nil
+		return
+	}
+	pbdata.Mode = proto.Uint32(uint32(*mode & os.ModePerm))
+}
+
+// ModTime returns the modification time stored in pbdata, or the zero time
+// if none is set.
+func ModTime(pbdata *pb.Data) time.Time {
+	mtime := pbdata.GetMtime()
+	if mtime == nil {
+		return time.Time{}
+	}
+	return time.Unix(mtime.GetSeconds(), int64(mtime.GetFractionalNanoseconds()))
+}
+
+// SetModTime stores mtime in pbdata. A zero time clears it.
+func SetModTime(pbdata *pb.Data, mtime time.Time) {
+	if mtime.IsZero() {
+		pbdata.Mtime = nil
+		return
+	}
+	pbdata.Mtime = &pb.UnixTime{
+		Seconds: proto.Int64(mtime.Unix()),
+	}
+	if ns := mtime.Nanosecond(); ns != 0 {
+		pbdata.Mtime.FractionalNanoseconds = proto.Uint32(uint32(ns))
+	}
+}
+
+// Attrs returns the mode and modification time of a unixfs node. The mode
+// is nil and the time zero if they aren't set, which is always the case for
+// raw nodes.
+func Attrs(nd node.Node) (*os.FileMode, time.Time, error) {
+	switch nd := nd.(type) {
+	case *dag.ProtoNode:
+		pbdata, err := FromBytes(nd.Data())
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		return Mode(pbdata), ModTime(pbdata), nil
+	case *dag.RawNode:
+		return nil, time.Time{}, nil
+	default:
+		return nil, time.Time{}, ErrUnrecognizedType
+	}
+}
+
+// WithAttrs returns a copy of the unixfs node nd with the given mode and
+// modification time. Raw nodes can't hold either, so they are wrapped in a
+// file node linking to them. A nil mode or zero time clears them.
+func WithAttrs(nd node.Node, mode *os.FileMode, mtime time.Time) (node.Node, error) {
+	switch nd := nd.(type) {
+	case *dag.ProtoNode:
+		pbdata, err := FromBytes(nd.Data())
+		if err != nil {
+			return nil, err
+		}
+		if pbdata.GetType() == pb.Data_HAMTShard {
+			return nil, fmt.Errorf("cannot set the mode or modification time of a sharded directory")
+		}
+
+		SetMode(pbdata, mode)
+		SetModTime(pbdata, mtime)
+		data, err := proto.Marshal(pbdata)
+		if err != nil {
+			return nil, err
+		}
+
+		out := nd.Copy().(*dag.ProtoNode)
+		out.SetData(data)
+		return out, nil
+	case *dag.RawNode:
+		if mode == nil && mtime.IsZero() {
+			return nd, nil
+		}
+
+		size := uint64(len(nd.RawData()))
+		fsn := &FSNode{Type: TFile, Mode: mode, ModTime: mtime}
+		fsn.AddBlockSize(size)
+		data, err := fsn.GetBytes()
+		if err != nil {
+			return nil, err
+		}
+
+		prefix := nd.Cid().Prefix()
+		prefix.Codec = cid.DagProtobuf
+		out := dag.NodeWithData(data)
+		out.SetPrefix(&prefix)
+		if err := out.AddRawLink("", &node.Link{Size: size, Cid: nd.Cid()}); err != nil {
+			return nil, err
+		}
+		return out, nil
+	default:
+		return nil, ErrUnrecognizedType
+	}
+}
+
 type FSNode struct {
 	Data []byte
 
@@ -137,6 +254,10 @@ type FSNode struct {
 
 	// node type of this node
 	Type pb.Data_DataType
+
+	// permission bits and modification time, if set
+	Mode    *os.FileMode
+	ModTime time.Time
 }
 
 func FSNodeFromBytes(b []byte) (*FSNode, error) {
@@ -151,6 +272,8 @@ func FSNodeFromBytes(b []byte) (*FSNode, error) {
 	n.blocksizes = pbn.Blocksizes
 	n.subtotal = pbn.GetFilesize() - uint64(len(n.Data))
 	n.Type = pbn.GetType()
+	n.Mode = Mode(pbn)
+	n.ModTime = ModTime(pbn)
 	return n, nil
 }
 
@@ -171,6 +294,8 @@ func (n *FSNode) GetBytes() ([]byte, error) {
 	pbn.Filesize = proto.Uint64(uint64(len(n.Data)) + n.subtotal)
 	pbn.Blocksizes = n.blocksizes
 	pbn.Data = n.Data
+	SetMode(pbn, n.Mode)
+	SetModTime(pbn, n.ModTime)
 	return proto.Marshal(pbn)
 }
 
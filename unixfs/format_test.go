@@ -2,10 +2,13 @@ package unixfs
 
 import (
 	"bytes"
+	"os"
 	"testing"
+	"time"
 
 	proto "gx/ipfs/QmZ4Qi3GaRbjcx28Sme5eMH7RQjGkt8wHxt2a65oLaeFEV/gogo-protobuf/proto"
 
+	dag "github.com/ipfs/go-ipfs/merkledag"
 	pb "github.com/ipfs/go-ipfs/unixfs/pb"
 )
 
@@ -159,3 +162,85 @@ func TestMetadata(t *testing.T) {
 	}
 
 }
+
+func TestAttrs(t *testing.T) {
+	mtime := time.Unix(1500000000, 12345)
+
+	perm := os.FileMode(0640)
+	fsn := &FSNode{Type: TFile, Data: []byte("data"), Mode: &perm, ModTime: mtime}
+	b, err := fsn.GetBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	nfsn, err := FSNodeFromBytes(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nfsn.Mode == nil || *nfsn.Mode != 0640 || !nfsn.ModTime.Equal(mtime) {
+		t.Fatalf("attributes not kept: %v %s", nfsn.Mode, nfsn.ModTime)
+	}
+
+	dir := EmptyDirNode()
+	mode, mt, err := Attrs(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode != nil || !mt.IsZero() {
+		t.Fatal("expected no attributes on a new directory")
+	}
+
+	perm = 0755
+	nd, err := WithAttrs(dir, &perm, mtime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mode, mt, err = Attrs(nd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode == nil || *mode != 0755 || !mt.Equal(mtime) {
+		t.Fatalf("unexpected attributes: %v %s", mode, mt)
+	}
+	if nd.Cid().Equals(dir.Cid()) {
+		t.Fatal("expected the attributes to change the node")
+	}
+
+	// raw nodes are wrapped in a file node
+	raw := dag.NewRawNode([]byte("raw data"))
+	perm = 0600
+	nd, err = WithAttrs(raw, &perm, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nd.Links()) != 1 || !nd.Links()[0].Cid.Equals(raw.Cid()) {
+		t.Fatal("expected the raw node to be linked")
+	}
+	size, err := DataSize(nd.(*dag.ProtoNode).Data())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != uint64(len(raw.RawData())) {
+		t.Fatalf("expected file size %d, got %d", len(raw.RawData()), size)
+	}
+	mode, mt, err = Attrs(nd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode == nil || *mode != 0600 || !mt.IsZero() {
+		t.Fatalf("unexpected attributes: %v %s", mode, mt)
+	}
+
+	// a mode without any permission bits is kept
+	perm = 0
+	nd, err = WithAttrs(dir, &perm, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mode, _, err = Attrs(nd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode == nil || *mode != 0 {
+		t.Fatalf("expected mode 0000, got %v", mode)
+	}
+}
@@ -6,14 +6,19 @@ import (
 	"io/ioutil"
 	"math/rand"
 	"strings"
+	"sync"
 	"testing"
 
+	exchange "github.com/ipfs/go-ipfs/exchange"
 	mdag "github.com/ipfs/go-ipfs/merkledag"
 	"github.com/ipfs/go-ipfs/unixfs"
 
 	context "context"
 
 	testu "github.com/ipfs/go-ipfs/unixfs/test"
+
+	node "gx/ipfs/QmNwUEK7QbwSqyKBu3mMtToo8SUc6wQJ7gdZq4gGGJqfnf/go-ipld-format"
+	cid "gx/ipfs/QmeSrf6pzut73u6zLQkRFQ3ygt3k6XFT2kjdYP8Tnkwwyg/go-cid"
 )
 
 func TestBasicRead(t *testing.T) {
@@ -302,3 +307,70 @@ func readByte(t testing.TB, reader DagReader) byte {
 
 	return out[0]
 }
+
+// prioRecorder records the priorities nodes are fetched with.
+type prioRecorder struct {
+	mdag.DAGService
+
+	lk    sync.Mutex
+	prios exchange.Priorities
+}
+
+func (r *prioRecorder) GetMany(ctx context.Context, keys []*cid.Cid) <-chan *mdag.NodeOption {
+	r.lk.Lock()
+	for _, k := range keys {
+		if p, ok := exchange.PrioritiesFromContext(ctx).Get(k); ok {
+			r.prios.Set(k, p)
+		}
+	}
+	r.lk.Unlock()
+	return r.DAGService.GetMany(ctx, keys)
+}
+
+func TestReadPriorities(t *testing.T) {
+	dserv := testu.GetDAGServ()
+	inbuf, nd := testu.GetRandomNode(t, dserv, 50000, testu.UseProtoBufLeaves)
+	ctx, closer := context.WithCancel(context.Background())
+	defer closer()
+
+	rec := &prioRecorder{DAGService: dserv, prios: make(exchange.Priorities)}
+	reader, err := NewDagReader(ctx, nd, rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outbuf, err := ioutil.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := testu.ArrComp(inbuf, outbuf); err != nil {
+		t.Fatal(err)
+	}
+
+	// the nodes of the file are read in depth-first order, so their
+	// priorities must not go up in that order
+	last := exchange.MaxPriority
+	var walk func(n node.Node)
+	walk = func(n node.Node) {
+		for _, l := range n.Links() {
+			p, ok := rec.prios.Get(l.Cid)
+			if !ok {
+				t.Fatalf("node %s was fetched without a priority", l.Cid)
+			}
+			if p > last {
+				t.Fatalf("node %s has a higher priority than the data before it", l.Cid)
+			}
+			last = p
+
+			child, err := dserv.Get(ctx, l.Cid)
+			if err != nil {
+				t.Fatal(err)
+			}
+			walk(child)
+		}
+	}
+	walk(nd)
+
+	if last == exchange.MaxPriority {
+		t.Fatal("expected the file to span several blocks")
+	}
+}
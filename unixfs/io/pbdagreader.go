@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 
+	exchange "github.com/ipfs/go-ipfs/exchange"
 	mdag "github.com/ipfs/go-ipfs/merkledag"
 	ft "github.com/ipfs/go-ipfs/unixfs"
 	ftpb "github.com/ipfs/go-ipfs/unixfs/pb"
@@ -41,6 +42,10 @@ type pbDagReader struct {
 	// current offset for the read head within the 'file'
 	offset int64
 
+	// the offset of the node's data within the file being read, when the
+	// node is a child of another
+	fileOffset uint64
+
 	// Our context
 	ctx context.Context
 
@@ -74,11 +79,40 @@ func (dr *pbDagReader) preloadNextNodes(ctx context.Context) {
 		end = len(dr.links)
 	}
 
+	ctx = exchange.WithPriorities(ctx, dr.readPriorities(beg, end))
 	for i, p := range mdag.GetNodes(ctx, dr.serv, dr.links[beg:end]) {
 		dr.promises[beg+i] = p
 	}
 }
 
+// readPriorities returns the priorities to fetch the given range of links
+// with, so that the blocks read first are fetched first.
+func (dr *pbDagReader) readPriorities(beg, end int) exchange.Priorities {
+	if len(dr.pbdata.Blocksizes) != len(dr.links) {
+		return nil
+	}
+
+	prios := make(exchange.Priorities, end-beg)
+	for i := beg; i < end; i++ {
+		prios.Set(dr.links[i], exchange.ReadPriority(dr.linkOffset(i)))
+	}
+	return prios
+}
+
+// linkOffset returns the offset within the file of the data under the i-th
+// link.
+func (dr *pbDagReader) linkOffset(i int) uint64 {
+	if i > len(dr.pbdata.Blocksizes) {
+		i = len(dr.pbdata.Blocksizes)
+	}
+
+	off := dr.fileOffset + uint64(len(dr.pbdata.GetData()))
+	for _, size := range dr.pbdata.Blocksizes[:i] {
+		off += size
+	}
+	return off
+}
+
 // precalcNextBuf follows the next link in line and loads it from the
 // DAGService, setting the next buffer to read from
 func (dr *pbDagReader) precalcNextBuf(ctx context.Context) error {
@@ -100,6 +134,7 @@ func (dr *pbDagReader) precalcNextBuf(ctx context.Context) error {
 		return err
 	}
 	dr.promises[dr.linkPosition] = nil
+	nxtOffset := dr.linkOffset(dr.linkPosition)
 	dr.linkPosition++
 
 	switch nxt := nxt.(type) {
@@ -115,7 +150,9 @@ func (dr *pbDagReader) precalcNextBuf(ctx context.Context) error {
 			// A directory should not exist within a file
 			return ft.ErrInvalidDirLocation
 		case ftpb.Data_File:
-			dr.buf = NewPBFileReader(dr.ctx, nxt, pb, dr.serv)
+			child := NewPBFileReader(dr.ctx, nxt, pb, dr.serv)
+			child.fileOffset = nxtOffset
+			dr.buf = child
 			return nil
 		case ftpb.Data_Raw:
 			dr.buf = NewBufDagReader(pb.GetData())